@@ -6,6 +6,7 @@ import (
 	"recycle-waste-management-backend/src/configuration"
 	ds "recycle-waste-management-backend/src/domain/datasources"
 	"recycle-waste-management-backend/src/gateways"
+	"recycle-waste-management-backend/src/infrastructure/providers"
 	"recycle-waste-management-backend/src/middlewares"
 	repo "recycle-waste-management-backend/src/repositories"
 	sv "recycle-waste-management-backend/src/services"
@@ -37,9 +38,16 @@ func main() {
 	app.Use(middlewares.Logger())
 
 	mongodb := ds.NewMongoDB(10)
+	redisConn := ds.NewRedisConnection()
 
 	// Initialize WebSocket Chat Hub
-	ws.InitChatHub()
+	// CHAT_BROKER=redis shares rooms between replicas; the default keeps them in memory
+	chatBroker := ws.NewMemoryBroker()
+	if os.Getenv("CHAT_BROKER") == "redis" {
+		chatBroker = ws.NewRedisBroker(redisConn)
+	}
+	chatMessageRepo := repo.NewChatMessageRepository(mongodb)
+	ws.InitChatHub(chatBroker, chatMessageRepo, ws.NewWordFilterFromEnv())
 
 	userMongo := repo.NewUsersRepository(mongodb)
 	recycleWastes := repo.NewRecyclableItemsRepository(mongodb)
@@ -49,28 +57,47 @@ func main() {
 	customerRequestRepo := repo.NewCustomerRequestRepository(mongodb)
 	reviewRepo := repo.NewReviewRepository(mongodb)
 	stockRepo := repo.NewStockRepository(mongodb) // Moved up
-
-	userSV := sv.NewUsersService(userMongo)
-	stockSV := sv.NewStockService(stockRepo, recycleWastes)                                // Pass recycleWastes repo
-	recycleWasteSV := sv.NewRecycleWasteService(recycleWastes, categoryWasteRepo, stockSV) // Updated
-	authSV := sv.NewAuthService(userMongo)
+	sessionRepo := repo.NewSessionRepository(mongodb)
+	receiptRepo := repo.NewReceiptRepository(mongodb)
+	shopVerificationRepo := repo.NewShopVerificationRepository(mongodb)
+	twoFactorRepo := repo.NewTwoFactorRepository(mongodb)
+	revokedTokenRepo := repo.NewRevokedTokenRepository(mongodb)
+	otpRepo := repo.NewOTPRepository(redisConn)
+	oauthStateRepo := repo.NewOAuthStateRepository(redisConn)
+	middlewares.SetTokenRevocationList(revokedTokenRepo)
+	middlewares.InitAccessControl(userMongo, shopRepo)
+	apiKeyRepo := repo.NewAPIKeyRepository(mongodb)
+	apiKeySV := sv.NewAPIKeyService(apiKeyRepo)
+	middlewares.SetAPIKeyAuthenticator(apiKeySV)
+
+	auditSV := sv.NewAuditService(repo.NewAuditEventRepository(mongodb))
+	userSV := sv.NewUsersService(userMongo, auditSV)
+	stockSV := sv.NewStockService(stockRepo, recycleWastes, shopRepo) // Pass recycleWastes repo
+	priceHistoryRepo := repo.NewPriceHistoryRepository(mongodb)
+	materialRepo := repo.NewMaterialRepository(mongodb)
+	recycleWasteSV := sv.NewRecycleWasteService(recycleWastes, categoryWasteRepo, stockSV, auditSV, priceHistoryRepo, materialRepo) // Updated
+	employeeRepo := repo.NewEmployeeRepository(mongodb)
+	sessionSV := sv.NewSessionService(sessionRepo, revokedTokenRepo, employeeRepo)
+	loginAttemptRepo := repo.NewLoginAttemptRepository(redisConn)
+	twoFactorSV := sv.NewTwoFactorService(twoFactorRepo, repo.NewLoginChallengeRepository(redisConn), loginAttemptRepo, userMongo, employeeRepo, sessionSV)
+	authSV := sv.NewAuthService(userMongo, twoFactorSV, otpRepo, providers.NewSMSSenderFromEnv(), oauthStateRepo)
 	imageSV := sv.NewImageService()
-	shopSV := sv.NewShopService(shopRepo, reviewRepo)
+	shopSV := sv.NewShopService(shopRepo, reviewRepo, recycleWastes, receiptRepo, stockRepo, employeeRepo, apiKeyRepo, sessionRepo, priceHistoryRepo, sessionSV, auditSV)
 	settingsSV := sv.NewSettingsService(settingsRepo)
 	customerRequestSV := sv.NewCustomerRequestService(customerRequestRepo, shopRepo)
 	reviewSV := sv.NewReviewService(reviewRepo, customerRequestRepo)
+	privacySV := sv.NewPrivacyService(userMongo, settingsRepo, customerRequestRepo, receiptRepo, reviewRepo, chatMessageRepo, shopRepo, shopVerificationRepo, sessionRepo, twoFactorRepo, sessionSV, imageSV, auditSV)
 
-	gateways.NewHTTPGateway(app, userSV, recycleWasteSV, authSV, imageSV, shopSV, settingsSV, customerRequestSV)
+	gateways.NewHTTPGateway(app, userSV, recycleWasteSV, authSV, imageSV, shopSV, settingsSV, customerRequestSV, privacySV)
 
 	// Initialize Review Gateway
 	reviewGateway := gateways.NewReviewGateway(reviewSV)
 	gateways.RouteReview(reviewGateway, app)
 
 	// Initialize Receipt Gateway
-	receiptRepo := repo.NewReceiptRepository(mongodb)
 	receiptItemRepo := repo.NewReceiptItemRepository(mongodb)
 	// stockRepo and stockSV are already initialized above
-	receiptSV := sv.NewReceiptService(receiptRepo, receiptItemRepo, recycleWastes, stockSV, customerRequestRepo, shopRepo, userMongo)
+	receiptSV := sv.NewReceiptService(receiptRepo, receiptItemRepo, recycleWastes, stockSV, customerRequestRepo, shopRepo, userMongo, auditSV)
 	receiptGateway := gateways.NewReceiptGateway(receiptSV)
 	gateways.RouteReceipt(receiptGateway, app)
 
@@ -79,11 +106,47 @@ func main() {
 	gateways.RouteStock(stockGateway, app)
 
 	// Initialize Employee Gateway
-	employeeRepo := repo.NewEmployeeRepository(mongodb)
-	employeeSV := sv.NewEmployeeService(employeeRepo)
-	employeeGateway := gateways.NewEmployeeGateway(employeeSV, shopRepo)
+	employeeSV := sv.NewEmployeeService(employeeRepo, shopRepo, loginAttemptRepo, repo.NewSecurityEventRepository(mongodb), auditSV)
+	employeeGateway := gateways.NewEmployeeGateway(employeeSV, shopRepo, twoFactorSV)
 	gateways.RouteEmployee(employeeGateway, app)
 
+	// Initialize Two-Factor Gateway (before the session routes, whose JWT check covers /api/auth)
+	twoFactorGateway := gateways.NewTwoFactorGateway(twoFactorSV)
+	gateways.RouteTwoFactor(twoFactorGateway, app)
+
+	// Initialize Session Gateway
+	sessionGateway := gateways.NewSessionGateway(sessionSV)
+	gateways.RouteSession(sessionGateway, app)
+
+	// Initialize API Key Gateway
+	apiKeyGateway := gateways.NewAPIKeyGateway(apiKeySV)
+	gateways.RouteAPIKey(apiKeyGateway, app)
+
+	// Initialize Shop Verification Gateway
+	shopVerificationSV := sv.NewShopVerificationService(shopVerificationRepo, shopRepo, userMongo, auditSV)
+	shopVerificationGateway := gateways.NewShopVerificationGateway(shopVerificationSV)
+	gateways.RouteShopVerification(shopVerificationGateway, app)
+
+	// Initialize Chat Gateway
+	chatSV := sv.NewChatService(chatMessageRepo, customerRequestRepo, shopRepo, imageSV)
+	chatGateway := gateways.NewChatGateway(chatSV)
+	gateways.RouteChat(chatGateway, app)
+	gateways.RouteWebSocket(chatGateway, app)
+
+	// Initialize Audit Gateway
+	auditGateway := gateways.NewAuditGateway(auditSV)
+	gateways.RouteAudit(auditGateway, app)
+
+	// Initialize Material Catalog Gateway
+	materialSV := sv.NewMaterialService(materialRepo, recycleWastes, categoryWasteRepo, auditSV)
+	materialGateway := gateways.NewMaterialGateway(materialSV)
+	gateways.RouteMaterial(materialGateway, app)
+
+	// Initialize Category Gateway (after NewHTTPGateway, which serves the public category list)
+	categorySV := sv.NewCategoryWasteService(categoryWasteRepo, recycleWastes, stockRepo, materialRepo, priceHistoryRepo, auditSV)
+	categoryGateway := gateways.NewCategoryGateway(categorySV)
+	gateways.RouteCategoryAdmin(categoryGateway, app)
+
 	PORT := os.Getenv("PORT")
 	if PORT == "" {
 		PORT = "8080"
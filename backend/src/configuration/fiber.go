@@ -1,6 +1,8 @@
 package configuration
 
 import (
+	"os"
+
 	"github.com/goccy/go-json"
 
 	"github.com/gofiber/fiber/v2"
@@ -11,5 +13,7 @@ func NewFiberConfiguration() fiber.Config {
 		AppName:     ")϶ recycle-waste-management-backend ϵ(",
 		JSONEncoder: json.Marshal,
 		JSONDecoder: json.Unmarshal,
+		// Header holding the client IP when running behind a reverse proxy, e.g. X-Forwarded-For
+		ProxyHeader: os.Getenv("PROXY_HEADER"),
 	}
 }
@@ -2,6 +2,7 @@ package datasources
 
 import (
 	"context"
+	"log"
 	"os"
 
 	"github.com/redis/go-redis/v9"
@@ -15,14 +16,29 @@ type RedisConnection struct {
 
 func NewRedisConnection() *RedisConnection {
 
-	opt, _ := redis.ParseURL(os.Getenv("REDIS_URI"))
+	opt, err := redis.ParseURL(os.Getenv("REDIS_URI"))
+	if err != nil {
+		log.Fatal("error parsing REDIS_URI : ", err)
+	}
 	rdb := redis.NewClient(opt)
 
-	optRead, _ := redis.ParseURL(os.Getenv("REDISREAD_URI"))
-	rdbRead := redis.NewClient(optRead)
+	// Reads go to the primary unless a replica is configured
+	rdbRead := rdb
+	if readURI := os.Getenv("REDISREAD_URI"); readURI != "" {
+		optRead, err := redis.ParseURL(readURI)
+		if err != nil {
+			log.Fatal("error parsing REDISREAD_URI : ", err)
+		}
+		rdbRead = redis.NewClient(optRead)
+	}
+
+	ctx := context.Background()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		log.Fatal("error connection redis : ", err)
+	}
 
 	return &RedisConnection{
-		Context:   context.Background(),
+		Context:   ctx,
 		RedisWR:   rdb,
 		RedisRead: rdbRead,
 	}
@@ -0,0 +1,42 @@
+package entities
+
+const (
+	ActorTypeUser     = "user"
+	ActorTypeEmployee = "employee"
+	ActorTypeAPIKey   = "api_key"
+)
+
+// Actor is whoever is making a request: a signed-in user, a shop employee or
+// an integration using a shop API key. Employees and API keys always act for
+// exactly one shop and only hold the permissions (scopes) they were given.
+type Actor struct {
+	ID          string   `json:"id"`
+	Type        string   `json:"type"`
+	ShopID      string   `json:"shop_id,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// ActiveShopID is the shop a user chose with the X-Shop-ID header. It is
+	// only a choice: services check that the user owns it before acting.
+	ActiveShopID string `json:"active_shop_id,omitempty"`
+}
+
+func (a Actor) IsEmployee() bool {
+	return a.Type == ActorTypeEmployee
+}
+
+func (a Actor) IsAPIKey() bool {
+	return a.Type == ActorTypeAPIKey
+}
+
+// IsShopBound reports whether the actor can only ever act for Actor.ShopID
+func (a Actor) IsShopBound() bool {
+	return a.IsEmployee() || a.IsAPIKey()
+}
+
+func (a Actor) HasPermission(permission string) bool {
+	for _, p := range a.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package entities
+
+// APIKeyScopes lists the permissions a shop owner may grant to an API key.
+// Price lists are public and need no key.
+var APIKeyScopes = []string{
+	PermissionReceiptView,
+	PermissionStockView,
+}
+
+// IsAPIKeyScope reports whether the permission may be granted to an API key
+func IsAPIKeyScope(permission string) bool {
+	for _, p := range APIKeyScopes {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"` // Defaults to 90, at most 365
+}
@@ -0,0 +1,60 @@
+package entities
+
+import "time"
+
+// Audited actions
+const (
+	AuditUserRoleChanged = "user.role_changed"
+	AuditWasteUpdated    = "waste.updated"
+	AuditPriceListImport = "waste.price_list_imported"
+	AuditReceiptCreated  = "receipt.created"
+	AuditReceiptVoided   = "receipt.voided"
+	AuditEmployeeCreated = "employee.created"
+	AuditEmployeeUpdated = "employee.updated"
+	AuditEmployeeDeleted = "employee.deleted"
+	AuditShopUpdated     = "shop.updated"
+	AuditShopDeleted     = "shop.deleted"
+	AuditShopArchived    = "shop.archived"
+	AuditUserErased      = "user.erased"
+	AuditMaterialCreated = "material.created"
+	AuditMaterialUpdated = "material.updated"
+	AuditMaterialDeleted = "material.deleted"
+	AuditCategoryCreated = "category.created"
+	AuditCategoryUpdated = "category.updated"
+	AuditCategoryMerged  = "category.merged"
+)
+
+// RequestMeta identifies who made a request and from where, for the audit log
+type RequestMeta struct {
+	Actor     Actor
+	IPAddress string
+}
+
+// AuditRecord describes one change for IAuditService.Record. Before and After
+// are snapshots of the target; only the fields that differ are stored.
+type AuditRecord struct {
+	Action     string
+	ShopID     string // Shop the target belongs to, if any
+	TargetType string
+	TargetID   string
+	Before     interface{}
+	After      interface{}
+}
+
+type AuditQuery struct {
+	ShopID     string
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	From       *time.Time
+	To         *time.Time
+}
+
+// AuditVerifyResult reports whether the audit chain still matches its hashes
+type AuditVerifyResult struct {
+	Valid    bool   `json:"valid"`
+	Checked  int64  `json:"checked"`
+	BrokenAt int64  `json:"broken_at,omitempty"` // Sequence of the first event that does not verify
+	Reason   string `json:"reason,omitempty"`
+}
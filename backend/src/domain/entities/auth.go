@@ -1,16 +1,20 @@
 package entities
 
-type TokenResponseGithub struct {
-	AccessToken string `json:"access_token"`
-	Scope       string `json:"scope"`
-	TokenType   string `json:"token_type"`
-	Error       string `json:"error"`
-	ErrorDesc   string `json:"error_description"`
-	JWT         string `json:"jwt"`
-}
+// OAuth identity providers
+const (
+	OAuthProviderGithub = "github"
+	OAuthProviderLine   = "line"
+	OAuthProviderGoogle = "google" // Firebase Authentication
+)
 
-type TokenResponseGoogle struct {
-	JWT string `json:"jwt"`
+type TokenResponseGithub struct {
+	AccessToken  string `json:"access_token"`
+	Scope        string `json:"scope"`
+	TokenType    string `json:"token_type"`
+	Error        string `json:"error"`
+	ErrorDesc    string `json:"error_description"`
+	JWT          string `json:"jwt"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 type UserGithub struct {
@@ -19,3 +23,63 @@ type UserGithub struct {
 	ImageURL string `json:"avatar_url"`
 	Email    string `json:"email"`
 }
+
+// OAuthToken is the result of exchanging an authorization code with a provider
+type OAuthToken struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	Scope        string `json:"scope"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"` // OpenID Connect providers only
+}
+
+// OAuthIdentity is the provider account a login resolved to
+type OAuthIdentity struct {
+	Provider       string `json:"provider"`
+	ProviderUserID string `json:"provider_user_id"`
+	Username       string `json:"username"`
+	Email          string `json:"email"`
+	ImageURL       string `json:"image_url"`
+}
+
+// OAuthState is remembered between starting a provider login and its callback
+type OAuthState struct {
+	Nonce      string `json:"nonce"`
+	LinkUserID string `json:"link_user_id,omitempty"` // Set when a logged-in user links the provider account
+}
+
+type UserLine struct {
+	UserID      string `json:"userId"`
+	DisplayName string `json:"displayName"`
+	PictureURL  string `json:"pictureUrl"`
+}
+
+// LoginResult is the outcome of a provider login. When the provider account is
+// new but its email matches an existing user, no session is opened; instead
+// LinkToken lets the user either link the account or create a separate one.
+type LoginResult struct {
+	Tokens       *AuthTokens `json:"tokens,omitempty"`
+	Linked       bool        `json:"linked,omitempty"` // The provider account was linked to the logged-in user
+	LinkToken    string      `json:"link_token,omitempty"`
+	Provider     string      `json:"provider,omitempty"`
+	MatchedEmail string      `json:"matched_email,omitempty"` // Masked
+	// Challenge is set instead of Tokens when the user must give a second factor
+	Challenge *TwoFactorChallenge `json:"challenge,omitempty"`
+}
+
+type LinkTokenRequest struct {
+	LinkToken string `json:"link_token"`
+}
+
+type GoogleLoginRequest struct {
+	IDToken string `json:"id_token" form:"id_token"` // Firebase ID token of the signed-in Google user
+}
+
+type LinkGoogleRequest struct {
+	IDToken string `json:"id_token"` // Firebase ID token of the signed-in Google user
+}
+
+type LinkStartResponse struct {
+	AuthorizeURL string `json:"authorize_url"`
+}
@@ -1,6 +1,40 @@
 package entities
 
+import "time"
+
+// CategoryWasteModel is a waste category. Items, materials and stock snapshots
+// refer to it by Name, so renaming and merging rewrite those references.
 type CategoryWasteModel struct {
-	ID   string `json:"id" bson:"_id"`
-	Name string `json:"name" bson:"name"`
+	ID         string    `json:"id" bson:"_id"`
+	CategoryID string    `json:"category_id" bson:"category_id"`
+	Name       string    `json:"name" bson:"name"`
+	NameTH     string    `json:"name_th" bson:"name_th"`
+	NameEN     string    `json:"name_en" bson:"name_en"`
+	IconURL    string    `json:"icon_url,omitempty" bson:"icon_url,omitempty"`
+	SortOrder  int       `json:"sort_order" bson:"sort_order"`
+	Active     bool      `json:"active" bson:"active"` // Inactive categories can't be chosen for items
+	CreatedAt  time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+type CategoryWasteRequest struct {
+	Name   string `json:"name"`
+	NameTH string `json:"name_th"`
+	NameEN string `json:"name_en"`
+}
+
+type ReorderCategoriesRequest struct {
+	CategoryIDs []string `json:"category_ids"` // Every category, in the new order
+}
+
+type MergeCategoryRequest struct {
+	IntoCategoryID string `json:"into_category_id"`
+}
+
+// CategoryMergeResult counts the references moved by a merge
+type CategoryMergeResult struct {
+	Items        int64 `json:"items"`
+	Stocks       int64 `json:"stocks"`
+	Materials    int64 `json:"materials"`
+	PriceHistory int64 `json:"price_history"`
 }
@@ -1,15 +1,73 @@
 package entities
 
+// Chat event types. Typing events are relayed only; delivered/read acknowledge stored messages.
+const (
+	ChatTypeMessage     = "message"
+	ChatTypeJoin        = "join"
+	ChatTypeLeave       = "leave"
+	ChatTypeTypingStart = "typing_start"
+	ChatTypeTypingStop  = "typing_stop"
+	ChatTypeDelivered   = "delivered"
+	ChatTypeRead        = "read"
+	ChatTypeImage       = "image"
+	ChatTypeLocation    = "location"
+	ChatTypePriceOffer  = "price_offer"
+	ChatTypeError       = "error" // Sent only to the client whose frame was rejected
+)
+
+// ChatImageFolder is the S3 folder chat photos are uploaded to, keyed by room ID
+const ChatImageFolder = "chat-images"
+
+type ChatLocation struct {
+	Lat   float64 `json:"lat" bson:"lat"`
+	Lng   float64 `json:"lng" bson:"lng"`
+	Label string  `json:"label,omitempty" bson:"label,omitempty"`
+}
+
+type ChatPriceOffer struct {
+	Amount   float64 `json:"amount" bson:"amount"`
+	Currency string  `json:"currency" bson:"currency"` // Defaults to "THB"
+	Note     string  `json:"note,omitempty" bson:"note,omitempty"`
+}
+
 type ChatMessage struct {
-	Type              string `json:"type"`                // "message", "join", "leave"
-	CustomerRequestID string `json:"customer_request_id"` // Room ID
-	SenderID          string `json:"sender_id"`
-	SenderType        string `json:"sender_type"` // "customer" or "shop"
-	Message           string `json:"message"`
-	Timestamp         string `json:"timestamp"`
+	Type              string          `json:"type"`                  // "message", "image", "location", "price_offer", "join", "leave", "typing_start", "typing_stop", "delivered", "read"
+	MessageID         string          `json:"message_id,omitempty"`  // Set for stored messages
+	MessageIDs        []string        `json:"message_ids,omitempty"` // Acknowledged messages for "delivered" and "read"
+	CustomerRequestID string          `json:"customer_request_id"`   // Room ID
+	SenderID          string          `json:"sender_id"`
+	SenderType        string          `json:"sender_type"` // "customer" or "shop"
+	Message           string          `json:"message"`
+	ImageURL          string          `json:"image_url,omitempty"`
+	Location          *ChatLocation   `json:"location,omitempty"`
+	PriceOffer        *ChatPriceOffer `json:"price_offer,omitempty"`
+	Timestamp         string          `json:"timestamp"`
 }
 
 type ChatMessageRequest struct {
+	Type              string          `json:"type,omitempty"` // Defaults to "message"
+	CustomerRequestID string          `json:"customer_request_id"`
+	Message           string          `json:"message"`               // Text, or an optional caption for attachments
+	MessageIDs        []string        `json:"message_ids,omitempty"` // For "delivered" and "read"
+	ImageURL          string          `json:"image_url,omitempty"`   // For "image", as returned by POST /api/chat/:request_id/images
+	Location          *ChatLocation   `json:"location,omitempty"`    // For "location"
+	PriceOffer        *ChatPriceOffer `json:"price_offer,omitempty"` // For "price_offer"
+}
+
+type ChatRoomUnread struct {
 	CustomerRequestID string `json:"customer_request_id"`
-	Message           string `json:"message"`
+	Unread            int64  `json:"unread"`
+}
+
+type ChatUnreadCountResponse struct {
+	Total int64            `json:"total"`
+	Rooms []ChatRoomUnread `json:"rooms"`
+}
+
+type ReportChatMessageRequest struct {
+	Reason string `json:"reason"`
+}
+
+type ReviewChatReportRequest struct {
+	Action string `json:"action"` // "dismiss" keeps the message, "remove" clears its content
 }
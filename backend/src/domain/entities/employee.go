@@ -3,28 +3,31 @@ package entities
 import "time"
 
 type CreateEmployeeRequest struct {
-	ShopID    string `json:"shop_id" bson:"shop_id"`
-	FirstName string `json:"first_name" bson:"first_name" validate:"required"`
-	LastName  string `json:"last_name" bson:"last_name" validate:"required"`
-	Username  string `json:"username" bson:"username" validate:"required"`
-	Password  string `json:"password" bson:"password" validate:"required,min=6"`
+	ShopID      string   `json:"shop_id" bson:"shop_id"`
+	FirstName   string   `json:"first_name" bson:"first_name" validate:"required"`
+	LastName    string   `json:"last_name" bson:"last_name" validate:"required"`
+	Username    string   `json:"username" bson:"username" validate:"required"`
+	Password    string   `json:"password" bson:"password" validate:"required,min=6"`
+	Permissions []string `json:"permissions,omitempty" bson:"permissions,omitempty"`
 }
 
 type UpdateEmployeeRequest struct {
-	FirstName string `json:"first_name,omitempty" bson:"first_name,omitempty"`
-	LastName  string `json:"last_name,omitempty" bson:"last_name,omitempty"`
-	Username  string `json:"username,omitempty" bson:"username,omitempty"`
-	Password  string `json:"password,omitempty" bson:"password,omitempty"`
+	FirstName   string   `json:"first_name,omitempty" bson:"first_name,omitempty"`
+	LastName    string   `json:"last_name,omitempty" bson:"last_name,omitempty"`
+	Username    string   `json:"username,omitempty" bson:"username,omitempty"`
+	Password    string   `json:"password,omitempty" bson:"password,omitempty"`
+	Permissions []string `json:"permissions,omitempty" bson:"permissions,omitempty"`
 }
 
 type EmployeeResponse struct {
-	EmployeeID string    `json:"employee_id"`
-	ShopID     string    `json:"shop_id"`
-	FirstName  string    `json:"first_name"`
-	LastName   string    `json:"last_name"`
-	Username   string    `json:"username"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	EmployeeID  string    `json:"employee_id"`
+	ShopID      string    `json:"shop_id"`
+	FirstName   string    `json:"first_name"`
+	LastName    string    `json:"last_name"`
+	Username    string    `json:"username"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 type EmployeeListResponse struct {
@@ -0,0 +1,36 @@
+package entities
+
+import "time"
+
+// MaterialModel is an entry of the platform-wide material catalog. Shops map
+// their recyclable items to a material so items can be compared across shops.
+type MaterialModel struct {
+	MaterialID string    `json:"material_id" bson:"material_id"`
+	Code       string    `json:"code" bson:"code"` // Unique, upper case, e.g. PET-CLEAR
+	NameTH     string    `json:"name_th" bson:"name_th"`
+	NameEN     string    `json:"name_en" bson:"name_en"`
+	Category   string    `json:"category" bson:"category"`
+	Aliases    []string  `json:"aliases" bson:"aliases"` // Other names shops use, matched case-insensitively
+	ImageURL   string    `json:"image_url,omitempty" bson:"image_url,omitempty"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Names returns every name the material is known by
+func (m *MaterialModel) Names() []string {
+	names := []string{}
+	for _, name := range append([]string{m.NameTH, m.NameEN}, m.Aliases...) {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+type MaterialRequest struct {
+	Code     string   `json:"code"`
+	NameTH   string   `json:"name_th"`
+	NameEN   string   `json:"name_en"`
+	Category string   `json:"category"`
+	Aliases  []string `json:"aliases"`
+}
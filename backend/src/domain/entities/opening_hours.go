@@ -0,0 +1,43 @@
+package entities
+
+import "time"
+
+// Weekday keys of a WeeklySchedule
+var ScheduleDays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// ScheduleDay returns the WeeklySchedule key of a weekday
+func ScheduleDay(day time.Weekday) string {
+	return ScheduleDays[day]
+}
+
+// OpeningRange is one opening period within a day, in Asia/Bangkok time.
+// Times are "HH:MM"; Close may be "24:00" for midnight. A shop open past
+// midnight uses a range ending at 24:00 and another starting at 00:00.
+type OpeningRange struct {
+	Open  string `json:"open" bson:"open"`
+	Close string `json:"close" bson:"close"`
+}
+
+// WeeklySchedule maps a lowercase weekday ("monday") to its opening ranges.
+// Days without ranges are closed.
+type WeeklySchedule map[string][]OpeningRange
+
+const (
+	ClosureHoliday   = "holiday"
+	ClosureTemporary = "temporary"
+)
+
+// ShopClosure closes a shop for whole days, from StartDate to EndDate inclusive
+type ShopClosure struct {
+	Type      string `json:"type" bson:"type"`             // holiday or temporary
+	StartDate string `json:"start_date" bson:"start_date"` // YYYY-MM-DD, Asia/Bangkok
+	EndDate   string `json:"end_date" bson:"end_date"`     // YYYY-MM-DD, Asia/Bangkok
+	Message   string `json:"message,omitempty" bson:"message,omitempty"`
+}
+
+// ShopOpenStatus is computed from the schedule and closures when a shop is read
+type ShopOpenStatus struct {
+	IsOpenNow     bool       `json:"is_open_now"`
+	NextOpening   *time.Time `json:"next_opening,omitempty"`   // Unset while open or when the shop never opens
+	ClosedMessage string     `json:"closed_message,omitempty"` // Message of today's closure
+}
@@ -0,0 +1,97 @@
+package entities
+
+// Permissions checked by middlewares.RequirePermission. Users get them through
+// their role (RolePermissions); employees carry theirs in the access token.
+const (
+	PermissionProfileManage   = "profile:manage"
+	PermissionUsersRead       = "users:read"
+	PermissionUsersManage     = "users:manage"
+	PermissionUsersAssignRole = "users:assign_role"
+
+	PermissionShopCreate     = "shops:create"
+	PermissionShopManage     = "shops:manage"
+	PermissionShopManageAny  = "shops:manage_any" // Bypasses shop ownership checks
+	PermissionShopVerify     = "shops:verify"     // Reviews shop verification applications
+	PermissionWasteManage    = "wastes:manage"
+	PermissionEmployeeManage = "employees:manage"
+
+	PermissionRequestCreate = "requests:create"
+	PermissionRequestView   = "requests:view"
+	PermissionRequestManage = "requests:manage"
+	PermissionReceiptCreate = "receipts:create"
+	PermissionReceiptView   = "receipts:view"
+	PermissionStockView     = "stock:view"
+
+	PermissionChatUse      = "chat:use"
+	PermissionChatModerate = "chat:moderate"
+
+	PermissionAuditRead = "audit:read" // Reads the audit log of every shop
+
+	PermissionCatalogManage = "catalog:manage" // Maintains the shared material catalog and waste categories
+)
+
+// EmployeePermissions lists every permission a shop owner may grant to an employee
+var EmployeePermissions = []string{
+	PermissionRequestView,
+	PermissionRequestManage,
+	PermissionReceiptCreate,
+	PermissionReceiptView,
+	PermissionStockView,
+	PermissionChatUse, // Chats with the customers of requests assigned to the employee's shop
+}
+
+// DefaultEmployeePermissions is used for employees created without an explicit permission set
+var DefaultEmployeePermissions = EmployeePermissions
+
+var customerPermissions = []string{
+	PermissionProfileManage,
+	PermissionShopCreate,
+	PermissionRequestCreate,
+	PermissionChatUse,
+}
+
+var shopOwnerPermissions = append(append([]string{}, customerPermissions...),
+	PermissionShopManage,
+	PermissionWasteManage,
+	PermissionEmployeeManage,
+	PermissionRequestView,
+	PermissionRequestManage,
+	PermissionReceiptCreate,
+	PermissionReceiptView,
+	PermissionStockView,
+)
+
+var adminPermissions = append(append([]string{}, shopOwnerPermissions...),
+	PermissionUsersRead,
+	PermissionUsersManage,
+	PermissionUsersAssignRole,
+	PermissionShopManageAny,
+	PermissionShopVerify,
+	PermissionChatModerate,
+	PermissionAuditRead,
+	PermissionCatalogManage,
+)
+
+// RolePermissions maps each user role to the permissions it grants
+var RolePermissions = map[UserRole][]string{
+	UserRoleUser:      customerPermissions,
+	UserRoleModerator: shopOwnerPermissions,
+	UserRoleAdmin:     adminPermissions,
+}
+
+// PermissionsForRole returns the permissions of a role. Users without a role are plain users.
+func PermissionsForRole(role string) []string {
+	if permissions, ok := RolePermissions[UserRole(role)]; ok {
+		return permissions
+	}
+	return RolePermissions[UserRoleUser]
+}
+
+func IsEmployeePermission(permission string) bool {
+	for _, p := range EmployeePermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,16 @@
+package entities
+
+type PhoneOTPRequest struct {
+	Phone string `json:"phone"`
+}
+
+type PhoneOTPVerifyRequest struct {
+	Phone string `json:"phone"`
+	Code  string `json:"code"`
+}
+
+type PhoneOTPResponse struct {
+	Phone       string `json:"phone"`        // Normalised E.164 number the code was sent to
+	ExpiresIn   int64  `json:"expires_in"`   // Seconds until the code expires
+	ResendAfter int64  `json:"resend_after"` // Seconds until another code may be requested
+}
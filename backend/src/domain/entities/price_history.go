@@ -0,0 +1,10 @@
+package entities
+
+// MarketPricePoint is the spread of one material's price across shops on a day
+type MarketPricePoint struct {
+	Date  string  `json:"date"` // YYYY-MM-DD
+	Min   float64 `json:"min"`
+	Avg   float64 `json:"avg"`
+	Max   float64 `json:"max"`
+	Shops int     `json:"shops"` // Items priced that day
+}
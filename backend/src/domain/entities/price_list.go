@@ -0,0 +1,51 @@
+package entities
+
+const (
+	PriceListCSV  = "csv"
+	PriceListXLSX = "xlsx"
+)
+
+// PriceListColumns is the header of an exported price list. Imports match
+// columns by header name; waste_id and material_code may be left blank.
+var PriceListColumns = []string{"waste_id", "name", "category", "material_code", "price"}
+
+const (
+	PriceListCreate    = "create"
+	PriceListUpdate    = "update"
+	PriceListUnchanged = "unchanged"
+)
+
+// PriceListChange is what importing one row does to the shop's items
+type PriceListChange struct {
+	Row        int     `json:"row"` // Spreadsheet row number, header is row 1
+	Action     string  `json:"action"`
+	WasteID    string  `json:"waste_id,omitempty"` // Unset for items still to be created
+	Name       string  `json:"name"`
+	Category   string  `json:"category"`
+	MaterialID string  `json:"material_id,omitempty"`
+	OldPrice   float64 `json:"old_price,omitempty"`
+	Price      float64 `json:"price"`
+}
+
+type PriceListRowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// PriceListImportResult previews an import, or reports the applied one.
+// Nothing is applied while any row has an error.
+type PriceListImportResult struct {
+	ShopID  string `json:"shop_id"`
+	DryRun  bool   `json:"dry_run"`
+	Applied bool   `json:"applied"`
+	// Atomic is false when the database could not use a transaction; a failed
+	// import then keeps the rows listed in WrittenRows
+	Atomic      bool                `json:"atomic"`
+	WrittenRows []int               `json:"written_rows,omitempty"`
+	Created     int                 `json:"created"`
+	Updated     int                 `json:"updated"`
+	Unchanged   int                 `json:"unchanged"`
+	Changes     []PriceListChange   `json:"changes"`
+	Errors      []PriceListRowError `json:"errors"`
+}
@@ -0,0 +1,13 @@
+package entities
+
+// ErasedUserID replaces the user ID on records that outlive an erased account,
+// such as the customer request behind a receipt
+const ErasedUserID = "erased-user"
+
+// PrivacyExportZIP is the format query value for a ZIP archive; JSON is the default
+const PrivacyExportZIP = "zip"
+
+// EraseAccountRequest confirms a self-service erasure; Confirm must be "DELETE"
+type EraseAccountRequest struct {
+	Confirm string `json:"confirm"`
+}
@@ -3,16 +3,22 @@ package entities
 import "time"
 
 type Receipt struct {
-	ID                string    `json:"id" bson:"_id,omitempty"`
-	ShopID            string    `json:"shop_id" bson:"shop_id"`
-	PaymentMethod     string    `json:"payment_method" bson:"payment_method"` // e.g., "cash"
-	TotalAmount       float64   `json:"total_amount" bson:"total_amount"`
-	VatRate           float64   `json:"vat_rate" bson:"vat_rate"` // e.g. 0.07
-	VAT               float64   `json:"vat" bson:"vat"`
-	NetTotal          float64   `json:"net_total" bson:"net_total"` // ยอดสุทธิ (Total + VAT)
-	Status            string    `json:"status" bson:"status"`       // เช่น "completed", "cancelled"
-	CustomerRequestID string    `json:"customer_request_id,omitempty" bson:"customer_request_id,omitempty"`
-	CreatedAt         time.Time `json:"created_at" bson:"created_at"`
+	ID                string     `json:"id" bson:"_id,omitempty"`
+	ShopID            string     `json:"shop_id" bson:"shop_id"`
+	PaymentMethod     string     `json:"payment_method" bson:"payment_method"` // e.g., "cash"
+	TotalAmount       float64    `json:"total_amount" bson:"total_amount"`
+	VatRate           float64    `json:"vat_rate" bson:"vat_rate"` // e.g. 0.07
+	VAT               float64    `json:"vat" bson:"vat"`
+	NetTotal          float64    `json:"net_total" bson:"net_total"` // ยอดสุทธิ (Total + VAT)
+	Status            string     `json:"status" bson:"status"`       // เช่น "completed", "cancelled"
+	CustomerRequestID string     `json:"customer_request_id,omitempty" bson:"customer_request_id,omitempty"`
+	CreatedAt         time.Time  `json:"created_at" bson:"created_at"`
+	VoidedAt          *time.Time `json:"voided_at,omitempty" bson:"voided_at,omitempty"`
+	VoidReason        string     `json:"void_reason,omitempty" bson:"void_reason,omitempty"`
+}
+
+type VoidReceiptRequest struct {
+	Reason string `json:"reason"`
 }
 
 type ReceiptItem struct {
@@ -32,3 +38,23 @@ type ReceiptWithDetails struct {
 	CustomerName string `json:"customer_name"`
 	ItemsCount   int    `json:"items_count"`
 }
+
+// OwnerReceiptSummary lists the receipts of every shop of one owner, newest first
+type OwnerReceiptSummary struct {
+	Shops    []ShopReceiptTotal `json:"shops"`
+	Receipts []OwnerReceipt     `json:"receipts"`
+}
+
+// ShopReceiptTotal sums the completed receipts of a shop; voided receipts are left out
+type ShopReceiptTotal struct {
+	ShopID       string  `json:"shop_id"`
+	ShopName     string  `json:"shop_name"`
+	ReceiptCount int     `json:"receipt_count"`
+	TotalAmount  float64 `json:"total_amount"`
+	NetTotal     float64 `json:"net_total"`
+}
+
+type OwnerReceipt struct {
+	ReceiptWithDetails
+	ShopName string `json:"shop_name"`
+}
@@ -5,6 +5,7 @@ import "time"
 type RecyclableItemsModel struct {
 	WasteID    string    `json:"waste_id,omitempty" bson:"waste_id,omitempty"`
 	ShopID     string    `json:"shop_id,omitempty" bson:"shop_id,omitempty"`
+	MaterialID string    `json:"material_id,omitempty" bson:"material_id,omitempty"` // Catalog material the item is sold as
 	Name       string    `json:"name,omitempty" bson:"name,omitempty"`
 	Category   string    `json:"category,omitempty" bson:"category,omitempty"`
 	Price      float64   `json:"price,omitempty" bson:"price,omitempty"`
@@ -12,20 +13,30 @@ type RecyclableItemsModel struct {
 	LastUpdate time.Time `json:"last_update,omitempty" bson:"last_update,omitempty"`
 	Hours      string    `json:"hours,omitempty" bson:"hours,omitempty"`
 	URL        string    `json:"url,omitempty" bson:"url,omitempty"`
+	// SearchTerms indexes the name and category for search, see repositories/search_terms.go
+	SearchTerms []string `json:"-" bson:"search_terms,omitempty"`
 }
 
 // ShopInfo contains information about a shop
 type ShopInfo struct {
-	ShopID       string `json:"shop_id"`
-	ShopName     string `json:"shop_name"`
-	ShopImageURL string `json:"shop_image_url"`
+	ShopID       string  `json:"shop_id"`
+	ShopName     string  `json:"shop_name"`
+	ShopImageURL string  `json:"shop_image_url"`
+	WasteID      string  `json:"waste_id"`
+	Price        float64 `json:"price"` // What this shop pays
 }
 
-// GroupedRecyclableItem represents a product grouped by name with multiple shop_ids
+// GroupedRecyclableItem represents a product grouped by catalog material, or by
+// name for items not mapped to the catalog, with multiple shop_ids
 type GroupedRecyclableItem struct {
+	MaterialID string     `json:"material_id,omitempty"`
+	Code       string     `json:"code,omitempty"`
 	Name       string     `json:"name"`
+	NameEN     string     `json:"name_en,omitempty"`
 	Category   string     `json:"category"`
 	Price      float64    `json:"price"`
+	MinPrice   float64    `json:"min_price"`
+	MaxPrice   float64    `json:"max_price"`
 	LastUpdate time.Time  `json:"last_update"`
 	Hours      string     `json:"hours,omitempty"`
 	URL        string     `json:"url,omitempty"`
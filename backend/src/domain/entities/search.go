@@ -0,0 +1,11 @@
+package entities
+
+// SearchSuggestion is one autocomplete entry for a material name, either
+// from the catalog or a name shops sell items under
+type SearchSuggestion struct {
+	Name       string `json:"name" bson:"name"`
+	MaterialID string `json:"material_id,omitempty" bson:"material_id,omitempty"`
+	Code       string `json:"code,omitempty" bson:"code,omitempty"` // Catalog code of the material
+	Shops      int64  `json:"shops" bson:"shops"`                   // Shops selling items under the name
+	Score      int    `json:"-" bson:"score"`                       // 3 exact, 2 prefix, 1 substring match
+}
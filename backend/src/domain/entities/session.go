@@ -0,0 +1,31 @@
+package entities
+
+import "time"
+
+type AuthTokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"` // Always "Bearer"
+	ExpiresIn    int64  `json:"expires_in"` // Access token lifetime in seconds
+	SessionID    string `json:"session_id"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type SessionResponse struct {
+	SessionID  string    `json:"session_id"`
+	Device     string    `json:"device"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// ClientInfo describes where a login or refresh came from
+type ClientInfo struct {
+	Device    string
+	IPAddress string
+}
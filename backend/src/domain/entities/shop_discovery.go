@@ -0,0 +1,49 @@
+package entities
+
+// GeoPoint is a GeoJSON point, kept on shops for the 2dsphere index
+type GeoPoint struct {
+	Type        string    `json:"type" bson:"type"`
+	Coordinates []float64 `json:"coordinates" bson:"coordinates"` // [longitude, latitude]
+}
+
+// NewGeoPoint returns the point of a latitude and longitude
+func NewGeoPoint(latitude, longitude float64) *GeoPoint {
+	return &GeoPoint{Type: "Point", Coordinates: []float64{longitude, latitude}}
+}
+
+// NearbyShopQuery filters the shop discovery search
+type NearbyShopQuery struct {
+	Latitude      float64
+	Longitude     float64
+	MaxDistanceKm float64
+	MaterialID    string // Catalog material, matches every item mapped to it
+	Material      string // Waste name, matched case-insensitively
+	Category      string
+	MinRating     float64
+	OpenNow       bool
+}
+
+// ShopDistance is a shop found by a geospatial search
+type ShopDistance struct {
+	ShopModel      `bson:",inline"`
+	DistanceMeters float64 `bson:"distance"`
+}
+
+// MaterialPrice is what a shop currently pays for a material
+type MaterialPrice struct {
+	WasteID    string  `json:"waste_id"`
+	MaterialID string  `json:"material_id,omitempty"`
+	Name       string  `json:"name"`
+	Category   string  `json:"category"`
+	Price      float64 `json:"price"`
+}
+
+// NearbyShop is a shop in the discovery results, nearest first
+type NearbyShop struct {
+	ShopModel
+	DistanceKm    float64         `json:"distance_km"`
+	AverageRating float64         `json:"average_rating"`
+	TotalReviews  int64           `json:"total_reviews"`
+	Prices        []MaterialPrice `json:"prices,omitempty"` // Prices of the requested material or category
+	ShopOpenStatus
+}
@@ -0,0 +1,21 @@
+package entities
+
+// ShopVerificationFolder is the S3 folder business documents are uploaded to, keyed by user ID
+const ShopVerificationFolder = "verification-documents"
+
+// ShopVerificationRequest holds the form fields of a verification application.
+// Documents are sent alongside as multipart files.
+type ShopVerificationRequest struct {
+	ShopID             string `json:"shop_id" form:"shop_id"` // Required when the owner has several shops
+	BusinessName       string `json:"business_name" form:"business_name"`
+	RegistrationNumber string `json:"registration_number" form:"registration_number"`
+	ContactName        string `json:"contact_name" form:"contact_name"`
+	ContactPhone       string `json:"contact_phone" form:"contact_phone"`
+	Address            string `json:"address" form:"address"`
+	Note               string `json:"note" form:"note"`
+}
+
+type ReviewShopVerificationRequest struct {
+	Action string `json:"action"` // "approve" or "reject"
+	Reason string `json:"reason"` // Required when rejecting
+}
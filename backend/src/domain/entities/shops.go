@@ -3,51 +3,85 @@ package entities
 import "time"
 
 type ShopModel struct {
-	ShopID      string    `json:"shop_id,omitempty" bson:"shop_id,omitempty"`
-	UserID      string    `json:"user_id,omitempty" bson:"user_id,omitempty"`
-	ShopCode    string    `json:"shop_code,omitempty" bson:"shop_code,omitempty"`
-	Name        string    `json:"name,omitempty" bson:"name,omitempty"`
-	Description string    `json:"description,omitempty" bson:"description,omitempty"`
-	Address     string    `json:"address,omitempty" bson:"address,omitempty"`
-	Phone       string    `json:"phone,omitempty" bson:"phone,omitempty"`
-	Email       string    `json:"email,omitempty" bson:"email,omitempty"`
-	ImageURL    string    `json:"image_url,omitempty" bson:"image_url,omitempty"`
-	OpeningTime string    `json:"opening_time,omitempty" bson:"opening_time,omitempty"`
-	ClosingTime string    `json:"closing_time,omitempty" bson:"closing_time,omitempty"`
-	Latitude    float64   `json:"latitude,omitempty" bson:"latitude,omitempty"`
-	Longitude   float64   `json:"longitude,omitempty" bson:"longitude,omitempty"`
-	CreatedAt   time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
-	UpdatedAt   time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+	ShopID       string         `json:"shop_id,omitempty" bson:"shop_id,omitempty"`
+	UserID       string         `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	ShopCode     string         `json:"shop_code,omitempty" bson:"shop_code,omitempty"`
+	Name         string         `json:"name,omitempty" bson:"name,omitempty"`
+	Description  string         `json:"description,omitempty" bson:"description,omitempty"`
+	Address      string         `json:"address,omitempty" bson:"address,omitempty"`
+	Phone        string         `json:"phone,omitempty" bson:"phone,omitempty"`
+	Email        string         `json:"email,omitempty" bson:"email,omitempty"`
+	ImageURL     string         `json:"image_url,omitempty" bson:"image_url,omitempty"`
+	OpeningHours WeeklySchedule `json:"opening_hours,omitempty" bson:"opening_hours,omitempty"`
+	Closures     []ShopClosure  `json:"closures,omitempty" bson:"closures,omitempty"`
+	Latitude     float64        `json:"latitude,omitempty" bson:"latitude,omitempty"`
+	Longitude    float64        `json:"longitude,omitempty" bson:"longitude,omitempty"`
+	Location     *GeoPoint      `json:"-" bson:"location,omitempty"`        // Mirrors latitude/longitude for geospatial queries
+	Verified     bool           `json:"verified" bson:"verified,omitempty"` // Set only through SetVerified
+	VerifiedAt   *time.Time     `json:"verified_at,omitempty" bson:"verified_at,omitempty"`
+	ArchivedAt   *time.Time     `json:"archived_at,omitempty" bson:"archived_at,omitempty"` // Set only through Archive
+	CreatedAt    time.Time      `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt    time.Time      `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
 }
 
+const (
+	ShopDeletionArchive = "archive" // The shop has receipts, so it is hidden but kept
+	ShopDeletionPurge   = "purge"   // The shop and everything belonging to it is removed
+)
+
+// ShopDeletionSummary describes what deleting a shop does, or did
+type ShopDeletionSummary struct {
+	ShopID          string `json:"shop_id"`
+	ShopName        string `json:"shop_name"`
+	OwnerID         string `json:"owner_id"`
+	Action          string `json:"action"` // archive or purge
+	DryRun          bool   `json:"dry_run"`
+	Receipts        int64  `json:"receipts"`
+	RecyclableItems int    `json:"recyclable_items"`
+	Stocks          int    `json:"stocks"`
+	Employees       int    `json:"employees"`
+	APIKeys         int    `json:"api_keys"`
+	Images          int    `json:"images"` // Images removed from storage on purge
+}
+
+// ActiveShopHeader selects which of their shops an owner acts for
+const ActiveShopHeader = "X-Shop-ID"
+
 type CreateShopRequest struct {
-	ShopCode    string  `json:"shop_code" validate:"required,max=12"`
-	Name        string  `json:"name" validate:"required"`
-	Description string  `json:"description"`
-	Address     string  `json:"address" validate:"required"`
-	Phone       string  `json:"phone"`
-	Email       string  `json:"email" validate:"omitempty,email"`
-	OpeningTime string  `json:"opening_time"`
-	ClosingTime string  `json:"closing_time"`
-	Latitude    float64 `json:"latitude"`
-	Longitude   float64 `json:"longitude"`
+	ShopCode     string         `json:"shop_code" validate:"required,max=12"`
+	Name         string         `json:"name" validate:"required"`
+	Description  string         `json:"description"`
+	Address      string         `json:"address" validate:"required"`
+	Phone        string         `json:"phone"`
+	Email        string         `json:"email" validate:"omitempty,email"`
+	OpeningHours WeeklySchedule `json:"opening_hours"`
+	Closures     []ShopClosure  `json:"closures"`
+	Latitude     float64        `json:"latitude"`
+	Longitude    float64        `json:"longitude"`
 }
 
 type UpdateShopRequest struct {
-	ShopCode    *string  `json:"shop_code,omitempty" validate:"omitempty,max=12"`
-	Name        *string  `json:"name,omitempty"`
-	Description *string  `json:"description,omitempty"`
-	Address     *string  `json:"address,omitempty"`
-	Phone       *string  `json:"phone,omitempty"`
-	Email       *string  `json:"email,omitempty"`
-	OpeningTime *string  `json:"opening_time,omitempty"`
-	ClosingTime *string  `json:"closing_time,omitempty"`
-	Latitude    *float64 `json:"latitude,omitempty"`
-	Longitude   *float64 `json:"longitude,omitempty"`
+	ShopCode     *string         `json:"shop_code,omitempty" validate:"omitempty,max=12"`
+	Name         *string         `json:"name,omitempty"`
+	Description  *string         `json:"description,omitempty"`
+	Address      *string         `json:"address,omitempty"`
+	Phone        *string         `json:"phone,omitempty"`
+	Email        *string         `json:"email,omitempty"`
+	OpeningHours *WeeklySchedule `json:"opening_hours,omitempty"`
+	Closures     *[]ShopClosure  `json:"closures,omitempty"`
+	Latitude     *float64        `json:"latitude,omitempty"`
+	Longitude    *float64        `json:"longitude,omitempty"`
 }
 
 type ShopResponse struct {
 	ShopModel
 	AverageRating float64 `json:"average_rating"`
 	TotalReviews  int64   `json:"total_reviews"`
+	ShopOpenStatus
+}
+
+// ShopListItem is a shop in the shop list with its open status
+type ShopListItem struct {
+	ShopModel
+	ShopOpenStatus
 }
@@ -26,3 +26,23 @@ type StockWithDetails struct {
 	CurrentPrice  float64   `json:"current_price"`  // ราคาปัจจุบัน (จาก Waste Live Data)
 	Profit        float64   `json:"profit"`         // ส่วนต่าง (กำไร/ขาดทุน)
 }
+
+// OwnerStockSummary is the stock of every shop of one owner
+type OwnerStockSummary struct {
+	Shops  []ShopStock  `json:"shops"`
+	Totals []StockTotal `json:"totals"` // Summed across shops by category and name
+}
+
+type ShopStock struct {
+	ShopID   string             `json:"shop_id"`
+	ShopName string             `json:"shop_name"`
+	Stocks   []StockWithDetails `json:"stocks"`
+}
+
+type StockTotal struct {
+	Category string  `json:"category"`
+	Name     string  `json:"name"`
+	Quantity float64 `json:"quantity"`
+	Value    float64 `json:"value"` // Quantity at current prices
+	Profit   float64 `json:"profit"`
+}
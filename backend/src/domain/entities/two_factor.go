@@ -0,0 +1,54 @@
+package entities
+
+// LoginChallenge is a login that passed the first factor and still needs a
+// two-factor code. SetupRequired is set for admins who have not enrolled yet;
+// they enroll with the challenge token before the login completes.
+type LoginChallenge struct {
+	Actor         Actor      `json:"actor"`
+	Client        ClientInfo `json:"client"`
+	SetupRequired bool       `json:"setup_required,omitempty"`
+}
+
+// TwoFactorChallenge is returned instead of tokens when a second factor is needed
+type TwoFactorChallenge struct {
+	MFAToken      string `json:"mfa_token"`
+	SetupRequired bool   `json:"setup_required"`
+	ExpiresIn     int64  `json:"expires_in"`
+}
+
+type TwoFactorStatus struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"` // Admins cannot turn it off
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"` // Base32, for entering the key by hand
+	OTPAuthURI string `json:"otpauth_uri"`
+	QRCode     string `json:"qr_code"` // PNG data URL of OTPAuthURI
+}
+
+type TwoFactorRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"` // Shown once; each code works one time
+}
+
+// TwoFactorCodeRequest carries a code from the authenticator app, or a recovery code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type TwoFactorLoginRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type TwoFactorSetupRequest struct {
+	MFAToken string `json:"mfa_token"`
+}
+
+// TwoFactorLoginResponse completes a challenged login. RecoveryCodes is only
+// set when the login enrolled the second factor.
+type TwoFactorLoginResponse struct {
+	Tokens        *AuthTokens `json:"tokens"`
+	RecoveryCodes []string    `json:"recovery_codes,omitempty"`
+}
@@ -8,7 +8,7 @@ type UserRole string
 const (
 	UserRoleUser      UserRole = "user"
 	UserRoleAdmin     UserRole = "admin"
-	UserRoleModerator UserRole = "moderator"
+	UserRoleModerator UserRole = "moderator" // Verified shop owner, granted by approving a shop verification
 )
 
 type NewUserBody struct {
@@ -17,12 +17,21 @@ type NewUserBody struct {
 }
 
 type UserDataFormat struct {
-	UserID    string    `json:"user_id,omitempty" bson:"user_id,omitempty"`
-	Username  string    `json:"username,omitempty" bson:"username,omitempty"`
-	Email     string    `json:"email,omitempty" bson:"email,omitempty"`
-	ImageURL  string    `json:"image_url,omitempty" bson:"image_url,omitempty"`
-	JWT       string    `json:"jwt,omitempty" bson:"jwt,omitempty"`
-	Role      string    `json:"role,omitempty" bson:"role,omitempty"` // User role: admin, user, moderator, etc.
-	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
-	LastLogin time.Time `json:"last_login,omitempty" bson:"last_login,omitempty"`
+	UserID     string           `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	Username   string           `json:"username,omitempty" bson:"username,omitempty"`
+	Email      string           `json:"email,omitempty" bson:"email,omitempty"`
+	Phone      string           `json:"phone,omitempty" bson:"phone,omitempty"`           // E.164, set by phone OTP login
+	Identities []LinkedIdentity `json:"identities,omitempty" bson:"identities,omitempty"` // Provider accounts that log in as this user
+	ImageURL   string           `json:"image_url,omitempty" bson:"image_url,omitempty"`
+	Role       string           `json:"role,omitempty" bson:"role,omitempty"` // User role: admin, user, moderator, etc.
+	CreatedAt  time.Time        `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	LastLogin  time.Time        `json:"last_login,omitempty" bson:"last_login,omitempty"`
+}
+
+// LinkedIdentity is a provider account (GitHub, Google, LINE) attached to a user
+type LinkedIdentity struct {
+	Provider       string    `json:"provider" bson:"provider"`
+	ProviderUserID string    `json:"provider_user_id" bson:"provider_user_id"`
+	Email          string    `json:"email,omitempty" bson:"email,omitempty"`
+	LinkedAt       time.Time `json:"linked_at" bson:"linked_at"`
 }
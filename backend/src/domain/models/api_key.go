@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// APIKey lets an integration call the API on behalf of one shop. Only the
+// SHA-256 of the key is stored; Prefix is the visible start of the key so
+// owners can tell their keys apart.
+type APIKey struct {
+	KeyID      string     `json:"key_id" bson:"key_id"`
+	ShopID     string     `json:"shop_id" bson:"shop_id"`
+	Name       string     `json:"name" bson:"name"`
+	Prefix     string     `json:"prefix" bson:"prefix"`
+	KeyHash    string     `json:"-" bson:"key_hash"`
+	Scopes     []string   `json:"scopes" bson:"scopes"` // Permissions granted to the key
+	CreatedBy  string     `json:"created_by" bson:"created_by"`
+	CreatedAt  time.Time  `json:"created_at" bson:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at" bson:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// AuditEvent is an entry of the append-only audit log. Hash covers every
+// other field and PrevHash, the Hash of the event before it, so editing or
+// removing an event breaks the chain from that point on.
+type AuditEvent struct {
+	Sequence   int64         `json:"sequence" bson:"sequence"`
+	EventID    string        `json:"event_id" bson:"event_id"`
+	Action     string        `json:"action" bson:"action"`
+	ShopID     string        `json:"shop_id,omitempty" bson:"shop_id,omitempty"`
+	ActorID    string        `json:"actor_id" bson:"actor_id"`
+	ActorType  string        `json:"actor_type" bson:"actor_type"`
+	IPAddress  string        `json:"ip_address" bson:"ip_address"`
+	TargetType string        `json:"target_type" bson:"target_type"`
+	TargetID   string        `json:"target_id" bson:"target_id"`
+	Changes    []AuditChange `json:"changes" bson:"changes"`
+	CreatedAt  time.Time     `json:"created_at" bson:"created_at"`
+	PrevHash   string        `json:"prev_hash" bson:"prev_hash"`
+	Hash       string        `json:"hash" bson:"hash"`
+}
+
+// AuditChange is one changed field. Values are JSON encoded so they hash the
+// same after a round trip through MongoDB.
+type AuditChange struct {
+	Field  string `json:"field" bson:"field"`
+	Before string `json:"before,omitempty" bson:"before,omitempty"`
+	After  string `json:"after,omitempty" bson:"after,omitempty"`
+}
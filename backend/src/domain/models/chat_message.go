@@ -0,0 +1,39 @@
+package models
+
+import (
+	"recycle-waste-management-backend/src/domain/entities"
+	"time"
+)
+
+type ChatMessageModel struct {
+	MessageID         string                   `json:"message_id" bson:"message_id"`
+	CustomerRequestID string                   `json:"customer_request_id" bson:"customer_request_id"` // Room ID
+	SenderID          string                   `json:"sender_id" bson:"sender_id"`
+	SenderType        string                   `json:"sender_type" bson:"sender_type"`
+	Type              string                   `json:"type" bson:"type"` // "message", "image", "location" or "price_offer"
+	Message           string                   `json:"message" bson:"message"`
+	ImageURL          string                   `json:"image_url,omitempty" bson:"image_url,omitempty"`
+	Location          *entities.ChatLocation   `json:"location,omitempty" bson:"location,omitempty"`
+	PriceOffer        *entities.ChatPriceOffer `json:"price_offer,omitempty" bson:"price_offer,omitempty"`
+	DeliveredTo       []string                 `json:"delivered_to" bson:"delivered_to"` // User IDs that received the message
+	ReadBy            []string                 `json:"read_by" bson:"read_by"`           // User IDs that read the message
+	CreatedAt         time.Time                `json:"created_at" bson:"created_at"`
+
+	// Moderation
+	Reports      []ChatMessageReport `json:"reports,omitempty" bson:"reports,omitempty"`
+	ReviewStatus string              `json:"review_status,omitempty" bson:"review_status,omitempty"` // "pending", "dismissed" or "removed"
+	ReviewedBy   string              `json:"reviewed_by,omitempty" bson:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time          `json:"reviewed_at,omitempty" bson:"reviewed_at,omitempty"`
+}
+
+const (
+	ChatReviewPending   = "pending"
+	ChatReviewDismissed = "dismissed"
+	ChatReviewRemoved   = "removed"
+)
+
+type ChatMessageReport struct {
+	ReporterID string    `json:"reporter_id" bson:"reporter_id"`
+	Reason     string    `json:"reason" bson:"reason"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+}
@@ -1,14 +1,32 @@
 package models
 
-import "time"
+import (
+	"recycle-waste-management-backend/src/domain/entities"
+	"time"
+)
 
 type Employee struct {
-	EmployeeID string    `json:"employee_id,omitempty" bson:"employee_id,omitempty"`
-	ShopID     string    `json:"shop_id,omitempty" bson:"shop_id,omitempty"`
-	FirstName  string    `json:"first_name,omitempty" bson:"first_name,omitempty"`
-	LastName   string    `json:"last_name,omitempty" bson:"last_name,omitempty"`
-	Username   string    `json:"username,omitempty" bson:"username,omitempty"`
-	Password   string    `json:"password,omitempty" bson:"password,omitempty"` // Hashed password
-	CreatedAt  time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
-	UpdatedAt  time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+	EmployeeID  string    `json:"employee_id,omitempty" bson:"employee_id,omitempty"`
+	ShopID      string    `json:"shop_id,omitempty" bson:"shop_id,omitempty"`
+	FirstName   string    `json:"first_name,omitempty" bson:"first_name,omitempty"`
+	LastName    string    `json:"last_name,omitempty" bson:"last_name,omitempty"`
+	Username    string    `json:"username,omitempty" bson:"username,omitempty"`
+	Password    string    `json:"password,omitempty" bson:"password,omitempty"`       // Hashed password
+	Permissions []string  `json:"permissions,omitempty" bson:"permissions,omitempty"` // Empty means entities.DefaultEmployeePermissions
+	CreatedAt   time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// Actor describes the employee as the acting party of a request, bound to their shop
+func (e *Employee) Actor() entities.Actor {
+	permissions := e.Permissions
+	if len(permissions) == 0 {
+		permissions = entities.DefaultEmployeePermissions
+	}
+	return entities.Actor{
+		ID:          e.EmployeeID,
+		Type:        entities.ActorTypeEmployee,
+		ShopID:      e.ShopID,
+		Permissions: permissions,
+	}
 }
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// PriceHistoryEntry snapshots a recyclable item whenever its price, name or
+// category changes. Removed marks the point the item stopped being offered.
+type PriceHistoryEntry struct {
+	WasteID    string    `json:"waste_id" bson:"waste_id"`
+	ShopID     string    `json:"shop_id" bson:"shop_id"`
+	MaterialID string    `json:"material_id,omitempty" bson:"material_id,omitempty"`
+	Name       string    `json:"name" bson:"name"`
+	Category   string    `json:"category" bson:"category"`
+	Price      float64   `json:"price" bson:"price"`
+	Removed    bool      `json:"removed,omitempty" bson:"removed,omitempty"`
+	ChangedAt  time.Time `json:"changed_at" bson:"changed_at"`
+}
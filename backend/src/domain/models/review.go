@@ -13,3 +13,10 @@ type ReviewModel struct {
 	CreatedAt         time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at" bson:"updated_at"`
 }
+
+// ShopRatingStats is the rating summary of one shop
+type ShopRatingStats struct {
+	ShopID        string  `bson:"_id"`
+	AverageRating float64 `bson:"average_rating"`
+	TotalReviews  int64   `bson:"total_reviews"`
+}
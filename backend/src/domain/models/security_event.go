@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// SecurityEvent is an alert shown to a shop owner, e.g. repeated failed
+// logins on one of their employee accounts
+type SecurityEvent struct {
+	EventID        string    `json:"event_id" bson:"event_id"`
+	ShopID         string    `json:"shop_id" bson:"shop_id"`
+	Type           string    `json:"type" bson:"type"`
+	EmployeeID     string    `json:"employee_id,omitempty" bson:"employee_id,omitempty"`
+	Username       string    `json:"username,omitempty" bson:"username,omitempty"`
+	IPAddress      string    `json:"ip_address,omitempty" bson:"ip_address,omitempty"`
+	FailedAttempts int64     `json:"failed_attempts,omitempty" bson:"failed_attempts,omitempty"`
+	LockedUntil    time.Time `json:"locked_until,omitempty" bson:"locked_until,omitempty"`
+	CreatedAt      time.Time `json:"created_at" bson:"created_at"`
+}
+
+const SecurityEventEmployeeLoginLocked = "employee_login_locked"
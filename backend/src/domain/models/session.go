@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Session is one signed-in device. It owns a rotating refresh token and
+// remembers the jti of the last access token issued for it, so that token
+// can be revoked on logout.
+type Session struct {
+	SessionID    string     `json:"session_id" bson:"session_id"`
+	SubjectID    string     `json:"subject_id" bson:"subject_id"`     // user_id or employee_id
+	SubjectType  string     `json:"subject_type" bson:"subject_type"` // entities.ActorTypeUser or entities.ActorTypeEmployee
+	RefreshHash  string     `json:"-" bson:"refresh_hash"`            // SHA-256 of the current refresh token
+	PreviousHash string     `json:"-" bson:"previous_hash,omitempty"` // SHA-256 of the token it replaced, for reuse detection
+	AccessJTI    string     `json:"-" bson:"access_jti"`
+	AccessExpiry time.Time  `json:"-" bson:"access_expiry"`
+	Device       string     `json:"device" bson:"device"`
+	IPAddress    string     `json:"ip_address" bson:"ip_address"`
+	CreatedAt    time.Time  `json:"created_at" bson:"created_at"`
+	LastUsedAt   time.Time  `json:"last_used_at" bson:"last_used_at"`
+	ExpiresAt    time.Time  `json:"expires_at" bson:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+// RevokedToken is an access token jti that must be rejected until it expires
+type RevokedToken struct {
+	JTI       string    `json:"jti" bson:"jti"`
+	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// ShopVerification is an application by a shop owner to have their shop
+// verified. Approval grants the applicant the shop owner role.
+type ShopVerification struct {
+	ApplicationID      string                 `json:"application_id" bson:"application_id"`
+	UserID             string                 `json:"user_id" bson:"user_id"`
+	ShopID             string                 `json:"shop_id" bson:"shop_id"`
+	Status             string                 `json:"status" bson:"status"` // "pending", "approved" or "rejected"
+	BusinessName       string                 `json:"business_name" bson:"business_name"`
+	RegistrationNumber string                 `json:"registration_number" bson:"registration_number"` // Company or tax ID
+	ContactName        string                 `json:"contact_name" bson:"contact_name"`
+	ContactPhone       string                 `json:"contact_phone" bson:"contact_phone"`
+	Address            string                 `json:"address" bson:"address"`
+	Note               string                 `json:"note,omitempty" bson:"note,omitempty"`
+	Documents          []VerificationDocument `json:"documents" bson:"documents"`
+	ReviewedBy         string                 `json:"reviewed_by,omitempty" bson:"reviewed_by,omitempty"`
+	ReviewedAt         *time.Time             `json:"reviewed_at,omitempty" bson:"reviewed_at,omitempty"`
+	RejectReason       string                 `json:"reject_reason,omitempty" bson:"reject_reason,omitempty"`
+	CreatedAt          time.Time              `json:"created_at" bson:"created_at"`
+	UpdatedAt          time.Time              `json:"updated_at" bson:"updated_at"`
+}
+
+const (
+	ShopVerificationPending  = "pending"
+	ShopVerificationApproved = "approved"
+	ShopVerificationRejected = "rejected"
+)
+
+// VerificationDocument is a business document stored privately in S3
+type VerificationDocument struct {
+	DocumentID  string    `json:"document_id" bson:"document_id"`
+	Type        string    `json:"type" bson:"type"` // e.g. "business_registration", "id_card"
+	Filename    string    `json:"filename" bson:"filename"`
+	ContentType string    `json:"content_type" bson:"content_type"`
+	Size        int64     `json:"size" bson:"size"`
+	Key         string    `json:"-" bson:"key"`           // S3 key, never exposed directly
+	URL         string    `json:"url,omitempty" bson:"-"` // Short-lived presigned link for reviewers
+	UploadedAt  time.Time `json:"uploaded_at" bson:"uploaded_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// TwoFactor is the TOTP (RFC 6238) second factor of a user or employee. It is
+// stored when enrollment starts and only enforced once Enabled is set by a
+// first valid code.
+type TwoFactor struct {
+	SubjectID     string     `json:"subject_id" bson:"subject_id"`     // user_id or employee_id
+	SubjectType   string     `json:"subject_type" bson:"subject_type"` // entities.ActorTypeUser or entities.ActorTypeEmployee
+	Secret        string     `json:"-" bson:"secret"`                  // AES-GCM encrypted base32 secret
+	Enabled       bool       `json:"enabled" bson:"enabled"`
+	RecoveryCodes []string   `json:"-" bson:"recovery_codes"` // SHA-256 of the unused recovery codes
+	LastUsedStep  int64      `json:"-" bson:"last_used_step"` // Time step of the last accepted code, so a code works once
+	CreatedAt     time.Time  `json:"created_at" bson:"created_at"`
+	EnabledAt     *time.Time `json:"enabled_at,omitempty" bson:"enabled_at,omitempty"`
+}
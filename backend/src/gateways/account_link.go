@@ -0,0 +1,116 @@
+package gateways
+
+import (
+	"errors"
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/middlewares"
+	"recycle-waste-management-backend/src/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetIdentities handles GET /api/user/identities
+func (h *HTTPGateway) GetIdentities(ctx *fiber.Ctx) error {
+	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	identities, err := h.AuthService.GetIdentities(tokenDetails.UserID)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot get linked accounts"})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: identities})
+}
+
+// StartLinkIdentity handles POST /api/user/identities/:provider/link. It returns
+// the provider URL to open; the provider callback then links the account.
+func (h *HTTPGateway) StartLinkIdentity(ctx *fiber.Ctx) error {
+	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	authorizeURL, err := h.AuthService.StartLink(tokenDetails.UserID, ctx.Params("provider"))
+	if err != nil {
+		return linkErrorResponse(ctx, err)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: entities.LinkStartResponse{AuthorizeURL: authorizeURL}})
+}
+
+// LinkGoogleIdentity handles POST /api/user/identities/google
+func (h *HTTPGateway) LinkGoogleIdentity(ctx *fiber.Ctx) error {
+	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	var req entities.LinkGoogleRequest
+	if err := ctx.BodyParser(&req); err != nil || req.IDToken == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "id_token is required"})
+	}
+
+	if err := h.AuthService.LinkGoogle(tokenDetails.UserID, req.IDToken); err != nil {
+		return linkErrorResponse(ctx, err)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseMessage{Message: "account linked"})
+}
+
+// UnlinkIdentity handles DELETE /api/user/identities/:provider
+func (h *HTTPGateway) UnlinkIdentity(ctx *fiber.Ctx) error {
+	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	if err := h.AuthService.UnlinkIdentity(tokenDetails.UserID, ctx.Params("provider")); err != nil {
+		return linkErrorResponse(ctx, err)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseMessage{Message: "account unlinked"})
+}
+
+// ConfirmLink handles POST /api/auth/link/confirm: the user logged in to their
+// existing account after an email match and links the new provider account to it
+func (h *HTTPGateway) ConfirmLink(ctx *fiber.Ctx) error {
+	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	var req entities.LinkTokenRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid json body"})
+	}
+
+	if err := h.AuthService.ConfirmLink(tokenDetails.UserID, req.LinkToken); err != nil {
+		return linkErrorResponse(ctx, err)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseMessage{Message: "account linked"})
+}
+
+// DeclineLink handles POST /api/auth/link/decline: the provider account is kept
+// as a separate user and logged in
+func (h *HTTPGateway) DeclineLink(ctx *fiber.Ctx) error {
+	var req entities.LinkTokenRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid json body"})
+	}
+
+	result, err := h.AuthService.DeclineLink(req.LinkToken, clientInfo(ctx))
+	if err != nil {
+		return linkErrorResponse(ctx, err)
+	}
+	return loginResponse(ctx, result)
+}
+
+func linkErrorResponse(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrUnknownOAuthProvider), errors.Is(err, services.ErrIdentityNotLinked):
+		return ctx.Status(fiber.StatusNotFound).JSON(entities.ResponseMessage{Message: err.Error()})
+	case errors.Is(err, services.ErrInvalidOAuthState), errors.Is(err, services.ErrReloginBeforeLink):
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: err.Error()})
+	case errors.Is(err, services.ErrIdentityLinkedElse), errors.Is(err, services.ErrLastLoginMethod):
+		return ctx.Status(fiber.StatusConflict).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+	return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+}
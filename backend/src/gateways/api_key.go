@@ -0,0 +1,60 @@
+package gateways
+
+import (
+	"errors"
+
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/middlewares"
+	"recycle-waste-management-backend/src/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type APIKeyGateway struct {
+	APIKeyService services.IAPIKeyService
+}
+
+func NewAPIKeyGateway(apiKeyService services.IAPIKeyService) *APIKeyGateway {
+	return &APIKeyGateway{
+		APIKeyService: apiKeyService,
+	}
+}
+
+// CreateAPIKey handles POST /api/shops/:shop_id/api-keys. The key is only returned here.
+func (g *APIKeyGateway) CreateAPIKey(ctx *fiber.Ctx) error {
+	actor, err := middlewares.CurrentActor(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	var req entities.CreateAPIKeyRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid json body"})
+	}
+
+	created, err := g.APIKeyService.CreateAPIKey(ctx.Params("shop_id"), actor.ID, req)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(entities.ResponseModel{Message: "api key created", Data: created})
+}
+
+// GetAPIKeys handles GET /api/shops/:shop_id/api-keys
+func (g *APIKeyGateway) GetAPIKeys(ctx *fiber.Ctx) error {
+	apiKeys, err := g.APIKeyService.GetAPIKeys(ctx.Params("shop_id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot get api keys"})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: apiKeys})
+}
+
+// RevokeAPIKey handles DELETE /api/shops/:shop_id/api-keys/:key_id
+func (g *APIKeyGateway) RevokeAPIKey(ctx *fiber.Ctx) error {
+	if err := g.APIKeyService.RevokeAPIKey(ctx.Params("shop_id"), ctx.Params("key_id")); err != nil {
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(entities.ResponseMessage{Message: err.Error()})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot revoke api key"})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseMessage{Message: "api key revoked"})
+}
@@ -0,0 +1,101 @@
+package gateways
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditGateway struct {
+	AuditService services.IAuditService
+}
+
+func NewAuditGateway(auditService services.IAuditService) *AuditGateway {
+	return &AuditGateway{
+		AuditService: auditService,
+	}
+}
+
+// GetEvents handles GET /api/audit?shop_id=&actor_id=&action=&target_type=&target_id=&from=&to=&page=1&limit=50 (admin only)
+func (g *AuditGateway) GetEvents(ctx *fiber.Ctx) error {
+	query, err := auditQuery(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+	query.ShopID = ctx.Query("shop_id")
+	return g.respondEvents(ctx, query)
+}
+
+// GetShopEvents handles GET /api/audit/shop/:shop_id with the same filters as GetEvents
+func (g *AuditGateway) GetShopEvents(ctx *fiber.Ctx) error {
+	query, err := auditQuery(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+	query.ShopID = ctx.Params("shop_id")
+	return g.respondEvents(ctx, query)
+}
+
+// Verify handles GET /api/audit/verify (admin only)
+func (g *AuditGateway) Verify(ctx *fiber.Ctx) error {
+	result, err := g.AuditService.Verify()
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot verify audit log"})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: result})
+}
+
+func (g *AuditGateway) respondEvents(ctx *fiber.Ctx, query entities.AuditQuery) error {
+	page, err := strconv.Atoi(ctx.Query("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(ctx.Query("limit", "50"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	events, total, err := g.AuditService.GetEvents(query, page, limit)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot get audit events"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponsePaginationModel{
+		Message:    "success",
+		Data:       events,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+		TotalItems: total,
+	})
+}
+
+// auditQuery reads the filters shared by the audit endpoints. from and to are RFC3339.
+func auditQuery(ctx *fiber.Ctx) (entities.AuditQuery, error) {
+	query := entities.AuditQuery{
+		ActorID:    ctx.Query("actor_id"),
+		Action:     ctx.Query("action"),
+		TargetType: ctx.Query("target_type"),
+		TargetID:   ctx.Query("target_id"),
+	}
+	if from := ctx.Query("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return query, fmt.Errorf("from must be an RFC3339 time")
+		}
+		query.From = &t
+	}
+	if to := ctx.Query("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return query, fmt.Errorf("to must be an RFC3339 time")
+		}
+		query.To = &t
+	}
+	return query, nil
+}
@@ -1,37 +1,155 @@
 package gateways
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/middlewares"
+	"recycle-waste-management-backend/src/services"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func (h *HTTPGateway) AuthGithubCallback(ctx *fiber.Ctx) error {
-	code := ctx.Query("code")
+// StartOAuthLogin handles GET /api/auth/:provider/login by redirecting to the provider
+func (h *HTTPGateway) StartOAuthLogin(ctx *fiber.Ctx) error {
+	authorizeURL, err := h.AuthService.StartOAuth(ctx.Params("provider"))
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownOAuthProvider) {
+			return ctx.Status(fiber.StatusNotFound).JSON(entities.ResponseMessage{Message: err.Error()})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot start login"})
+	}
+	return ctx.Redirect(authorizeURL, fiber.StatusTemporaryRedirect)
+}
 
+// OAuthCallback handles GET /api/auth/:provider/callback for the OAuth providers (GitHub, LINE)
+func (h *HTTPGateway) OAuthCallback(ctx *fiber.Ctx) error {
+	if errCode := ctx.Query("error"); errCode != "" {
+		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: ctx.Query("error_description", errCode)})
+	}
+
+	code := ctx.Query("code")
 	if code == "" {
 		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid query params"})
 	}
 
-	data, err := h.AuthService.AuthGithub(code)
+	result, err := h.AuthService.AuthOAuth(ctx.Params("provider"), code, ctx.Query("state"), clientInfo(ctx))
 	if err != nil {
-		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: data.ErrorDesc})
+		if errors.Is(err, services.ErrUnknownOAuthProvider) {
+			return ctx.Status(fiber.StatusNotFound).JSON(entities.ResponseMessage{Message: err.Error()})
+		}
+		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: err.Error()})
 	}
-	return ctx.Redirect(fmt.Sprintf("%v/auth?token=%v", os.Getenv("FRONTEND_URL"), data.JWT), fiber.StatusTemporaryRedirect)
+	return loginRedirect(ctx, result)
 }
 
+// AuthGoogleCallback handles the form POST the frontend submits after Google
+// sign-in with Firebase. The ID token travels in the body, not the URL.
 func (h *HTTPGateway) AuthGoogleCallback(ctx *fiber.Ctx) error {
-	token := ctx.Query("uid")
-
-	if token == "" {
-		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid query params"})
+	var req entities.GoogleLoginRequest
+	if err := ctx.BodyParser(&req); err != nil || req.IDToken == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "id_token is required"})
 	}
 
-	data, err := h.AuthService.AuthGoogle(token)
+	result, err := h.AuthService.AuthGoogle(req.IDToken, clientInfo(ctx))
 	if err != nil {
 		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: err.Error()})
 	}
-	return ctx.Redirect(fmt.Sprintf("%v/auth?token=%v", os.Getenv("FRONTEND_URL"), data.JWT), fiber.StatusTemporaryRedirect)
+	return loginRedirect(ctx, result)
+}
+
+// loginRedirect sends the browser back to the frontend with the login outcome:
+// tokens, a two-factor prompt, a link prompt after an email match, or
+// confirmation of a linked account. Tokens, MFA and link tokens included, go in
+// the URL fragment, which the browser never sends to a server or leaks through
+// the Referer header.
+func loginRedirect(ctx *fiber.Ctx, result *entities.LoginResult) error {
+	frontendURL := os.Getenv("FRONTEND_URL")
+	var target string
+	switch {
+	case result.Linked:
+		target = fmt.Sprintf("%v/settings?linked=%v", frontendURL, url.QueryEscape(result.Provider))
+	case result.Challenge != nil:
+		fragment := url.Values{"mfa_token": {result.Challenge.MFAToken}, "setup": {strconv.FormatBool(result.Challenge.SetupRequired)}}
+		target = fmt.Sprintf("%v/auth/2fa#%v", frontendURL, fragment.Encode())
+	case result.LinkToken != "":
+		fragment := url.Values{"link_token": {result.LinkToken}, "provider": {result.Provider}, "email": {result.MatchedEmail}}
+		target = fmt.Sprintf("%v/auth/link#%v", frontendURL, fragment.Encode())
+	default:
+		fragment := url.Values{"token": {result.Tokens.AccessToken}, "refresh_token": {result.Tokens.RefreshToken}}
+		target = fmt.Sprintf("%v/auth#%v", frontendURL, fragment.Encode())
+	}
+	return ctx.Redirect(target, fiber.StatusTemporaryRedirect)
+}
+
+// RequestPhoneOTP handles POST /api/auth/phone/request-otp
+func (h *HTTPGateway) RequestPhoneOTP(ctx *fiber.Ctx) error {
+	var req entities.PhoneOTPRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid json body"})
+	}
+
+	data, err := h.AuthService.RequestPhoneOTP(req.Phone)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPhone):
+			return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+		case errors.Is(err, services.ErrOTPCooldown):
+			ctx.Set(fiber.HeaderRetryAfter, strconv.FormatInt(data.ResendAfter, 10))
+			return ctx.Status(fiber.StatusTooManyRequests).JSON(entities.ResponseModel{Message: err.Error(), Data: data})
+		case errors.Is(err, services.ErrOTPRateLimited):
+			return ctx.Status(fiber.StatusTooManyRequests).JSON(entities.ResponseMessage{Message: err.Error()})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "code sent", Data: data})
+}
+
+// VerifyPhoneOTP handles POST /api/auth/phone/verify
+func (h *HTTPGateway) VerifyPhoneOTP(ctx *fiber.Ctx) error {
+	var req entities.PhoneOTPVerifyRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid json body"})
+	}
+	if req.Phone == "" || req.Code == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "phone and code are required"})
+	}
+
+	result, err := h.AuthService.VerifyPhoneOTP(req.Phone, req.Code, clientInfo(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPhone):
+			return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+		case errors.Is(err, services.ErrOTPInvalid), errors.Is(err, services.ErrOTPTooManyAttempts):
+			return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: err.Error()})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot log in"})
+	}
+	return loginResponse(ctx, result)
+}
+
+// loginResponse answers an API login with its tokens, or with the challenge
+// to complete at POST /api/auth/2fa/login/verify
+func loginResponse(ctx *fiber.Ctx, result *entities.LoginResult) error {
+	if result.Challenge != nil {
+		return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "two-factor code required", Data: result.Challenge})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: result.Tokens})
+}
+
+// requestMeta identifies the caller and their address for the audit log
+func requestMeta(ctx *fiber.Ctx) entities.RequestMeta {
+	actor, _ := middlewares.CurrentActor(ctx)
+	return entities.RequestMeta{Actor: actor, IPAddress: ctx.IP()}
+}
+
+// clientInfo captures the device and address a login or refresh came from
+func clientInfo(ctx *fiber.Ctx) entities.ClientInfo {
+	return entities.ClientInfo{
+		Device:    ctx.Get(fiber.HeaderUserAgent),
+		IPAddress: ctx.IP(),
+	}
 }
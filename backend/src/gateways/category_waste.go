@@ -0,0 +1,139 @@
+package gateways
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CategoryGateway struct {
+	CategoryService services.ICategoryWasteService
+}
+
+func NewCategoryGateway(categoryService services.ICategoryWasteService) *CategoryGateway {
+	return &CategoryGateway{
+		CategoryService: categoryService,
+	}
+}
+
+// GetAllCategories handles GET /api/category-waste/all, inactive categories included
+func (g *CategoryGateway) GetAllCategories(ctx *fiber.Ctx) error {
+	categories, err := g.CategoryService.GetCategories()
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot get categories"})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: categories})
+}
+
+// CreateCategory handles POST /api/category-waste as multipart form: name,
+// name_th, name_en and an optional icon
+func (g *CategoryGateway) CreateCategory(ctx *fiber.Ctx) error {
+	req, icon, err := categoryForm(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+
+	category, err := g.CategoryService.CreateCategory(requestMeta(ctx), req, icon)
+	if err != nil {
+		return categoryError(ctx, err)
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(entities.ResponseModel{Message: "category created", Data: category})
+}
+
+// UpdateCategory handles PUT /api/category-waste/:category_id with the same form as CreateCategory
+func (g *CategoryGateway) UpdateCategory(ctx *fiber.Ctx) error {
+	req, icon, err := categoryForm(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+
+	category, err := g.CategoryService.UpdateCategory(requestMeta(ctx), ctx.Params("category_id"), req, icon)
+	if err != nil {
+		return categoryError(ctx, err)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "category updated", Data: category})
+}
+
+// ReorderCategories handles PUT /api/category-waste/order
+func (g *CategoryGateway) ReorderCategories(ctx *fiber.Ctx) error {
+	var req entities.ReorderCategoriesRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid json body"})
+	}
+
+	categories, err := g.CategoryService.ReorderCategories(requestMeta(ctx), req.CategoryIDs)
+	if err != nil {
+		return categoryError(ctx, err)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "categories reordered", Data: categories})
+}
+
+// ActivateCategory handles PUT /api/category-waste/:category_id/activate
+func (g *CategoryGateway) ActivateCategory(ctx *fiber.Ctx) error {
+	return g.setActive(ctx, true)
+}
+
+// DeactivateCategory handles PUT /api/category-waste/:category_id/deactivate.
+// Existing items keep the category, but it can't be chosen for new ones.
+func (g *CategoryGateway) DeactivateCategory(ctx *fiber.Ctx) error {
+	return g.setActive(ctx, false)
+}
+
+func (g *CategoryGateway) setActive(ctx *fiber.Ctx, active bool) error {
+	category, err := g.CategoryService.SetCategoryActive(requestMeta(ctx), ctx.Params("category_id"), active)
+	if err != nil {
+		return categoryError(ctx, err)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "category updated", Data: category})
+}
+
+// MergeCategory handles POST /api/category-waste/:category_id/merge
+func (g *CategoryGateway) MergeCategory(ctx *fiber.Ctx) error {
+	var req entities.MergeCategoryRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid json body"})
+	}
+
+	result, err := g.CategoryService.MergeCategory(requestMeta(ctx), ctx.Params("category_id"), req.IntoCategoryID)
+	if err != nil {
+		return categoryError(ctx, err)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "categories merged", Data: result})
+}
+
+func categoryForm(ctx *fiber.Ctx) (entities.CategoryWasteRequest, []byte, error) {
+	req := entities.CategoryWasteRequest{
+		Name:   ctx.FormValue("name"),
+		NameTH: ctx.FormValue("name_th"),
+		NameEN: ctx.FormValue("name_en"),
+	}
+
+	icon := []byte{}
+	if iconFile, err := ctx.FormFile("icon"); err == nil {
+		file, err := iconFile.Open()
+		if err != nil {
+			return req, nil, fmt.Errorf("cannot read icon")
+		}
+		defer file.Close()
+		if icon, err = io.ReadAll(file); err != nil {
+			return req, nil, fmt.Errorf("cannot read icon")
+		}
+	}
+	return req, icon, nil
+}
+
+func categoryError(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrCategoryNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(entities.ResponseMessage{Message: err.Error()})
+	case errors.Is(err, services.ErrCategoryNameTaken):
+		return ctx.Status(fiber.StatusConflict).JSON(entities.ResponseMessage{Message: err.Error()})
+	default:
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+}
@@ -0,0 +1,120 @@
+package gateways
+
+import (
+	"errors"
+
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/middlewares"
+	"recycle-waste-management-backend/src/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+type ChatGateway struct {
+	ChatService services.IChatService
+}
+
+func NewChatGateway(chatService services.IChatService) *ChatGateway {
+	return &ChatGateway{
+		ChatService: chatService,
+	}
+}
+
+// GetUnreadCount handles GET /api/chat/unread-count
+func (g *ChatGateway) GetUnreadCount(ctx *fiber.Ctx) error {
+	actor, err := middlewares.CurrentActor(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	counts, err := g.ChatService.GetUnreadCounts(actor)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot get unread count"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: counts})
+}
+
+// UploadChatImage handles POST /api/chat/:request_id/images
+func (g *ChatGateway) UploadChatImage(ctx *fiber.Ctx) error {
+	actor, err := middlewares.CurrentActor(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	customerRequestID := ctx.Params("request_id")
+	if customerRequestID == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "request_id is required"})
+	}
+
+	file, err := ctx.FormFile("image")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "no image file uploaded"})
+	}
+
+	imageURL, err := g.ChatService.UploadChatImage(actor, customerRequestID, file)
+	if err != nil {
+		if errors.Is(err, services.ErrNotChatMember) {
+			return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: err.Error()})
+		}
+		log.Error("Failed to upload chat image:", err)
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{
+		Message: "success",
+		Data:    map[string]string{"image_url": imageURL},
+	})
+}
+
+// ReportMessage handles POST /api/chat/messages/:message_id/report
+func (g *ChatGateway) ReportMessage(ctx *fiber.Ctx) error {
+	actor, err := middlewares.CurrentActor(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	var body entities.ReportChatMessageRequest
+	if err := ctx.BodyParser(&body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid json body"})
+	}
+
+	if err := g.ChatService.ReportMessage(actor, ctx.Params("message_id"), body.Reason); err != nil {
+		if errors.Is(err, services.ErrNotChatMember) {
+			return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: err.Error()})
+		}
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseMessage{Message: "message reported"})
+}
+
+// GetReportedMessages handles GET /api/chat/reports?status=pending (admin only)
+func (g *ChatGateway) GetReportedMessages(ctx *fiber.Ctx) error {
+	messages, err := g.ChatService.GetReportedMessages(ctx.Query("status"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: messages})
+}
+
+// ReviewReport handles PUT /api/chat/reports/:message_id (admin only)
+func (g *ChatGateway) ReviewReport(ctx *fiber.Ctx) error {
+	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	var body entities.ReviewChatReportRequest
+	if err := ctx.BodyParser(&body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid json body"})
+	}
+
+	if err := g.ChatService.ReviewReport(tokenDetails.UserID, ctx.Params("message_id"), body.Action); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseMessage{Message: "report reviewed"})
+}
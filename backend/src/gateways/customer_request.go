@@ -24,7 +24,7 @@ func (h *HTTPGateway) AddCustomerRequest(ctx *fiber.Ctx) error {
 }
 
 func (h *HTTPGateway) GetCustomerRequests(ctx *fiber.Ctx) error {
-	token, err := middlewares.DecodeJWTToken(ctx)
+	actor, err := middlewares.CurrentActor(ctx)
 	if err != nil {
 		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
 	}
@@ -36,7 +36,7 @@ func (h *HTTPGateway) GetCustomerRequests(ctx *fiber.Ctx) error {
 	// Get max distance parameter (default 20.0 km)
 	maxDistance := ctx.QueryFloat("maxDistance", 20.0)
 
-	customerRequests, err := h.CustomerRequestService.GetCustomerRequests(token.UserID, page, limit, maxDistance)
+	customerRequests, err := h.CustomerRequestService.GetCustomerRequests(actor, page, limit, maxDistance)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot get customer requests"})
 	}
@@ -57,7 +57,7 @@ func (h *HTTPGateway) GetCustomerRequestByRequestID(ctx *fiber.Ctx) error {
 
 func (h *HTTPGateway) CancelCustomerRequest(ctx *fiber.Ctx) error {
 	// Verify authentication
-	_, err := middlewares.DecodeJWTToken(ctx)
+	actor, err := middlewares.CurrentActor(ctx)
 	if err != nil {
 		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
 	}
@@ -88,7 +88,7 @@ func (h *HTTPGateway) CancelCustomerRequest(ctx *fiber.Ctx) error {
 	// (You might want to add this verification in the service layer)
 
 	// Cancel the request
-	err = h.CustomerRequestService.CancelCustomerRequest(customerRequestID, body.Reason)
+	err = h.CustomerRequestService.CancelCustomerRequest(actor, customerRequestID, body.Reason)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: err.Error()})
 	}
@@ -110,7 +110,7 @@ func (h *HTTPGateway) GetCustomerRequestByUserID(ctx *fiber.Ctx) error {
 
 func (h *HTTPGateway) AcceptCustomerRequest(ctx *fiber.Ctx) error {
 	// Verify authentication
-	_, err := middlewares.DecodeJWTToken(ctx)
+	actor, err := middlewares.CurrentActor(ctx)
 	if err != nil {
 		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
 	}
@@ -122,7 +122,7 @@ func (h *HTTPGateway) AcceptCustomerRequest(ctx *fiber.Ctx) error {
 	}
 
 	// Accept the request
-	err = h.CustomerRequestService.AcceptCustomerRequest(customerRequestID)
+	err = h.CustomerRequestService.AcceptCustomerRequest(actor, customerRequestID)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: err.Error()})
 	}
@@ -132,7 +132,7 @@ func (h *HTTPGateway) AcceptCustomerRequest(ctx *fiber.Ctx) error {
 
 func (h *HTTPGateway) CompleteCustomerRequest(ctx *fiber.Ctx) error {
 	// Verify authentication
-	token, err := middlewares.DecodeJWTToken(ctx)
+	actor, err := middlewares.CurrentActor(ctx)
 	if err != nil {
 		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
 	}
@@ -143,14 +143,8 @@ func (h *HTTPGateway) CompleteCustomerRequest(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "customer_request_id is required"})
 	}
 
-	// Get shop by user ID
-	shop, err := h.ShopService.GetShopByUserID(token.UserID)
-	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot get shop info"})
-	}
-
-	// Complete the request
-	err = h.CustomerRequestService.CompleteCustomerRequest(customerRequestID, shop.ShopID)
+	// Complete the request for the shop the caller acts for
+	err = h.CustomerRequestService.CompleteCustomerRequest(actor, customerRequestID)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: err.Error()})
 	}
@@ -160,7 +154,7 @@ func (h *HTTPGateway) CompleteCustomerRequest(ctx *fiber.Ctx) error {
 
 func (h *HTTPGateway) CreateWalkInRequest(ctx *fiber.Ctx) error {
 	// Verify authentication (Shop Owner)
-	token, err := middlewares.DecodeJWTToken(ctx)
+	actor, err := middlewares.CurrentActor(ctx)
 	if err != nil {
 		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
 	}
@@ -171,15 +165,8 @@ func (h *HTTPGateway) CreateWalkInRequest(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid json body"})
 	}
 
-	// Get Shop ID from User ID (to ensure security)
-	shop, err := h.ShopService.GetShopByUserID(token.UserID)
-	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot get shop info"})
-	}
-	body.ShopID = shop.ShopID
-
-	// Call Service
-	requestID, err := h.CustomerRequestService.CreateWalkInRequest(*body)
+	// Call Service; the shop is taken from the caller, never from the body
+	requestID, err := h.CustomerRequestService.CreateWalkInRequest(actor, *body)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: err.Error()})
 	}
@@ -1,6 +1,8 @@
 package gateways
 
 import (
+	"errors"
+	"math"
 	"strconv"
 
 	"recycle-waste-management-backend/src/domain/entities"
@@ -14,12 +16,14 @@ import (
 type EmployeeGateway struct {
 	EmployeeService services.IEmployeeService
 	ShopRepository  repositories.IShopRepository
+	TwoFactor       services.ITwoFactorService
 }
 
-func NewEmployeeGateway(service services.IEmployeeService, shopRepo repositories.IShopRepository) *EmployeeGateway {
+func NewEmployeeGateway(service services.IEmployeeService, shopRepo repositories.IShopRepository, twoFactor services.ITwoFactorService) *EmployeeGateway {
 	return &EmployeeGateway{
 		EmployeeService: service,
 		ShopRepository:  shopRepo,
+		TwoFactor:       twoFactor,
 	}
 }
 
@@ -29,12 +33,23 @@ func (g *EmployeeGateway) SetupRoutes(app *fiber.App) {
 	// Public route - login
 	employee.Post("/login", g.EmployeeLogin)
 
-	// Protected routes - require JWT authentication
-	employee.Post("/", middlewares.SetJWtHeaderHandler(), g.CreateEmployee)
-	employee.Get("/shop/:shop_id", middlewares.SetJWtHeaderHandler(), g.GetEmployeesByShopID)
-	employee.Get("/:employee_id", middlewares.SetJWtHeaderHandler(), g.GetEmployeeByID)
-	employee.Put("/:employee_id", middlewares.SetJWtHeaderHandler(), g.UpdateEmployee)
-	employee.Delete("/:employee_id", middlewares.SetJWtHeaderHandler(), g.DeleteEmployee)
+	// Protected routes - require JWT authentication and access to the employee's shop
+	protected := employee.Group("", middlewares.SetJWtHeaderHandler(), middlewares.RequirePermission(entities.PermissionEmployeeManage))
+	protected.Post("/", g.CreateEmployee)
+	protected.Get("/shop/:shop_id", middlewares.RequireShopAccess("shop_id"), g.GetEmployeesByShopID)
+	protected.Get("/shop/:shop_id/security-events", middlewares.RequireShopAccess("shop_id"), g.GetSecurityEvents)
+	protected.Get("/:employee_id", middlewares.RequireOwnership(g.employeeShop), g.GetEmployeeByID)
+	protected.Put("/:employee_id", middlewares.RequireOwnership(g.employeeShop), g.UpdateEmployee)
+	protected.Delete("/:employee_id", middlewares.RequireOwnership(g.employeeShop), g.DeleteEmployee)
+}
+
+// employeeShop resolves the shop of the employee addressed by the route
+func (g *EmployeeGateway) employeeShop(c *fiber.Ctx) (string, error) {
+	employee, err := g.EmployeeService.GetEmployeeByID(c.Params("employee_id"))
+	if err != nil {
+		return "", err
+	}
+	return employee.ShopID, nil
 }
 
 // EmployeeLogin handles employee authentication
@@ -60,50 +75,58 @@ func (g *EmployeeGateway) EmployeeLogin(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check if shop exists
-	shop, err := g.ShopRepository.GetByShopCode(req.ShopCode)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(entities.ResponseModel{
-			Message: "Invalid shop code",
-			Status:  fiber.StatusUnauthorized,
-		})
-	}
-
-	// Validate employee credentials
-	employee, err := g.EmployeeService.ValidateEmployeePassword(req.Username, req.Password)
+	// Wrong shop code, username and password all get the same answer
+	employee, err := g.EmployeeService.Login(req.ShopCode, req.Username, req.Password, c.IP())
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(entities.ResponseModel{
-			Message: "Invalid username or password",
-			Status:  fiber.StatusUnauthorized,
-		})
-	}
-
-	// Verify employee belongs to this shop
-	if employee.ShopID != shop.ShopID {
-		return c.Status(fiber.StatusUnauthorized).JSON(entities.ResponseModel{
-			Message: "Employee does not belong to this shop",
-			Status:  fiber.StatusUnauthorized,
+		var locked *services.LoginLockedError
+		switch {
+		case errors.As(err, &locked):
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+			return c.Status(fiber.StatusTooManyRequests).JSON(entities.ResponseModel{
+				Message: err.Error(),
+				Status:  fiber.StatusTooManyRequests,
+			})
+		case errors.Is(err, services.ErrEmployeeLoginFailed):
+			return c.Status(fiber.StatusUnauthorized).JSON(entities.ResponseModel{
+				Message: err.Error(),
+				Status:  fiber.StatusUnauthorized,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(entities.ResponseModel{
+			Message: "Error logging in",
+			Status:  fiber.StatusInternalServerError,
 		})
 	}
 
-	// Generate JWT token for employee
-	tokenDetails, err := middlewares.GenerateJWTToken(employee.EmployeeID)
+	// Start a session for the employee, unless a two-factor code is needed first
+	result, err := g.TwoFactor.Login(employee.Actor(), clientInfo(c))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(entities.ResponseModel{
 			Message: "Error generating token",
 			Status:  fiber.StatusInternalServerError,
 		})
 	}
+	if result.Challenge != nil {
+		return c.Status(fiber.StatusOK).JSON(entities.ResponseModel{
+			Message: "Two-factor code required",
+			Data:    result.Challenge,
+			Status:  fiber.StatusOK,
+		})
+	}
+	tokens := result.Tokens
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "Login successful",
-		"token":   *tokenDetails.Token,
+		"message":       "Login successful",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 		"employee": map[string]interface{}{
 			"employee_id": employee.EmployeeID,
 			"shop_id":     employee.ShopID,
 			"first_name":  employee.FirstName,
 			"last_name":   employee.LastName,
 			"username":    employee.Username,
+			"permissions": employee.Actor().Permissions,
 		},
 	})
 }
@@ -133,7 +156,27 @@ func (g *EmployeeGateway) CreateEmployee(c *fiber.Ctx) error {
 		})
 	}
 
-	employee, err := g.EmployeeService.CreateEmployee(&req)
+	// Default to the caller's own shop
+	if req.ShopID == "" {
+		actor, err := middlewares.CurrentActor(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(entities.ResponseModel{
+				Message: "unauthorized",
+				Status:  fiber.StatusUnauthorized,
+			})
+		}
+		if shopID, err := services.ResolveActingShop(actor, g.ShopRepository); err == nil {
+			req.ShopID = shopID
+		}
+	}
+	if err := middlewares.CheckShopAccess(c, req.ShopID); err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(entities.ResponseModel{
+			Message: err.Error(),
+			Status:  fiber.StatusForbidden,
+		})
+	}
+
+	employee, err := g.EmployeeService.CreateEmployee(requestMeta(c), &req)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(entities.ResponseModel{
 			Message: err.Error(),
@@ -236,7 +279,7 @@ func (g *EmployeeGateway) UpdateEmployee(c *fiber.Ctx) error {
 		})
 	}
 
-	employee, err := g.EmployeeService.UpdateEmployee(employeeID, &req)
+	employee, err := g.EmployeeService.UpdateEmployee(requestMeta(c), employeeID, &req)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(entities.ResponseModel{
 			Message: err.Error(),
@@ -260,7 +303,7 @@ func (g *EmployeeGateway) DeleteEmployee(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := g.EmployeeService.DeleteEmployee(employeeID); err != nil {
+	if err := g.EmployeeService.DeleteEmployee(requestMeta(c), employeeID); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(entities.ResponseModel{
 			Message: err.Error(),
 			Status:  fiber.StatusInternalServerError,
@@ -272,3 +315,33 @@ func (g *EmployeeGateway) DeleteEmployee(c *fiber.Ctx) error {
 		Status:  fiber.StatusOK,
 	})
 }
+
+// GetSecurityEvents lists alerts about the shop's employee accounts, such as login lockouts
+func (g *EmployeeGateway) GetSecurityEvents(c *fiber.Ctx) error {
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(c.Query("page_size", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	events, total, err := g.EmployeeService.GetSecurityEvents(c.Params("shop_id"), page, pageSize)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(entities.ResponseModel{
+			Message: err.Error(),
+			Status:  fiber.StatusInternalServerError,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(entities.ResponsePaginationModel{
+		Message:    "Security events retrieved successfully",
+		Data:       events,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+		TotalItems: total,
+		Page:       page,
+		Limit:      pageSize,
+		Status:     fiber.StatusOK,
+	})
+}
@@ -14,9 +14,10 @@ type HTTPGateway struct {
 	ShopService            service.IShopService
 	SettingsService        service.ISettingsService
 	CustomerRequestService service.ICustomerRequestService
+	PrivacyService         service.IPrivacyService
 }
 
-func NewHTTPGateway(app *fiber.App, users service.IUsersService, recWasteSV service.IRecycleWasteService, authService service.IAuthService, imageService service.IImageService, shopService service.IShopService, settingsService service.ISettingsService, customerRequestService service.ICustomerRequestService) {
+func NewHTTPGateway(app *fiber.App, users service.IUsersService, recWasteSV service.IRecycleWasteService, authService service.IAuthService, imageService service.IImageService, shopService service.IShopService, settingsService service.ISettingsService, customerRequestService service.ICustomerRequestService, privacyService service.IPrivacyService) {
 	gateway := &HTTPGateway{
 		UserService:            users,
 		RecycleService:         recWasteSV,
@@ -25,6 +26,7 @@ func NewHTTPGateway(app *fiber.App, users service.IUsersService, recWasteSV serv
 		ShopService:            shopService,
 		SettingsService:        settingsService,
 		CustomerRequestService: customerRequestService,
+		PrivacyService:         privacyService,
 	}
 
 	RouteUsers(*gateway, app)
@@ -34,5 +36,4 @@ func NewHTTPGateway(app *fiber.App, users service.IUsersService, recWasteSV serv
 	RouteShop(*gateway, app)
 	RouteSettings(*gateway, app)
 	RouteCustomerRequest(*gateway, app)
-	RouteWebSocket(*gateway, app)
 }
@@ -0,0 +1,116 @@
+package gateways
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/services"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+)
+
+type MaterialGateway struct {
+	MaterialService services.IMaterialService
+}
+
+func NewMaterialGateway(materialService services.IMaterialService) *MaterialGateway {
+	return &MaterialGateway{
+		MaterialService: materialService,
+	}
+}
+
+// GetMaterials handles GET /api/materials, optionally filtered by category
+func (g *MaterialGateway) GetMaterials(ctx *fiber.Ctx) error {
+	materials, err := g.MaterialService.GetMaterials(ctx.Query("category"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot get materials"})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: materials})
+}
+
+// GetMaterial handles GET /api/materials/:material_id
+func (g *MaterialGateway) GetMaterial(ctx *fiber.Ctx) error {
+	material, err := g.MaterialService.GetMaterial(ctx.Params("material_id"))
+	if err != nil {
+		return materialError(ctx, err)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: material})
+}
+
+// CreateMaterial handles POST /api/materials as multipart form: code, name_th,
+// name_en, category, aliases (JSON array) and an optional image
+func (g *MaterialGateway) CreateMaterial(ctx *fiber.Ctx) error {
+	req, image, err := materialForm(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+
+	material, err := g.MaterialService.CreateMaterial(requestMeta(ctx), req, image)
+	if err != nil {
+		return materialError(ctx, err)
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(entities.ResponseModel{Message: "material created", Data: material})
+}
+
+// UpdateMaterial handles PUT /api/materials/:material_id with the same form as CreateMaterial
+func (g *MaterialGateway) UpdateMaterial(ctx *fiber.Ctx) error {
+	req, image, err := materialForm(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+
+	material, err := g.MaterialService.UpdateMaterial(requestMeta(ctx), ctx.Params("material_id"), req, image)
+	if err != nil {
+		return materialError(ctx, err)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "material updated", Data: material})
+}
+
+// DeleteMaterial handles DELETE /api/materials/:material_id
+func (g *MaterialGateway) DeleteMaterial(ctx *fiber.Ctx) error {
+	if err := g.MaterialService.DeleteMaterial(requestMeta(ctx), ctx.Params("material_id")); err != nil {
+		return materialError(ctx, err)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseMessage{Message: "material deleted"})
+}
+
+func materialForm(ctx *fiber.Ctx) (entities.MaterialRequest, []byte, error) {
+	req := entities.MaterialRequest{
+		Code:     ctx.FormValue("code"),
+		NameTH:   ctx.FormValue("name_th"),
+		NameEN:   ctx.FormValue("name_en"),
+		Category: ctx.FormValue("category"),
+	}
+	if aliases := ctx.FormValue("aliases"); aliases != "" {
+		if err := json.Unmarshal([]byte(aliases), &req.Aliases); err != nil {
+			return req, nil, fmt.Errorf("aliases must be a JSON array of strings")
+		}
+	}
+
+	image := []byte{}
+	if imageFile, err := ctx.FormFile("image"); err == nil {
+		file, err := imageFile.Open()
+		if err != nil {
+			return req, nil, fmt.Errorf("cannot read image")
+		}
+		defer file.Close()
+		if image, err = io.ReadAll(file); err != nil {
+			return req, nil, fmt.Errorf("cannot read image")
+		}
+	}
+	return req, image, nil
+}
+
+func materialError(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrMaterialNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(entities.ResponseMessage{Message: err.Error()})
+	case errors.Is(err, services.ErrMaterialCodeTaken):
+		return ctx.Status(fiber.StatusConflict).JSON(entities.ResponseMessage{Message: err.Error()})
+	default:
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+}
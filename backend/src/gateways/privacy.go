@@ -0,0 +1,71 @@
+package gateways
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/middlewares"
+	"recycle-waste-management-backend/src/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// ExportMyData handles GET /api/user/me/export?format=json|zip
+func (h *HTTPGateway) ExportMyData(ctx *fiber.Ctx) error {
+	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	if ctx.Query("format") == entities.PrivacyExportZIP {
+		archive, err := h.PrivacyService.ExportUserArchive(tokenDetails.UserID)
+		if err != nil {
+			log.Error("Failed to export user data:", err)
+			return ctx.Status(privacyErrorStatus(err)).JSON(entities.ResponseMessage{Message: "cannot export user data"})
+		}
+		filename := fmt.Sprintf("personal-data-%s.zip", time.Now().UTC().Format("20060102"))
+		ctx.Set(fiber.HeaderContentType, "application/zip")
+		ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+		return ctx.Status(fiber.StatusOK).Send(archive)
+	}
+
+	export, err := h.PrivacyService.ExportUserData(tokenDetails.UserID)
+	if err != nil {
+		log.Error("Failed to export user data:", err)
+		return ctx.Status(privacyErrorStatus(err)).JSON(entities.ResponseMessage{Message: "cannot export user data"})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: export})
+}
+
+// EraseMyAccount handles DELETE /api/user/me with {"confirm": "DELETE"}
+func (h *HTTPGateway) EraseMyAccount(ctx *fiber.Ctx) error {
+	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	var body entities.EraseAccountRequest
+	if err := ctx.BodyParser(&body); err != nil || body.Confirm != "DELETE" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: `send {"confirm": "DELETE"} to erase the account`})
+	}
+
+	if err := h.PrivacyService.EraseUser(requestMeta(ctx), tokenDetails.UserID); err != nil {
+		log.Error("Failed to erase user:", err)
+		return ctx.Status(privacyErrorStatus(err)).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseMessage{Message: "account erased"})
+}
+
+func privacyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrPrivacyUserNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrErasureShopOwner):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
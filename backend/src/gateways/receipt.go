@@ -1,8 +1,10 @@
 package gateways
 
 import (
+	"fmt"
 	"strconv"
 
+	"recycle-waste-management-backend/src/domain/entities"
 	"recycle-waste-management-backend/src/middlewares"
 	"recycle-waste-management-backend/src/services"
 
@@ -20,16 +22,13 @@ func NewReceiptGateway(receiptService services.IReceiptService) *ReceiptGateway
 }
 
 func (h *ReceiptGateway) CreateReceipt(ctx *fiber.Ctx) error {
-	// Check user role authorization
-
-	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
-	if err != nil {
+	// Shop access is checked against the caller's permissions
+	if _, err := middlewares.CurrentActor(ctx); err != nil {
 		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "Unauthorized",
 		})
 
 	}
-	userID := tokenDetails.UserID
 	var req services.CreateReceiptRequest
 	if err := ctx.BodyParser(&req); err != nil {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -39,7 +38,7 @@ func (h *ReceiptGateway) CreateReceipt(ctx *fiber.Ctx) error {
 		})
 	}
 
-	receipt, err := h.ReceiptService.CreateReceipt(userID, req)
+	receipt, err := h.ReceiptService.CreateReceipt(requestMeta(ctx), req)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -55,6 +54,38 @@ func (h *ReceiptGateway) CreateReceipt(ctx *fiber.Ctx) error {
 	})
 }
 
+// VoidReceipt handles POST /api/receipts/:receipt_id/void
+func (h *ReceiptGateway) VoidReceipt(ctx *fiber.Ctx) error {
+	if _, err := middlewares.CurrentActor(ctx); err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+	var req entities.VoidReceiptRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+	}
+
+	receipt, err := h.ReceiptService.VoidReceipt(requestMeta(ctx), ctx.Params("receipt_id"), req.Reason)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Failed to void receipt",
+			"error":   err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Receipt voided successfully",
+		"data":    receipt,
+	})
+}
+
 func (h *ReceiptGateway) GetReceiptByRequestID(ctx *fiber.Ctx) error {
 	requestID := ctx.Params("request_id")
 	if requestID == "" {
@@ -72,6 +103,12 @@ func (h *ReceiptGateway) GetReceiptByRequestID(ctx *fiber.Ctx) error {
 			"error":   err.Error(),
 		})
 	}
+	if err := h.authorizeReceiptView(ctx, receipt.Receipt); err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
 
 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
@@ -96,6 +133,12 @@ func (h *ReceiptGateway) GetReceiptByID(ctx *fiber.Ctx) error {
 			"error":   err.Error(),
 		})
 	}
+	if err := h.authorizeReceiptView(ctx, receipt.Receipt); err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
 
 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
@@ -103,6 +146,22 @@ func (h *ReceiptGateway) GetReceiptByID(ctx *fiber.Ctx) error {
 	})
 }
 
+// authorizeReceiptView lets the customer of the receipt's request and the shop
+// that issued it see a receipt
+func (h *ReceiptGateway) authorizeReceiptView(ctx *fiber.Ctx, receipt *entities.Receipt) error {
+	actor, err := middlewares.CurrentActor(ctx)
+	if err != nil {
+		return err
+	}
+	if actor.HasPermission(entities.PermissionReceiptView) && middlewares.CheckShopAccess(ctx, receipt.ShopID) == nil {
+		return nil
+	}
+	if h.ReceiptService.IsReceiptCustomer(receipt, actor.ID) {
+		return nil
+	}
+	return fmt.Errorf("access denied - this receipt is not yours")
+}
+
 func (h *ReceiptGateway) GetReceiptsByShopID(ctx *fiber.Ctx) error {
 	shopID := ctx.Params("shop_id")
 	if shopID == "" {
@@ -158,3 +217,54 @@ func (h *ReceiptGateway) GetReceiptsByShopID(ctx *fiber.Ctx) error {
 		"total_pages": totalPages,
 	})
 }
+
+// GetOwnerReceipts handles GET /api/receipts/owner: the receipts of all of the
+// caller's shops with per-shop totals. Only the receipt list is paginated.
+func (h *ReceiptGateway) GetOwnerReceipts(ctx *fiber.Ctx) error {
+	actor, err := middlewares.CurrentActor(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.Query("page_size", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	summary, err := h.ReceiptService.GetOwnerReceipts(actor.ID)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Failed to get receipts",
+			"error":   err.Error(),
+		})
+	}
+
+	total := len(summary.Receipts)
+	totalPages := (total + pageSize - 1) / pageSize
+	start := (page - 1) * pageSize
+	end := start + pageSize
+	if start >= total {
+		start = 0
+		end = 0
+	}
+	if end > total {
+		end = total
+	}
+	summary.Receipts = summary.Receipts[start:end]
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success":     true,
+		"data":        summary,
+		"page":        page,
+		"page_size":   pageSize,
+		"total":       total,
+		"total_pages": totalPages,
+	})
+}
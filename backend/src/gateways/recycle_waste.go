@@ -3,11 +3,13 @@ package gateways
 import (
 	"fmt"
 	"io"
+	"path/filepath"
 	"recycle-waste-management-backend/src/domain/entities"
 	"recycle-waste-management-backend/src/middlewares"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -19,7 +21,7 @@ func (h *HTTPGateway) GetRecycleWaste(ctx *fiber.Ctx) error {
 	pageQuery := ctx.Query("page")
 	limitQuery := ctx.Query("limit")
 	shopIDQuery := ctx.Query("shop_id")    // Get shop_id from query params
-	searchQuery := ctx.Query("search")     // Get search query from params
+	searchQuery := ctx.Query("search")     // Searched in the repository, best matches first
 	categoryQuery := ctx.Query("category") // Get category query from params
 
 	if pageQuery != "" {
@@ -42,43 +44,20 @@ func (h *HTTPGateway) GetRecycleWaste(ctx *fiber.Ctx) error {
 		// Check if category filter is also provided
 		if categoryQuery != "" {
 			// Get recyclable items for a specific shop and category
-			data, totalCount, err = h.RecycleService.GetRecyclableItemsByShopIDAndCategoryPaginated(shopIDQuery, categoryQuery, page, limit)
+			data, totalCount, err = h.RecycleService.GetRecyclableItemsByShopIDAndCategoryPaginated(shopIDQuery, categoryQuery, searchQuery, page, limit)
 		} else {
 			// Get recyclable items for a specific shop
-			data, totalCount, err = h.RecycleService.GetRecyclableItemsByShopIDPaginated(shopIDQuery, page, limit)
+			data, totalCount, err = h.RecycleService.GetRecyclableItemsByShopIDPaginated(shopIDQuery, searchQuery, page, limit)
 		}
 	} else {
 		// Get all recyclable items regardless of shop
-		data, totalCount, err = h.RecycleService.GetRecyclableItemsPaginated(page, limit)
+		data, totalCount, err = h.RecycleService.GetRecyclableItemsPaginated(searchQuery, page, limit)
 	}
 
 	if err != nil {
 		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseModel{Message: err.Error()})
 	}
 
-	// Apply search filter if provided
-	if searchQuery != "" {
-		var filteredData []entities.RecyclableItemsModel
-		searchLower := strings.ToLower(strings.TrimSpace(searchQuery))
-
-		for _, item := range *data {
-			// Check if the item's name contains the search query (case-insensitive)
-			if item.Name != "" && strings.Contains(strings.ToLower(item.Name), searchLower) {
-				filteredData = append(filteredData, item)
-			} else if item.Category != "" && strings.Contains(strings.ToLower(item.Category), searchLower) {
-				// Also check category for search matches
-				filteredData = append(filteredData, item)
-			}
-		}
-
-		// Update data to be the filtered results
-		filteredDataPtr := &filteredData
-		data = filteredDataPtr
-
-		// Update total count to reflect filtered results
-		totalCount = int64(len(filteredData))
-	}
-
 	totalPages := int((totalCount + int64(limit) - 1) / int64(limit)) // Ceiling division
 
 	// Group items by name if not filtering by shop_id
@@ -128,13 +107,33 @@ func getShopInfo(shopID string, gateway *HTTPGateway) entities.ShopInfo {
 	}
 }
 
-// groupRecyclableItemsByName groups recyclable items by their name and collects shop info
+// groupRecyclableItemsByName groups recyclable items by their catalog material
+// and collects shop info with each shop's price. Items not mapped to the
+// catalog are grouped by name as before.
 func groupRecyclableItemsByName(items []entities.RecyclableItemsModel, gateway *HTTPGateway) []entities.GroupedRecyclableItem {
+	materialIDs := []string{}
+	for _, item := range items {
+		if item.MaterialID != "" {
+			materialIDs = append(materialIDs, item.MaterialID)
+		}
+	}
+	materials, err := gateway.RecycleService.GetMaterialsByIDs(materialIDs)
+	if err != nil {
+		fmt.Println("Error loading materials:", err)
+		materials = map[string]entities.MaterialModel{}
+	}
+
 	groupMap := make(map[string]*entities.GroupedRecyclableItem)
 
 	for _, item := range items {
-		key := item.Name
+		key := "name:" + item.Name
+		material, mapped := materials[item.MaterialID]
+		if mapped {
+			key = "material:" + material.MaterialID
+		}
 		shopInfo := getShopInfo(item.ShopID, gateway)
+		shopInfo.WasteID = item.WasteID
+		shopInfo.Price = item.Price
 
 		if existingItem, exists := groupMap[key]; exists {
 			// If item with same name exists, add shop info and waste_id to arrays
@@ -144,18 +143,38 @@ func groupRecyclableItemsByName(items []entities.RecyclableItemsModel, gateway *
 			if item.LastUpdate.After(existingItem.LastUpdate) {
 				existingItem.LastUpdate = item.LastUpdate
 			}
+			if item.Price < existingItem.MinPrice {
+				existingItem.MinPrice = item.Price
+			}
+			if item.Price > existingItem.MaxPrice {
+				existingItem.MaxPrice = item.Price
+				existingItem.Price = item.Price
+			}
 		} else {
 			// Create new grouped item
-			groupMap[key] = &entities.GroupedRecyclableItem{
+			grouped := &entities.GroupedRecyclableItem{
 				Name:       item.Name,
 				Category:   item.Category,
-				Price:      item.Price,
+				Price:      item.Price, // Best price offered
+				MinPrice:   item.Price,
+				MaxPrice:   item.Price,
 				LastUpdate: item.LastUpdate,
 				Hours:      item.Hours,
 				URL:        item.URL,
 				Shops:      []entities.ShopInfo{shopInfo},
 				WasteIDs:   []string{item.WasteID},
 			}
+			if mapped {
+				grouped.MaterialID = material.MaterialID
+				grouped.Code = material.Code
+				grouped.Name = material.NameTH
+				grouped.NameEN = material.NameEN
+				grouped.Category = material.Category
+				if material.ImageURL != "" {
+					grouped.URL = material.ImageURL
+				}
+			}
+			groupMap[key] = grouped
 		}
 	}
 
@@ -186,18 +205,6 @@ func groupRecyclableItemsByName(items []entities.RecyclableItemsModel, gateway *
 }
 
 func (h *HTTPGateway) AddRecycleWaste(ctx *fiber.Ctx) error {
-	// Decode JWT token to get user ID
-	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
-	if err != nil {
-		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "Unauthorized access"})
-	}
-
-	// Get user data to check role
-	userData, err := h.UserService.GetUser(tokenDetails.UserID)
-	if err != nil {
-		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "User not found"})
-	}
-
 	name := ctx.FormValue("name")
 	price := ctx.FormValue("price")
 	category := ctx.FormValue("category")
@@ -225,36 +232,18 @@ func (h *HTTPGateway) AddRecycleWaste(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
 	}
 
-	// Automatically determine the shop_id based on the user_id
-	shopID := ""
-	if userData.Role != string(entities.UserRoleAdmin) && userData.Role != string(entities.UserRoleModerator) {
-		// For regular users, get their shop automatically
-		userShop, err := h.ShopService.GetShopByUserID(tokenDetails.UserID)
-		if err != nil || userShop == nil {
-			return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "Access denied: You must have a shop to add items to"})
-		}
-		shopID = userShop.ShopID
-	} else {
-		// For admin and moderator, allow them to specify a shop_id in the request (or get from request for backward compatibility)
-		// But normally, they would have some way to specify the target shop in a real implementation
-		requestedShopID := ctx.FormValue("shop_id") // Still allow shop_id in the request for admin/moderator
-		if requestedShopID != "" {
-			shopID = requestedShopID
-		} else {
-			// If no shop_id in request, get the admin/moderator's own shop if they have one
-			userShop, err := h.ShopService.GetShopByUserID(tokenDetails.UserID)
-			if err != nil || userShop == nil {
-				return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "Access denied: Admin/Moderator must specify a shop_id or have a shop"})
-			}
-			shopID = userShop.ShopID
-		}
+	// Items go to the caller's own shop unless an accessible shop_id is given
+	shopID, err := h.wasteTargetShop(ctx, ctx.FormValue("shop_id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: err.Error()})
 	}
 
 	bodyData := entities.RecyclableItemsModel{
-		Name:     name,
-		Price:    priceFloat,
-		Category: category,
-		ShopID:   shopID, // Include the auto-determined shop_id in the data
+		Name:       name,
+		Price:      priceFloat,
+		Category:   category,
+		ShopID:     shopID, // Include the auto-determined shop_id in the data
+		MaterialID: ctx.FormValue("material_id"),
 	}
 	if err := h.RecycleService.AddRecycleWaste(bodyData, fileBytes); err != nil {
 		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: err.Error()})
@@ -263,18 +252,6 @@ func (h *HTTPGateway) AddRecycleWaste(ctx *fiber.Ctx) error {
 }
 
 func (h *HTTPGateway) DeleteRecycleWaste(ctx *fiber.Ctx) error {
-	// Decode JWT token to get user ID
-	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
-	if err != nil {
-		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "Unauthorized access"})
-	}
-
-	// Get user data to check role
-	userData, err := h.UserService.GetUser(tokenDetails.UserID)
-	if err != nil {
-		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "User not found"})
-	}
-
 	id := ctx.Params("waste_id")
 	if id == "" {
 		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid query params"})
@@ -286,22 +263,8 @@ func (h *HTTPGateway) DeleteRecycleWaste(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusNotFound).JSON(entities.ResponseMessage{Message: "Waste item not found"})
 	}
 
-	// Check permissions: admin and moderator can delete items for any shop
-	// Regular users can only delete items from their own shop
-	if userData.Role != string(entities.UserRoleAdmin) && userData.Role != string(entities.UserRoleModerator) {
-		// Check if user owns the shop that has this item
-		if foundItem.ShopID != "" {
-			shop, err := h.ShopService.GetShopByShopID(foundItem.ShopID)
-			if err != nil || shop == nil {
-				return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "Access denied: Shop not found"})
-			}
-			if shop.UserID != tokenDetails.UserID {
-				return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "Access denied: You don't own this shop"})
-			}
-		} else {
-			// If the item doesn't have a shop_id, user needs to be admin/moderator
-			return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "Access denied: You don't have permission to delete this item"})
-		}
+	if err := middlewares.CheckShopAccess(ctx, foundItem.ShopID); err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: err.Error()})
 	}
 
 	err = h.RecycleService.DeleteWasteItem(id)
@@ -320,18 +283,6 @@ func (h *HTTPGateway) GetCategoryWaste(ctx *fiber.Ctx) error {
 }
 
 func (h *HTTPGateway) EditRecycleWaste(ctx *fiber.Ctx) error {
-	// Decode JWT token to get user ID
-	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
-	if err != nil {
-		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "Unauthorized access"})
-	}
-
-	// Get user data to check role
-	userData, err := h.UserService.GetUser(tokenDetails.UserID)
-	if err != nil {
-		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "User not found"})
-	}
-
 	wasteID := ctx.Params("waste_id")
 	name := ctx.FormValue("name")
 	price := ctx.FormValue("price")
@@ -366,58 +317,182 @@ func (h *HTTPGateway) EditRecycleWaste(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusNotFound).JSON(entities.ResponseMessage{Message: "Waste item not found"})
 	}
 
-	// Check permissions: admin and moderator can edit items for any shop
-	// Regular users can only edit items from their own shop
-	if userData.Role != string(entities.UserRoleAdmin) && userData.Role != string(entities.UserRoleModerator) {
-		// Check if user owns the shop that has this item
-		if foundItem.ShopID != "" {
-			shop, err := h.ShopService.GetShopByShopID(foundItem.ShopID)
-			if err != nil || shop == nil {
-				return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "Access denied: Shop not found"})
-			}
-			if shop.UserID != tokenDetails.UserID {
-				return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "Access denied: You don't own this shop"})
-			}
-		} else {
-			// If the item doesn't have a shop_id, user needs to be admin/moderator
-			return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "Access denied: You don't have permission to edit this item"})
-		}
+	if err := middlewares.CheckShopAccess(ctx, foundItem.ShopID); err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: err.Error()})
 	}
 
-	// Automatically determine the shop_id based on the user_id
-	shopID := ""
-	if userData.Role != string(entities.UserRoleAdmin) && userData.Role != string(entities.UserRoleModerator) {
-		// For regular users, get their shop automatically
-		userShop, err := h.ShopService.GetShopByUserID(tokenDetails.UserID)
-		if err != nil || userShop == nil {
-			return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "Access denied: You must have a shop to edit items in"})
-		}
-		shopID = userShop.ShopID
-	} else {
-		// For admin and moderator, allow them to specify a shop_id in the request (or get from request for backward compatibility)
-		// But normally, they would have some way to specify the target shop in a real implementation
-		requestedShopID := ctx.FormValue("shop_id") // Still allow shop_id in the request for admin/moderator
-		if requestedShopID != "" {
-			shopID = requestedShopID
-		} else {
-			// If no shop_id in request, use the existing shop_id of the item if it's theirs
-			// Or get the admin/moderator's own shop if they have one
-			userShop, err := h.ShopService.GetShopByUserID(tokenDetails.UserID)
-			if err != nil || userShop == nil {
-				return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "Access denied: Admin/Moderator must specify a shop_id or have a shop"})
-			}
-			shopID = userShop.ShopID
+	// The item stays in its shop unless it is moved to another accessible shop
+	shopID := foundItem.ShopID
+	if requestedShopID := ctx.FormValue("shop_id"); requestedShopID != "" && requestedShopID != shopID {
+		if shopID, err = h.wasteTargetShop(ctx, requestedShopID); err != nil {
+			return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: err.Error()})
 		}
 	}
 
 	bodyData := entities.RecyclableItemsModel{
-		Name:     name,
-		Price:    priceFloat,
-		Category: category,
-		ShopID:   shopID, // Include the auto-determined shop_id in the data
+		Name:       name,
+		Price:      priceFloat,
+		Category:   category,
+		ShopID:     shopID, // Include the auto-determined shop_id in the data
+		MaterialID: ctx.FormValue("material_id"),
 	}
-	if err := h.RecycleService.EditWasteItem(wasteID, bodyData, fileBytes); err != nil {
+	if err := h.RecycleService.EditWasteItem(requestMeta(ctx), wasteID, bodyData, fileBytes); err != nil {
 		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: err.Error()})
 	}
 	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success"})
 }
+
+// wasteTargetShop picks the shop a waste item is written to: the requested
+// shop if the caller may act for it, otherwise the caller's own shop.
+func (h *HTTPGateway) wasteTargetShop(ctx *fiber.Ctx, requestedShopID string) (string, error) {
+	if requestedShopID != "" {
+		if err := middlewares.CheckShopAccess(ctx, requestedShopID); err != nil {
+			return "", err
+		}
+		return requestedShopID, nil
+	}
+
+	actor, err := middlewares.CurrentActor(ctx)
+	if err != nil {
+		return "", err
+	}
+	userShop, err := h.ShopService.GetActingShop(actor)
+	if err != nil {
+		return "", fmt.Errorf("access denied: %v", err)
+	}
+	return userShop.ShopID, nil
+}
+
+// GetPriceHistory returns the price changes of one item, by default over the last 30 days
+func (h *HTTPGateway) GetPriceHistory(ctx *fiber.Ctx) error {
+	from, to, err := priceSeriesRange(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+
+	history, err := h.RecycleService.GetPriceHistory(ctx.Params("waste_id"), from, to)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: history})
+}
+
+// GetMarketPrices returns the daily min, avg and max price of a material across
+// shops, by catalog material_id or, for unmapped items, by name
+func (h *HTTPGateway) GetMarketPrices(ctx *fiber.Ctx) error {
+	from, to, err := priceSeriesRange(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+
+	series, err := h.RecycleService.GetMarketPriceSeries(ctx.Query("material_id"), ctx.Query("name"), ctx.Query("category"), from, to)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: series})
+}
+
+// priceSeriesRange reads the from/to days (YYYY-MM-DD, Bangkok time), defaulting to the last 30 days
+func priceSeriesRange(ctx *fiber.Ctx) (time.Time, time.Time, error) {
+	// Stored times are Bangkok wall clock in UTC, so days are UTC midnights
+	today := time.Now().UTC().Add(7 * time.Hour).Truncate(24 * time.Hour)
+	from, to := today.AddDate(0, 0, -29), today
+
+	if value := ctx.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid 'from' date, use YYYY-MM-DD")
+		}
+		from = parsed
+	}
+	if value := ctx.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid 'to' date, use YYYY-MM-DD")
+		}
+		to = parsed
+	}
+	return from, to, nil
+}
+
+// priceListContentTypes maps an export format to its download content type
+var priceListContentTypes = map[string]string{
+	entities.PriceListCSV:  "text/csv; charset=utf-8",
+	entities.PriceListXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ExportPriceList downloads the shop's items as ?format=csv (default) or xlsx
+func (h *HTTPGateway) ExportPriceList(ctx *fiber.Ctx) error {
+	format := strings.ToLower(ctx.Query("format", entities.PriceListCSV))
+	contentType, ok := priceListContentTypes[format]
+	if !ok {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "format must be csv or xlsx"})
+	}
+	shopID, err := h.wasteTargetShop(ctx, ctx.Query("shop_id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+
+	file, err := h.RecycleService.ExportPriceList(shopID, format)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+	ctx.Set(fiber.HeaderContentType, contentType)
+	ctx.Attachment(fmt.Sprintf("price-list-%s.%s", shopID, format))
+	return ctx.Status(fiber.StatusOK).Send(file)
+}
+
+// ImportPriceList takes a multipart "file" (.csv or .xlsx, or set the format
+// form value). With ?dry_run=true it only previews the changes. A list with
+// row errors is rejected as a whole with 422 and the per-row report.
+func (h *HTTPGateway) ImportPriceList(ctx *fiber.Ctx) error {
+	upload, err := ctx.FormFile("file")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "file is required"})
+	}
+	format := strings.ToLower(ctx.FormValue("format"))
+	if format == "" {
+		format = strings.ToLower(strings.TrimPrefix(filepath.Ext(upload.Filename), "."))
+	}
+	if _, ok := priceListContentTypes[format]; !ok {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "file must be csv or xlsx"})
+	}
+	shopID, err := h.wasteTargetShop(ctx, ctx.FormValue("shop_id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+
+	file, err := upload.Open()
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "cannot read file"})
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "cannot read file"})
+	}
+
+	result, err := h.RecycleService.ImportPriceList(requestMeta(ctx), shopID, format, data, ctx.Query("dry_run") == "true")
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+	switch {
+	case len(result.WrittenRows) > 0 && !result.Applied:
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseModel{Message: "price list import failed part way, only written_rows were imported", Data: result})
+	case len(result.Errors) > 0:
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(entities.ResponseModel{Message: "price list has errors, nothing was imported", Data: result})
+	case result.DryRun:
+		return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "price list preview", Data: result})
+	default:
+		return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "price list imported", Data: result})
+	}
+}
+
+// SuggestMaterialNames autocompletes material names for ?q=, up to ?limit= (default 10, max 20)
+func (h *HTTPGateway) SuggestMaterialNames(ctx *fiber.Ctx) error {
+	suggestions, err := h.RecycleService.SuggestMaterialNames(ctx.Query("q"), ctx.QueryInt("limit", 10))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot get suggestions"})
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: suggestions})
+}
@@ -1,6 +1,7 @@
 package gateways
 
 import (
+	"recycle-waste-management-backend/src/domain/entities"
 	"recycle-waste-management-backend/src/middlewares"
 
 	"github.com/gofiber/contrib/websocket"
@@ -8,33 +9,46 @@ import (
 )
 
 func RouteUsers(gateway HTTPGateway, app *fiber.App) {
-	api := app.Group("/api/user")
-
-	api.Post("/add_user", gateway.CreateNewUserAccount)
-	api.Get("/users", gateway.GetAllUserData)
-	api.Put("/update_user", gateway.UpdateUserData)
-	api.Delete("/delete_user/:user_id", gateway.DeleteUser)
-	api.Get("/get_user", gateway.GetUser)
-
-	// Protected routes for current user profile
-	api.Get("/profile", middlewares.SetJWtHeaderHandler(), gateway.GetCurrentUser)
-	api.Put("/profile", middlewares.SetJWtHeaderHandler(), gateway.UpdateCurrentUser)
-	api.Post("/update-image-profile", middlewares.SetJWtHeaderHandler(), gateway.UpdateProfileImage)
-	api.Put("/update-role-user", middlewares.SetJWtHeaderHandler(), gateway.UpdateUserRoleUser)
+	api := app.Group("/api/user", middlewares.SetJWtHeaderHandler())
+
+	// User administration (admin only)
+	api.Post("/add_user", middlewares.RequirePermission(entities.PermissionUsersManage), gateway.CreateNewUserAccount)
+	api.Get("/users", middlewares.RequirePermission(entities.PermissionUsersRead), gateway.GetAllUserData)
+	api.Put("/update_user", middlewares.RequirePermission(entities.PermissionUsersManage), gateway.UpdateUserData)
+	api.Delete("/delete_user/:user_id", middlewares.RequirePermission(entities.PermissionUsersManage), gateway.DeleteUser)
+	api.Get("/get_user", middlewares.RequirePermission(entities.PermissionUsersRead), gateway.GetUser)
+
+	// Current user profile
+	api.Get("/profile", middlewares.RequirePermission(entities.PermissionProfileManage), gateway.GetCurrentUser)
+	api.Put("/profile", middlewares.RequirePermission(entities.PermissionProfileManage), gateway.UpdateCurrentUser)
+	api.Post("/update-image-profile", middlewares.RequirePermission(entities.PermissionProfileManage), gateway.UpdateProfileImage)
+	api.Get("/my-role", middlewares.RequirePermission(entities.PermissionProfileManage), gateway.GetCurrentUserRole)
+	// Personal data (PDPA)
+	api.Get("/me/export", middlewares.RequirePermission(entities.PermissionProfileManage), gateway.ExportMyData)
+	api.Delete("/me", middlewares.RequirePermission(entities.PermissionProfileManage), gateway.EraseMyAccount)
+	// Linked login accounts
+	api.Get("/identities", middlewares.RequirePermission(entities.PermissionProfileManage), gateway.GetIdentities)
+	api.Post("/identities/google", middlewares.RequirePermission(entities.PermissionProfileManage), gateway.LinkGoogleIdentity)
+	api.Post("/identities/:provider/link", middlewares.RequirePermission(entities.PermissionProfileManage), gateway.StartLinkIdentity)
+	api.Delete("/identities/:provider", middlewares.RequirePermission(entities.PermissionProfileManage), gateway.UnlinkIdentity)
 	// Role management (admin only)
-	api.Put("/update-role", middlewares.SetJWtHeaderHandler(), gateway.UpdateUserRole)
-	api.Get("/my-role", middlewares.SetJWtHeaderHandler(), gateway.GetCurrentUserRole)
+	api.Put("/update-role", middlewares.RequirePermission(entities.PermissionUsersAssignRole), gateway.UpdateUserRole)
 }
 
 func RouteRecycle(gateway HTTPGateway, app *fiber.App) {
 	api := app.Group("/api/recycle-waste")
 	api.Get("/get-wastes", gateway.GetRecycleWaste)
+	api.Get("/price-history/:waste_id", gateway.GetPriceHistory)
+	api.Get("/market-prices", gateway.GetMarketPrices)
+	api.Get("/suggest", gateway.SuggestMaterialNames)
 
-	// Protected routes requiring JWT authentication
-	protected := api.Group("", middlewares.SetJWtHeaderHandler())
+	// Protected routes requiring JWT authentication; shop ownership is checked per item
+	protected := api.Group("", middlewares.SetJWtHeaderHandler(), middlewares.RequirePermission(entities.PermissionWasteManage))
 	protected.Post("/add-waste", gateway.AddRecycleWaste)
 	protected.Delete("/delete-waste/:waste_id", gateway.DeleteRecycleWaste)
 	protected.Put("/edit-waste/:waste_id", gateway.EditRecycleWaste)
+	protected.Get("/price-list/export", gateway.ExportPriceList)
+	protected.Post("/price-list/import", gateway.ImportPriceList)
 }
 
 func RouteCategoryWaste(gateway HTTPGateway, app *fiber.App) {
@@ -44,8 +58,15 @@ func RouteCategoryWaste(gateway HTTPGateway, app *fiber.App) {
 
 func RouteAuth(gateway HTTPGateway, app *fiber.App) {
 	api := app.Group("/api/auth")
-	api.Get("github/callback", gateway.AuthGithubCallback)
-	api.Get("google/callback", gateway.AuthGoogleCallback)
+	api.Post("google/callback", gateway.AuthGoogleCallback)
+	// OAuth providers: github, line
+	api.Get("/:provider/login", gateway.StartOAuthLogin)
+	api.Get("/:provider/callback", gateway.OAuthCallback)
+	// Answer to the link prompt shown after an email match at login
+	api.Post("/link/confirm", middlewares.SetJWtHeaderHandler(), middlewares.RequirePermission(entities.PermissionProfileManage), gateway.ConfirmLink)
+	api.Post("/link/decline", gateway.DeclineLink)
+	api.Post("/phone/request-otp", gateway.RequestPhoneOTP)
+	api.Post("/phone/verify", gateway.VerifyPhoneOTP)
 }
 
 func RouteShop(gateway HTTPGateway, app *fiber.App) {
@@ -53,23 +74,25 @@ func RouteShop(gateway HTTPGateway, app *fiber.App) {
 
 	// Public routes
 	api.Get("/get-shops", gateway.GetAllShops)
+	api.Get("/nearby", gateway.FindNearbyShops)
 	api.Get("/get-shop/:shop_id", gateway.GetShopByShopID)
 	api.Get("/check-code", gateway.CheckShopCode)
 	// api.Get("/:shop_id", gateway.GetShopByShopID) // Add this line for cleaner URL
 
 	// Protected routes requiring JWT authentication
 	protected := api.Group("", middlewares.SetJWtHeaderHandler())
-	protected.Post("/create-shop", gateway.CreateShop)
-	protected.Get("/my-shop", gateway.GetShopByUserID)
-	protected.Put("/update-shop/:shop_id", gateway.UpdateShop)
-	protected.Delete("/delete-shop/:shop_id", gateway.DeleteShop)
+	protected.Post("/create-shop", middlewares.RequirePermission(entities.PermissionShopCreate), gateway.CreateShop)
+	protected.Get("/my-shop", middlewares.RequirePermission(entities.PermissionProfileManage), gateway.GetMyShop)
+	protected.Get("/my-shops", middlewares.RequirePermission(entities.PermissionProfileManage), gateway.GetMyShops)
+	protected.Put("/update-shop/:shop_id", middlewares.RequirePermission(entities.PermissionShopManage), middlewares.RequireShopAccess("shop_id"), gateway.UpdateShop)
+	protected.Delete("/delete-shop/:shop_id", middlewares.RequirePermission(entities.PermissionShopManage), middlewares.RequireShopAccess("shop_id"), gateway.DeleteShop)
 }
 
 func RouteSettings(gateway HTTPGateway, app *fiber.App) {
 	api := app.Group("/api/settings")
 
 	// Protected routes requiring JWT authentication
-	protected := api.Group("", middlewares.SetJWtHeaderHandler())
+	protected := api.Group("", middlewares.SetJWtHeaderHandler(), middlewares.RequirePermission(entities.PermissionProfileManage))
 	protected.Get("/", gateway.GetUserSettings)
 	protected.Put("/", gateway.UpdateUserSettings)
 	protected.Delete("/", gateway.DeleteUserSettings)
@@ -77,22 +100,29 @@ func RouteSettings(gateway HTTPGateway, app *fiber.App) {
 
 func RouteCustomerRequest(gateway HTTPGateway, app *fiber.App) {
 	api := app.Group("/api/customer-request", middlewares.SetJWtHeaderHandler())
-	api.Get("/my-request", gateway.GetCustomerRequestByRequestID)
-	api.Post("", gateway.AddCustomerRequest)
-	api.Delete("", gateway.DeleteCustomerRequest)
-	api.Put("", gateway.UpdateCustomerRequest)
-	api.Get("/all", gateway.GetCustomerRequests)
-	api.Put("/accept/:id", gateway.AcceptCustomerRequest)
-	api.Put("/cancel/:id", gateway.CancelCustomerRequest)
-	api.Put("/complete/:id", gateway.CompleteCustomerRequest)
-	api.Post("/walk-in", gateway.CreateWalkInRequest)
-}
-
-func RouteWebSocket(gateway HTTPGateway, app *fiber.App) {
-	// WebSocket chat endpoint
+
+	// Customer side
+	api.Get("/my-request", middlewares.RequirePermission(entities.PermissionRequestCreate), gateway.GetCustomerRequestByRequestID)
+	api.Post("", middlewares.RequirePermission(entities.PermissionRequestCreate), gateway.AddCustomerRequest)
+	api.Delete("", middlewares.RequirePermission(entities.PermissionRequestCreate), gateway.DeleteCustomerRequest)
+	api.Put("", middlewares.RequirePermission(entities.PermissionRequestCreate), gateway.UpdateCustomerRequest)
+
+	// Shop side
+	api.Get("/all", middlewares.RequirePermission(entities.PermissionRequestView), gateway.GetCustomerRequests)
+	api.Put("/accept/:id", middlewares.RequirePermission(entities.PermissionRequestManage), gateway.AcceptCustomerRequest)
+	api.Put("/cancel/:id", middlewares.RequireAnyPermission(entities.PermissionRequestCreate, entities.PermissionRequestManage), gateway.CancelCustomerRequest)
+	api.Put("/complete/:id", middlewares.RequirePermission(entities.PermissionRequestManage), gateway.CompleteCustomerRequest)
+	api.Post("/walk-in", middlewares.RequirePermission(entities.PermissionRequestManage), gateway.CreateWalkInRequest)
+}
+
+func RouteWebSocket(chatGateway *ChatGateway, app *fiber.App) {
+	// WebSocket chat endpoint; browsers can't set headers on the upgrade, so the token comes as ?token=
 	app.Get("/ws/chat",
-		gateway.WebSocketChatUpgrade,
-		websocket.New(gateway.HandleWebSocketChat),
+		chatGateway.WebSocketChatUpgrade,
+		middlewares.SetWebSocketJWTMiddleware(),
+		middlewares.RequirePermission(entities.PermissionChatUse),
+		chatGateway.AuthorizeChatRoom,
+		websocket.New(chatGateway.HandleWebSocketChat),
 	)
 }
 
@@ -104,7 +134,7 @@ func RouteReview(reviewGateway *ReviewGateway, app *fiber.App) {
 	api.Get("/check/:customer_request_id", reviewGateway.CheckReviewExists)
 
 	// Protected routes requiring JWT authentication
-	protected := api.Group("", middlewares.SetJWtHeaderHandler())
+	protected := api.Group("", middlewares.SetJWtHeaderHandler(), middlewares.RequirePermission(entities.PermissionRequestCreate))
 	protected.Post("", reviewGateway.CreateReview)
 	protected.Post("/skip", reviewGateway.SkipReview)
 }
@@ -112,23 +142,121 @@ func RouteReview(reviewGateway *ReviewGateway, app *fiber.App) {
 func RouteReceipt(receiptGateway *ReceiptGateway, app *fiber.App) {
 	api := app.Group("/api/receipts")
 
-	// Public routes
-	api.Get("/shop/:shop_id", receiptGateway.GetReceiptsByShopID)
-	api.Get("/:receipt_id", receiptGateway.GetReceiptByID)
+	// Also open to shop API keys with the receipts:view scope
+	api.Get("/shop/:shop_id", middlewares.SetJWTOrAPIKeyHandler(), middlewares.RequirePermission(entities.PermissionReceiptView), middlewares.RequireShopAccess("shop_id"), receiptGateway.GetReceiptsByShopID)
 
-	// Protected routes requiring JWT authentication
 	protected := api.Group("", middlewares.SetJWtHeaderHandler())
-	protected.Post("", receiptGateway.CreateReceipt)
-	protected.Get("/by-request/:request_id", receiptGateway.GetReceiptByRequestID)
+	protected.Post("", middlewares.RequirePermission(entities.PermissionReceiptCreate), receiptGateway.CreateReceipt)
+	protected.Post("/:receipt_id/void", middlewares.RequirePermission(entities.PermissionReceiptCreate), receiptGateway.VoidReceipt)
+	// All shops of the caller; shop owners only
+	protected.Get("/owner", middlewares.RequirePermission(entities.PermissionShopManage, entities.PermissionReceiptView), receiptGateway.GetOwnerReceipts)
+	// Customers see the receipt of their own request, shops the receipts they issued
+	protected.Get("/by-request/:request_id", middlewares.RequireAnyPermission(entities.PermissionRequestCreate, entities.PermissionReceiptView), receiptGateway.GetReceiptByRequestID)
+	protected.Get("/:receipt_id", middlewares.RequireAnyPermission(entities.PermissionRequestCreate, entities.PermissionReceiptView), receiptGateway.GetReceiptByID)
 }
 
 func RouteStock(stockGateway *StockGateway, app *fiber.App) {
-	api := app.Group("/api/stocks")
+	// Also open to shop API keys with the stock:view scope
+	api := app.Group("/api/stocks", middlewares.SetJWTOrAPIKeyHandler())
 
-	// Public routes
-	api.Get("/shop/:shop_id", stockGateway.GetStocksByShopID)
+	api.Get("/shop/:shop_id", middlewares.RequirePermission(entities.PermissionStockView), middlewares.RequireShopAccess("shop_id"), stockGateway.GetStocksByShopID)
+	// All shops of the caller; shop owners only
+	api.Get("/owner", middlewares.RequirePermission(entities.PermissionShopManage, entities.PermissionStockView), stockGateway.GetOwnerStocks)
 }
 
 func RouteEmployee(employeeGateway *EmployeeGateway, app *fiber.App) {
 	employeeGateway.SetupRoutes(app)
 }
+
+func RouteChat(chatGateway *ChatGateway, app *fiber.App) {
+	api := app.Group("/api/chat", middlewares.SetJWtHeaderHandler())
+	api.Get("/unread-count", middlewares.RequirePermission(entities.PermissionChatUse), chatGateway.GetUnreadCount)
+	api.Post("/:request_id/images", middlewares.RequirePermission(entities.PermissionChatUse), chatGateway.UploadChatImage)
+	api.Post("/messages/:message_id/report", middlewares.RequirePermission(entities.PermissionChatUse), chatGateway.ReportMessage)
+
+	// Moderation queue (admin only)
+	api.Get("/reports", middlewares.RequirePermission(entities.PermissionChatModerate), chatGateway.GetReportedMessages)
+	api.Put("/reports/:message_id", middlewares.RequirePermission(entities.PermissionChatModerate), chatGateway.ReviewReport)
+}
+
+func RouteSession(sessionGateway *SessionGateway, app *fiber.App) {
+	api := app.Group("/api/auth")
+	api.Post("/refresh", sessionGateway.RefreshToken)
+
+	// Users and employees manage their own sessions
+	protected := api.Group("", middlewares.SetJWtHeaderHandler())
+	protected.Post("/logout", sessionGateway.Logout)
+	protected.Post("/logout-all", sessionGateway.LogoutAll)
+	protected.Get("/sessions", sessionGateway.GetMySessions)
+	protected.Delete("/sessions/:session_id", sessionGateway.RevokeSession)
+}
+
+func RouteTwoFactor(twoFactorGateway *TwoFactorGateway, app *fiber.App) {
+	api := app.Group("/api/auth/2fa")
+
+	// Second step of a login that returned a challenge
+	api.Post("/login/setup", twoFactorGateway.SetupForLogin)
+	api.Post("/login/verify", twoFactorGateway.VerifyLogin)
+
+	// Users and employees manage their own second factor
+	api.Get("", middlewares.SetJWtHeaderHandler(), twoFactorGateway.GetStatus)
+	api.Post("/setup", middlewares.SetJWtHeaderHandler(), twoFactorGateway.Setup)
+	api.Post("/enable", middlewares.SetJWtHeaderHandler(), twoFactorGateway.Enable)
+	api.Post("/disable", middlewares.SetJWtHeaderHandler(), twoFactorGateway.Disable)
+	api.Post("/recovery-codes", middlewares.SetJWtHeaderHandler(), twoFactorGateway.RegenerateRecoveryCodes)
+}
+
+func RouteAPIKey(apiKeyGateway *APIKeyGateway, app *fiber.App) {
+	// Shop owners manage the API keys of their shop
+	api := app.Group("/api/shops/:shop_id/api-keys", middlewares.SetJWtHeaderHandler(), middlewares.RequirePermission(entities.PermissionShopManage), middlewares.RequireShopAccess("shop_id"))
+	api.Post("", apiKeyGateway.CreateAPIKey)
+	api.Get("", apiKeyGateway.GetAPIKeys)
+	api.Delete("/:key_id", apiKeyGateway.RevokeAPIKey)
+}
+
+func RouteAudit(auditGateway *AuditGateway, app *fiber.App) {
+	api := app.Group("/api/audit", middlewares.SetJWtHeaderHandler())
+
+	// Shop owners read the events of their own shop
+	api.Get("/shop/:shop_id", middlewares.RequirePermission(entities.PermissionShopManage), middlewares.RequireShopAccess("shop_id"), auditGateway.GetShopEvents)
+
+	api.Get("", middlewares.RequirePermission(entities.PermissionAuditRead), auditGateway.GetEvents)
+	api.Get("/verify", middlewares.RequirePermission(entities.PermissionAuditRead), auditGateway.Verify)
+}
+
+func RouteShopVerification(verificationGateway *ShopVerificationGateway, app *fiber.App) {
+	api := app.Group("/api/shop-verification", middlewares.SetJWtHeaderHandler())
+
+	// Shop owners apply with their business documents
+	api.Post("", middlewares.RequirePermission(entities.PermissionShopCreate), verificationGateway.Apply)
+	api.Get("/me", middlewares.RequirePermission(entities.PermissionShopCreate), verificationGateway.GetMyApplication)
+
+	// Review queue (admin only)
+	api.Get("", middlewares.RequirePermission(entities.PermissionShopVerify), verificationGateway.GetApplications)
+	api.Get("/:application_id", middlewares.RequirePermission(entities.PermissionShopVerify), verificationGateway.GetApplication)
+	api.Put("/:application_id/review", middlewares.RequirePermission(entities.PermissionShopVerify), verificationGateway.ReviewApplication)
+}
+
+func RouteMaterial(materialGateway *MaterialGateway, app *fiber.App) {
+	api := app.Group("/api/materials")
+
+	// The catalog is public; only admins maintain it
+	api.Get("", materialGateway.GetMaterials)
+	api.Get("/:material_id", materialGateway.GetMaterial)
+	api.Post("", middlewares.SetJWtHeaderHandler(), middlewares.RequirePermission(entities.PermissionCatalogManage), materialGateway.CreateMaterial)
+	api.Put("/:material_id", middlewares.SetJWtHeaderHandler(), middlewares.RequirePermission(entities.PermissionCatalogManage), materialGateway.UpdateMaterial)
+	api.Delete("/:material_id", middlewares.SetJWtHeaderHandler(), middlewares.RequirePermission(entities.PermissionCatalogManage), materialGateway.DeleteMaterial)
+}
+
+func RouteCategoryAdmin(categoryGateway *CategoryGateway, app *fiber.App) {
+	// Shares /api/category-waste with the public get-category route
+	api := app.Group("/api/category-waste", middlewares.SetJWtHeaderHandler(), middlewares.RequirePermission(entities.PermissionCatalogManage))
+
+	api.Get("/all", categoryGateway.GetAllCategories)
+	api.Post("", categoryGateway.CreateCategory)
+	api.Put("/order", categoryGateway.ReorderCategories)
+	api.Put("/:category_id", categoryGateway.UpdateCategory)
+	api.Put("/:category_id/activate", categoryGateway.ActivateCategory)
+	api.Put("/:category_id/deactivate", categoryGateway.DeactivateCategory)
+	api.Post("/:category_id/merge", categoryGateway.MergeCategory)
+}
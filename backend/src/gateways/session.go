@@ -0,0 +1,91 @@
+package gateways
+
+import (
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/middlewares"
+	"recycle-waste-management-backend/src/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SessionGateway struct {
+	SessionService services.ISessionService
+}
+
+func NewSessionGateway(sessionService services.ISessionService) *SessionGateway {
+	return &SessionGateway{
+		SessionService: sessionService,
+	}
+}
+
+// RefreshToken handles POST /api/auth/refresh
+func (g *SessionGateway) RefreshToken(ctx *fiber.Ctx) error {
+	var body entities.RefreshTokenRequest
+	if err := ctx.BodyParser(&body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid json body"})
+	}
+
+	tokens, err := g.SessionService.Refresh(body.RefreshToken, clientInfo(ctx))
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "invalid refresh token"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: tokens})
+}
+
+// Logout handles POST /api/auth/logout
+func (g *SessionGateway) Logout(ctx *fiber.Ctx) error {
+	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	if err := g.SessionService.Logout(tokenDetails); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot logout"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseMessage{Message: "logged out"})
+}
+
+// LogoutAll handles POST /api/auth/logout-all
+func (g *SessionGateway) LogoutAll(ctx *fiber.Ctx) error {
+	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	if err := g.SessionService.LogoutAll(tokenDetails.UserID); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot logout"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseMessage{Message: "logged out from all sessions"})
+}
+
+// GetMySessions handles GET /api/auth/sessions
+func (g *SessionGateway) GetMySessions(ctx *fiber.Ctx) error {
+	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	sessions, err := g.SessionService.GetSessions(tokenDetails)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot get sessions"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: sessions})
+}
+
+// RevokeSession handles DELETE /api/auth/sessions/:session_id
+func (g *SessionGateway) RevokeSession(ctx *fiber.Ctx) error {
+	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	if err := g.SessionService.RevokeSession(tokenDetails.UserID, ctx.Params("session_id")); err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseMessage{Message: "session revoked"})
+}
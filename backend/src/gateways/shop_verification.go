@@ -0,0 +1,128 @@
+package gateways
+
+import (
+	"errors"
+	"strconv"
+
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/middlewares"
+	"recycle-waste-management-backend/src/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+type ShopVerificationGateway struct {
+	VerificationService services.IShopVerificationService
+}
+
+func NewShopVerificationGateway(verificationService services.IShopVerificationService) *ShopVerificationGateway {
+	return &ShopVerificationGateway{
+		VerificationService: verificationService,
+	}
+}
+
+// Apply handles POST /api/shop-verification (multipart form).
+// Files are sent as "documents", with an optional "document_types" value per file.
+func (g *ShopVerificationGateway) Apply(ctx *fiber.Ctx) error {
+	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	var req entities.ShopVerificationRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid form body"})
+	}
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "documents must be sent as multipart/form-data"})
+	}
+
+	application, err := g.VerificationService.Apply(tokenDetails.UserID, req, form.File["documents"], form.Value["document_types"])
+	if err != nil {
+		log.Error("Failed to submit shop verification:", err)
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(entities.ResponseModel{Message: "verification submitted", Data: application})
+}
+
+// GetMyApplication handles GET /api/shop-verification/me
+func (g *ShopVerificationGateway) GetMyApplication(ctx *fiber.Ctx) error {
+	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	application, err := g.VerificationService.GetMyApplication(tokenDetails.UserID)
+	if err != nil {
+		if errors.Is(err, services.ErrShopVerificationNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(entities.ResponseMessage{Message: err.Error()})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot get verification application"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: application})
+}
+
+// GetApplications handles GET /api/shop-verification?status=pending&page=1&limit=20 (admin only)
+func (g *ShopVerificationGateway) GetApplications(ctx *fiber.Ctx) error {
+	page, err := strconv.Atoi(ctx.Query("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(ctx.Query("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	applications, total, err := g.VerificationService.GetApplications(ctx.Query("status"), page, limit)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponsePaginationModel{
+		Message:    "success",
+		Data:       applications,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+		TotalItems: total,
+	})
+}
+
+// GetApplication handles GET /api/shop-verification/:application_id (admin only)
+func (g *ShopVerificationGateway) GetApplication(ctx *fiber.Ctx) error {
+	application, err := g.VerificationService.GetApplication(ctx.Params("application_id"))
+	if err != nil {
+		if errors.Is(err, services.ErrShopVerificationNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(entities.ResponseMessage{Message: err.Error()})
+		}
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot get verification application"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: application})
+}
+
+// ReviewApplication handles PUT /api/shop-verification/:application_id/review (admin only)
+func (g *ShopVerificationGateway) ReviewApplication(ctx *fiber.Ctx) error {
+	if _, err := middlewares.DecodeJWTToken(ctx); err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	var body entities.ReviewShopVerificationRequest
+	if err := ctx.BodyParser(&body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid json body"})
+	}
+
+	if err := g.VerificationService.Review(requestMeta(ctx), ctx.Params("application_id"), body); err != nil {
+		if errors.Is(err, services.ErrShopVerificationNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(entities.ResponseMessage{Message: err.Error()})
+		}
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseMessage{Message: "verification reviewed"})
+}
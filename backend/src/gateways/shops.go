@@ -1,12 +1,15 @@
 package gateways
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"recycle-waste-management-backend/src/domain/entities"
 	"recycle-waste-management-backend/src/middlewares"
+	"recycle-waste-management-backend/src/services"
 	"strconv"
 
+	"github.com/goccy/go-json"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -22,8 +25,8 @@ func (h *HTTPGateway) CreateShop(ctx *fiber.Ctx) error {
 	address := ctx.FormValue("address")
 	phone := ctx.FormValue("phone")
 	email := ctx.FormValue("email")
-	openingTime := ctx.FormValue("opening_time")
-	closingTime := ctx.FormValue("closing_time")
+	openingHoursJSON := ctx.FormValue("opening_hours")
+	closuresJSON := ctx.FormValue("closures")
 	latitudeStr := ctx.FormValue("latitude")
 	longitudeStr := ctx.FormValue("longitude")
 
@@ -61,12 +64,21 @@ func (h *HTTPGateway) CreateShop(ctx *fiber.Ctx) error {
 		Address:     address,
 		Phone:       phone,
 		Email:       email,
-		OpeningTime: openingTime,
-		ClosingTime: closingTime,
 		Latitude:    latitude,
 		Longitude:   longitude,
 	}
 
+	if openingHoursJSON != "" {
+		if err := json.Unmarshal([]byte(openingHoursJSON), &shopRequest.OpeningHours); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "Invalid opening_hours JSON"})
+		}
+	}
+	if closuresJSON != "" {
+		if err := json.Unmarshal([]byte(closuresJSON), &shopRequest.Closures); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "Invalid closures JSON"})
+		}
+	}
+
 	if err := h.ShopService.CreateShop(tokenDetails.UserID, shopRequest, fileBytes); err != nil {
 		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: err.Error()})
 	}
@@ -88,21 +100,39 @@ func (h *HTTPGateway) GetShopByShopID(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: shop})
 }
 
-func (h *HTTPGateway) GetShopByUserID(ctx *fiber.Ctx) error {
-	// Decode JWT token to get user ID
-	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
+// GetMyShop returns the shop the caller acts for: the one in the X-Shop-ID header or their only shop
+func (h *HTTPGateway) GetMyShop(ctx *fiber.Ctx) error {
+	actor, err := middlewares.CurrentActor(ctx)
 	if err != nil {
 		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "Unauthorized access"})
 	}
 
-	shop, err := h.ShopService.GetShopByUserID(tokenDetails.UserID)
+	shop, err := h.ShopService.GetActingShop(actor)
 	if err != nil {
+		if errors.Is(err, services.ErrActiveShopRequired) {
+			return ctx.Status(fiber.StatusConflict).JSON(entities.ResponseMessage{Message: err.Error()})
+		}
 		return ctx.Status(fiber.StatusNotFound).JSON(entities.ResponseMessage{Message: err.Error()})
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: shop})
 }
 
+// GetMyShops lists every shop the caller owns
+func (h *HTTPGateway) GetMyShops(ctx *fiber.Ctx) error {
+	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "Unauthorized access"})
+	}
+
+	shops, err := h.ShopService.GetShopsByUserID(tokenDetails.UserID)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot get shops"})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: shops})
+}
+
 func (h *HTTPGateway) GetAllShops(ctx *fiber.Ctx) error {
 	page := 1
 	limit := 12
@@ -122,7 +152,9 @@ func (h *HTTPGateway) GetAllShops(ctx *fiber.Ctx) error {
 		}
 	}
 
-	shops, totalCount, err := h.ShopService.GetAllShops(page, limit)
+	openNow := ctx.Query("open_now") == "true"
+
+	shops, totalCount, err := h.ShopService.GetAllShops(page, limit, openNow)
 	if err != nil {
 		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseModel{Message: err.Error()})
 	}
@@ -141,6 +173,62 @@ func (h *HTTPGateway) GetAllShops(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).JSON(response)
 }
 
+// FindNearbyShops lists shops around the customer's coordinates, nearest first
+func (h *HTTPGateway) FindNearbyShops(ctx *fiber.Ctx) error {
+	latitude, latErr := strconv.ParseFloat(ctx.Query("lat"), 64)
+	longitude, lonErr := strconv.ParseFloat(ctx.Query("lon"), 64)
+	if latErr != nil || lonErr != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "lat and lon are required"})
+	}
+
+	query := entities.NearbyShopQuery{
+		Latitude:      latitude,
+		Longitude:     longitude,
+		MaxDistanceKm: 10,
+		MaterialID:    ctx.Query("material_id"),
+		Material:      ctx.Query("material"),
+		Category:      ctx.Query("category"),
+		OpenNow:       ctx.Query("open_now") == "true",
+	}
+	if maxDistance := ctx.Query("max_distance_km"); maxDistance != "" {
+		d, err := strconv.ParseFloat(maxDistance, 64)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "Invalid max_distance_km"})
+		}
+		query.MaxDistanceKm = d
+	}
+	if minRating := ctx.Query("min_rating"); minRating != "" {
+		r, err := strconv.ParseFloat(minRating, 64)
+		if err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "Invalid min_rating"})
+		}
+		query.MinRating = r
+	}
+
+	page := ctx.QueryInt("page", 1)
+	limit := ctx.QueryInt("limit", 12)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 12
+	}
+
+	shops, totalCount, err := h.ShopService.FindNearbyShops(query, page, limit)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponsePaginationModel{
+		Message:    "success",
+		Data:       shops,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int((totalCount + int64(limit) - 1) / int64(limit)),
+		TotalItems: totalCount,
+	})
+}
+
 func (h *HTTPGateway) UpdateShop(ctx *fiber.Ctx) error {
 	// Decode JWT token to get user ID
 	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
@@ -169,8 +257,8 @@ func (h *HTTPGateway) UpdateShop(ctx *fiber.Ctx) error {
 	address := ctx.FormValue("address")
 	phone := ctx.FormValue("phone")
 	email := ctx.FormValue("email")
-	openingTime := ctx.FormValue("opening_time")
-	closingTime := ctx.FormValue("closing_time")
+	openingHoursJSON := ctx.FormValue("opening_hours")
+	closuresJSON := ctx.FormValue("closures")
 	latitudeStr := ctx.FormValue("latitude")
 	longitudeStr := ctx.FormValue("longitude")
 	shopCode := ctx.FormValue("shop_code")
@@ -194,11 +282,19 @@ func (h *HTTPGateway) UpdateShop(ctx *fiber.Ctx) error {
 	if email != "" {
 		updateRequest.Email = &email
 	}
-	if openingTime != "" {
-		updateRequest.OpeningTime = &openingTime
+	if openingHoursJSON != "" {
+		var openingHours entities.WeeklySchedule
+		if err := json.Unmarshal([]byte(openingHoursJSON), &openingHours); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "Invalid opening_hours JSON"})
+		}
+		updateRequest.OpeningHours = &openingHours
 	}
-	if closingTime != "" {
-		updateRequest.ClosingTime = &closingTime
+	if closuresJSON != "" {
+		closures := []entities.ShopClosure{}
+		if err := json.Unmarshal([]byte(closuresJSON), &closures); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "Invalid closures JSON"})
+		}
+		updateRequest.Closures = &closures
 	}
 	if latitudeStr != "" {
 		var latitude float64
@@ -230,13 +326,15 @@ func (h *HTTPGateway) UpdateShop(ctx *fiber.Ctx) error {
 		}
 	}
 
-	if err := h.ShopService.UpdateShop(shopID, updateRequest, fileBytes); err != nil {
+	if err := h.ShopService.UpdateShop(requestMeta(ctx), shopID, updateRequest, fileBytes); err != nil {
 		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: err.Error()})
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "Shop updated successfully"})
 }
 
+// DeleteShop archives a shop with receipts and purges one without. With
+// dry_run=true it only reports what would happen; otherwise confirm=true is required.
 func (h *HTTPGateway) DeleteShop(ctx *fiber.Ctx) error {
 	// Decode JWT token to get user ID
 	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
@@ -249,22 +347,33 @@ func (h *HTTPGateway) DeleteShop(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "Shop ID is required"})
 	}
 
-	// Get existing shop to verify ownership
-	existingShop, err := h.ShopService.GetShopByShopID(shopID)
+	summary, err := h.ShopService.PreviewShopDeletion(shopID)
 	if err != nil {
-		return ctx.Status(fiber.StatusNotFound).JSON(entities.ResponseMessage{Message: "Shop not found"})
+		return ctx.Status(fiber.StatusNotFound).JSON(entities.ResponseMessage{Message: err.Error()})
 	}
 
 	// Check if the current user owns this shop
-	if existingShop.UserID != tokenDetails.UserID {
+	if summary.OwnerID != tokenDetails.UserID {
 		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "Access denied: You don't own this shop"})
 	}
 
-	if err := h.ShopService.DeleteShop(shopID); err != nil {
+	if ctx.Query("dry_run") == "true" {
+		return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "Dry run: nothing was deleted", Data: summary})
+	}
+	if ctx.Query("confirm") != "true" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseModel{Message: "Review the summary and repeat with confirm=true", Data: summary})
+	}
+
+	summary, err = h.ShopService.DeleteShop(requestMeta(ctx), shopID)
+	if err != nil {
 		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: err.Error()})
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "Shop deleted successfully"})
+	message := "Shop deleted successfully"
+	if summary.Action == entities.ShopDeletionArchive {
+		message = "Shop has receipts and was archived"
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: message, Data: summary})
 }
 
 func (h *HTTPGateway) CheckShopCode(ctx *fiber.Ctx) error {
@@ -3,6 +3,7 @@ package gateways
 import (
 	"strconv"
 
+	"recycle-waste-management-backend/src/middlewares"
 	"recycle-waste-management-backend/src/services"
 
 	"github.com/gofiber/fiber/v2"
@@ -73,3 +74,28 @@ func (h *StockGateway) GetStocksByShopID(ctx *fiber.Ctx) error {
 		"total_pages": totalPages,
 	})
 }
+
+// GetOwnerStocks handles GET /api/stocks/owner: the stock of all of the caller's shops
+func (h *StockGateway) GetOwnerStocks(ctx *fiber.Ctx) error {
+	actor, err := middlewares.CurrentActor(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	summary, err := h.StockService.GetOwnerStocks(actor.ID)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": "Failed to get stocks",
+			"error":   err.Error(),
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Stocks retrieved successfully",
+		"data":    summary,
+	})
+}
@@ -0,0 +1,159 @@
+package gateways
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/middlewares"
+	"recycle-waste-management-backend/src/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+type TwoFactorGateway struct {
+	TwoFactorService services.ITwoFactorService
+}
+
+func NewTwoFactorGateway(twoFactorService services.ITwoFactorService) *TwoFactorGateway {
+	return &TwoFactorGateway{
+		TwoFactorService: twoFactorService,
+	}
+}
+
+// VerifyLogin handles POST /api/auth/2fa/login/verify with the mfa_token of a challenged login
+func (g *TwoFactorGateway) VerifyLogin(ctx *fiber.Ctx) error {
+	var req entities.TwoFactorLoginRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid json body"})
+	}
+	if req.MFAToken == "" || req.Code == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "mfa_token and code are required"})
+	}
+
+	response, err := g.TwoFactorService.VerifyLogin(req.MFAToken, req.Code, clientInfo(ctx))
+	if err != nil {
+		return twoFactorErrorResponse(ctx, err)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: response})
+}
+
+// SetupForLogin handles POST /api/auth/2fa/login/setup, for admins who must enroll before their login completes
+func (g *TwoFactorGateway) SetupForLogin(ctx *fiber.Ctx) error {
+	var req entities.TwoFactorSetupRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid json body"})
+	}
+
+	setup, err := g.TwoFactorService.SetupForLogin(req.MFAToken)
+	if err != nil {
+		return twoFactorErrorResponse(ctx, err)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: setup})
+}
+
+// GetStatus handles GET /api/auth/2fa
+func (g *TwoFactorGateway) GetStatus(ctx *fiber.Ctx) error {
+	actor, err := middlewares.CurrentActor(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	status, err := g.TwoFactorService.GetStatus(actor)
+	if err != nil {
+		return twoFactorErrorResponse(ctx, err)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: status})
+}
+
+// Setup handles POST /api/auth/2fa/setup. The returned secret is only active after Enable.
+func (g *TwoFactorGateway) Setup(ctx *fiber.Ctx) error {
+	actor, err := middlewares.CurrentActor(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	setup, err := g.TwoFactorService.Setup(actor)
+	if err != nil {
+		return twoFactorErrorResponse(ctx, err)
+	}
+	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "success", Data: setup})
+}
+
+// Enable handles POST /api/auth/2fa/enable with a code from the authenticator app
+func (g *TwoFactorGateway) Enable(ctx *fiber.Ctx) error {
+	return g.withCode(ctx, func(actor entities.Actor, code string) error {
+		codes, err := g.TwoFactorService.Enable(actor, code)
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{
+			Message: "two-factor authentication enabled",
+			Data:    entities.TwoFactorRecoveryCodesResponse{RecoveryCodes: codes},
+		})
+	})
+}
+
+// Disable handles POST /api/auth/2fa/disable. Admins cannot turn two-factor off.
+func (g *TwoFactorGateway) Disable(ctx *fiber.Ctx) error {
+	return g.withCode(ctx, func(actor entities.Actor, code string) error {
+		if err := g.TwoFactorService.Disable(actor, code); err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusOK).JSON(entities.ResponseMessage{Message: "two-factor authentication disabled"})
+	})
+}
+
+// RegenerateRecoveryCodes handles POST /api/auth/2fa/recovery-codes
+func (g *TwoFactorGateway) RegenerateRecoveryCodes(ctx *fiber.Ctx) error {
+	return g.withCode(ctx, func(actor entities.Actor, code string) error {
+		codes, err := g.TwoFactorService.RegenerateRecoveryCodes(actor, code)
+		if err != nil {
+			return err
+		}
+		return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{
+			Message: "success",
+			Data:    entities.TwoFactorRecoveryCodesResponse{RecoveryCodes: codes},
+		})
+	})
+}
+
+// withCode parses a TwoFactorCodeRequest for the current actor and maps service errors
+func (g *TwoFactorGateway) withCode(ctx *fiber.Ctx, handle func(actor entities.Actor, code string) error) error {
+	actor, err := middlewares.CurrentActor(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+
+	var req entities.TwoFactorCodeRequest
+	if err := ctx.BodyParser(&req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid json body"})
+	}
+	if req.Code == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "code is required"})
+	}
+
+	if err := handle(actor, req.Code); err != nil {
+		return twoFactorErrorResponse(ctx, err)
+	}
+	return nil
+}
+
+func twoFactorErrorResponse(ctx *fiber.Ctx, err error) error {
+	var locked *services.LoginLockedError
+	switch {
+	case errors.As(err, &locked):
+		ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+		return ctx.Status(fiber.StatusTooManyRequests).JSON(entities.ResponseMessage{Message: err.Error()})
+	case errors.Is(err, services.ErrInvalidMFAToken), errors.Is(err, services.ErrTwoFactorCodeInvalid):
+		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: err.Error()})
+	case errors.Is(err, services.ErrTwoFactorRequired):
+		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: err.Error()})
+	case errors.Is(err, services.ErrTwoFactorNotEnabled), errors.Is(err, services.ErrTwoFactorAlreadyActive):
+		return ctx.Status(fiber.StatusConflict).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+	log.Error("Two-factor request failed:", err)
+	return ctx.Status(fiber.StatusInternalServerError).JSON(entities.ResponseMessage{Message: "cannot complete two-factor request"})
+}
@@ -57,9 +57,9 @@ func (h *HTTPGateway) DeleteUser(ctx *fiber.Ctx) error {
 	if userID == "" {
 		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid query params"})
 	}
-	err := h.UserService.DeleteUser(userID)
-	if err != nil {
-		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "cannot delete user data"})
+	if err := h.PrivacyService.EraseUser(requestMeta(ctx), userID); err != nil {
+		log.Error("Failed to erase user:", err)
+		return ctx.Status(privacyErrorStatus(err)).JSON(entities.ResponseMessage{Message: err.Error()})
 	}
 	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "deleted successfully"})
 }
@@ -147,47 +147,8 @@ func (h *HTTPGateway) UpdateProfileImage(ctx *fiber.Ctx) error {
 	})
 }
 
-func (h *HTTPGateway) UpdateUserRoleUser(ctx *fiber.Ctx) error {
-	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
-	if err != nil {
-		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
-	}
-	var req struct {
-		Role string `json:"role"`
-	}
-	if err := ctx.BodyParser(&req); err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid json body"})
-	}
-	if req.Role == "" {
-		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "role is required"})
-	}
-	if req.Role != "user" && req.Role != "moderator" {
-		return ctx.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "invalid role"})
-	}
-	err = h.UserService.UpdateUserRole(tokenDetails.UserID, entities.UserRole(req.Role))
-	if err != nil {
-		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "cannot update user role"})
-	}
-	return ctx.Status(fiber.StatusOK).JSON(entities.ResponseModel{Message: "updated successfully"})
-}
-
+// UpdateUserRole lets an admin change another user's role; the route requires users:assign_role
 func (h *HTTPGateway) UpdateUserRole(ctx *fiber.Ctx) error {
-	tokenDetails, err := middlewares.DecodeJWTToken(ctx)
-	if err != nil {
-		return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
-	}
-
-	// First, get the current user to check their role
-	currentUser, err := h.UserService.GetUser(tokenDetails.UserID)
-	if err != nil {
-		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "cannot get current user data"})
-	}
-
-	// Only allow admin users to update roles
-	if currentUser.Role != string(entities.UserRoleAdmin) {
-		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "access denied - admin role required"})
-	}
-
 	// Get the target user ID and new role from the request
 	var req struct {
 		UserID string `json:"user_id"`
@@ -214,7 +175,7 @@ func (h *HTTPGateway) UpdateUserRole(ctx *fiber.Ctx) error {
 
 	// Update the target user's role
 	userRole := entities.UserRole(req.Role)
-	err = h.UserService.UpdateUserRole(req.UserID, userRole)
+	err := h.UserService.UpdateUserRole(requestMeta(ctx), req.UserID, userRole)
 	if err != nil {
 		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "cannot update user role"})
 	}
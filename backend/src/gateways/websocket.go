@@ -1,16 +1,27 @@
 package gateways
 
 import (
+	"errors"
 	"log"
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/middlewares"
+	"recycle-waste-management-backend/src/services"
 	ws "recycle-waste-management-backend/src/websocket"
 
 	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 )
 
-func (h *HTTPGateway) HandleWebSocketChat(c *websocket.Conn) {
-	// Get query parameters
-	userID := c.Query("user_id")
+// Locals handed from AuthorizeChatRoom to the WebSocket handler
+const (
+	chatUserIDLocal   = "chat_user_id"
+	chatUserTypeLocal = "chat_user_type"
+)
+
+func (g *ChatGateway) HandleWebSocketChat(c *websocket.Conn) {
+	// Identity comes from the verified token, never from the query
+	userID, _ := c.Locals(chatUserIDLocal).(string)
+	userType, _ := c.Locals(chatUserTypeLocal).(string)
 	customerRequestID := c.Query("request_id")
 
 	if userID == "" || customerRequestID == "" {
@@ -25,7 +36,7 @@ func (h *HTTPGateway) HandleWebSocketChat(c *websocket.Conn) {
 	client := &ws.Client{
 		Conn:              c,
 		UserID:            userID,
-		UserType:          "user", // Default type since we removed it from query
+		UserType:          userType,
 		CustomerRequestID: customerRequestID,
 		Send:              make(chan []byte, 256),
 	}
@@ -50,7 +61,7 @@ func (h *HTTPGateway) HandleWebSocketChat(c *websocket.Conn) {
 	log.Printf("[Chat] Connection closed: UserID=%s, Room=%s", userID, customerRequestID)
 }
 
-func (h *HTTPGateway) WebSocketChatUpgrade(c *fiber.Ctx) error {
+func (g *ChatGateway) WebSocketChatUpgrade(c *fiber.Ctx) error {
 	// Check if connection is WebSocket upgrade
 	if websocket.IsWebSocketUpgrade(c) {
 		return c.Next()
@@ -60,3 +71,27 @@ func (h *HTTPGateway) WebSocketChatUpgrade(c *fiber.Ctx) error {
 		"message": "WebSocket upgrade required",
 	})
 }
+
+// AuthorizeChatRoom runs after the JWT check and admits only members of the room
+func (g *ChatGateway) AuthorizeChatRoom(c *fiber.Ctx) error {
+	actor, err := middlewares.CurrentActor(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+	}
+	customerRequestID := c.Query("request_id")
+	if customerRequestID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(entities.ResponseMessage{Message: "request_id is required"})
+	}
+
+	userType, err := g.ChatService.JoinChatRoom(actor, customerRequestID)
+	if err != nil {
+		if errors.Is(err, services.ErrNotChatMember) {
+			return c.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: err.Error()})
+		}
+		return c.Status(fiber.StatusNotFound).JSON(entities.ResponseMessage{Message: err.Error()})
+	}
+
+	c.Locals(chatUserIDLocal, actor.ID)
+	c.Locals(chatUserTypeLocal, userType)
+	return c.Next()
+}
@@ -13,6 +13,7 @@ import (
 )
 
 type authGithub struct {
+	urlAuthorize string
 	url          string
 	urlClient    string
 	http         *http.Client
@@ -21,22 +22,80 @@ type authGithub struct {
 	RedirectURI  string
 }
 
-type IAuthGithub interface {
-	GitHubAccessToken(code string) (entities.TokenResponseGithub, error)
-	GetUserGithub(token string) (entities.UserGithub, error)
-}
+// NewAuthGithub creates the GitHub provider. GITHUB_OAUTH_BASE_URL and
+// GITHUB_API_BASE_URL override github.com and api.github.com.
+func NewAuthGithub() IOAuthProvider {
+	oauthBase := os.Getenv("GITHUB_OAUTH_BASE_URL")
+	if oauthBase == "" {
+		oauthBase = "https://github.com"
+	}
+	apiBase := os.Getenv("GITHUB_API_BASE_URL")
+	if apiBase == "" {
+		apiBase = "https://api.github.com"
+	}
 
-func NewAuthGithub() IAuthGithub {
 	return &authGithub{
-		url:          "https://github.com/login/oauth/access_token",
-		urlClient:    "https://api.github.com/user",
-		http:         &http.Client{},
+		urlAuthorize: endpoint(oauthBase, "/login/oauth/authorize"),
+		url:          endpoint(oauthBase, "/login/oauth/access_token"),
+		urlClient:    endpoint(apiBase, "/user"),
+		http:         newOAuthHTTPClient(),
 		ClientID:     os.Getenv("GITHUB_CLIENT_ID"),
 		ClientSecret: os.Getenv("GITHUB_CLIENT_SECRET"),
 		RedirectURI:  os.Getenv("GITHUB_REDIRECT_URI"),
 	}
 }
 
+func (a *authGithub) Name() string {
+	return entities.OAuthProviderGithub
+}
+
+func (a *authGithub) AuthorizeURL(state string, nonce string) string {
+	query := url.Values{}
+	query.Set("client_id", a.ClientID)
+	query.Set("redirect_uri", a.RedirectURI)
+	query.Set("scope", "read:user user:email")
+	query.Set("state", state)
+	return a.urlAuthorize + "?" + query.Encode()
+}
+
+// RequiresState is true: logins must start at /api/auth/github/login, so a
+// callback carrying someone else's code is rejected (login CSRF)
+func (a *authGithub) RequiresState() bool {
+	return true
+}
+
+func (a *authGithub) ExchangeCode(code string) (*entities.OAuthToken, error) {
+	data, err := a.GitHubAccessToken(code)
+	if err != nil {
+		return nil, err
+	}
+	if data.AccessToken == "" {
+		return nil, fmt.Errorf("failed to get access token: %s", data.ErrorDesc)
+	}
+	return &entities.OAuthToken{
+		AccessToken: data.AccessToken,
+		TokenType:   data.TokenType,
+		Scope:       data.Scope,
+	}, nil
+}
+
+func (a *authGithub) GetIdentity(token *entities.OAuthToken, nonce string) (*entities.OAuthIdentity, error) {
+	user, err := a.GetUserGithub(token.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	if user.UserID == "" {
+		return nil, fmt.Errorf("github user has no id")
+	}
+	return &entities.OAuthIdentity{
+		Provider:       entities.OAuthProviderGithub,
+		ProviderUserID: user.UserID,
+		Username:       user.Username,
+		Email:          user.Email,
+		ImageURL:       user.ImageURL,
+	}, nil
+}
+
 func (a *authGithub) GitHubAccessToken(code string) (entities.TokenResponseGithub, error) {
 	data := url.Values{}
 	data.Set("client_id", a.ClientID)
@@ -103,7 +162,7 @@ func (a *authGithub) GetUserGithub(token string) (entities.UserGithub, error) {
 
 	req.Header.Add("Authorization", fmt.Sprintf("Bearer %v", token))
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := a.http.Do(req)
 	if err != nil {
 		return entities.UserGithub{}, err
 	}
@@ -0,0 +1,171 @@
+package httpclients
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"recycle-waste-management-backend/src/domain/entities"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const lineIssuer = "https://access.line.me"
+
+type authLine struct {
+	urlAuthorize  string
+	urlToken      string
+	urlProfile    string
+	http          *http.Client
+	ChannelID     string
+	ChannelSecret string
+	RedirectURI   string
+}
+
+// LineConfig configures the LINE Login provider. Empty URLs fall back to LINE's production endpoints.
+type LineConfig struct {
+	ChannelID     string
+	ChannelSecret string
+	RedirectURI   string
+	AuthBaseURL   string // https://access.line.me
+	APIBaseURL    string // https://api.line.me
+	HTTPClient    *http.Client
+}
+
+// NewAuthLineFromEnv reads LINE_CHANNEL_ID, LINE_CHANNEL_SECRET and
+// LINE_REDIRECT_URI, plus the optional LINE_AUTH_BASE_URL and LINE_API_BASE_URL overrides
+func NewAuthLineFromEnv() IOAuthProvider {
+	return NewAuthLine(LineConfig{
+		ChannelID:     os.Getenv("LINE_CHANNEL_ID"),
+		ChannelSecret: os.Getenv("LINE_CHANNEL_SECRET"),
+		RedirectURI:   os.Getenv("LINE_REDIRECT_URI"),
+		AuthBaseURL:   os.Getenv("LINE_AUTH_BASE_URL"),
+		APIBaseURL:    os.Getenv("LINE_API_BASE_URL"),
+	})
+}
+
+func NewAuthLine(config LineConfig) IOAuthProvider {
+	if config.AuthBaseURL == "" {
+		config.AuthBaseURL = "https://access.line.me"
+	}
+	if config.APIBaseURL == "" {
+		config.APIBaseURL = "https://api.line.me"
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = newOAuthHTTPClient()
+	}
+
+	return &authLine{
+		urlAuthorize:  endpoint(config.AuthBaseURL, "/oauth2/v2.1/authorize"),
+		urlToken:      endpoint(config.APIBaseURL, "/oauth2/v2.1/token"),
+		urlProfile:    endpoint(config.APIBaseURL, "/v2/profile"),
+		http:          config.HTTPClient,
+		ChannelID:     config.ChannelID,
+		ChannelSecret: config.ChannelSecret,
+		RedirectURI:   config.RedirectURI,
+	}
+}
+
+func (a *authLine) Name() string {
+	return entities.OAuthProviderLine
+}
+
+func (a *authLine) AuthorizeURL(state string, nonce string) string {
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", a.ChannelID)
+	query.Set("redirect_uri", a.RedirectURI)
+	query.Set("scope", "profile openid email")
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	return a.urlAuthorize + "?" + query.Encode()
+}
+
+// RequiresState is true: the nonce in the ID token is checked against the one stored with the state
+func (a *authLine) RequiresState() bool {
+	return true
+}
+
+func (a *authLine) ExchangeCode(code string) (*entities.OAuthToken, error) {
+	data := url.Values{}
+	data.Set("grant_type", "authorization_code")
+	data.Set("code", code)
+	data.Set("redirect_uri", a.RedirectURI)
+	data.Set("client_id", a.ChannelID)
+	data.Set("client_secret", a.ChannelSecret)
+
+	req, err := http.NewRequest(http.MethodPost, a.urlToken, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token entities.OAuthToken
+	if err := doJSON(a.http, req, &token); err != nil {
+		return nil, fmt.Errorf("failed to get line access token: %v", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("failed to get line access token: empty token")
+	}
+	return &token, nil
+}
+
+func (a *authLine) GetIdentity(token *entities.OAuthToken, nonce string) (*entities.OAuthIdentity, error) {
+	claims, err := a.verifyIDToken(token.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, a.urlProfile, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+	var profile entities.UserLine
+	if err := doJSON(a.http, req, &profile); err != nil {
+		return nil, fmt.Errorf("failed to get line profile: %v", err)
+	}
+
+	// The profile and the ID token must describe the same account
+	subject, _ := claims.GetSubject()
+	if profile.UserID == "" || profile.UserID != subject {
+		return nil, fmt.Errorf("line profile does not match id token")
+	}
+
+	email, _ := claims["email"].(string)
+	return &entities.OAuthIdentity{
+		Provider:       entities.OAuthProviderLine,
+		ProviderUserID: profile.UserID,
+		Username:       profile.DisplayName,
+		Email:          email,
+		ImageURL:       profile.PictureURL,
+	}, nil
+}
+
+// verifyIDToken checks a LINE Login ID token, which is an HS256 JWT signed
+// with the channel secret, and returns its claims
+func (a *authLine) verifyIDToken(idToken string, nonce string) (jwt.MapClaims, error) {
+	if idToken == "" {
+		return nil, fmt.Errorf("line id token is missing")
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(a.ChannelSecret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(lineIssuer),
+		jwt.WithAudience(a.ChannelID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid line id token: %v", err)
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); nonce == "" || tokenNonce != nonce {
+		return nil, fmt.Errorf("invalid line id token: nonce mismatch")
+	}
+	return claims, nil
+}
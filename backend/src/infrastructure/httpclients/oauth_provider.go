@@ -0,0 +1,57 @@
+package httpclients
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"recycle-waste-management-backend/src/domain/entities"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// IOAuthProvider is an identity provider that logs users in with the OAuth 2.0
+// authorization code flow
+type IOAuthProvider interface {
+	Name() string
+	// AuthorizeURL is where the browser is sent to log in. state and nonce are echoed back.
+	AuthorizeURL(state string, nonce string) string
+	// RequiresState reports whether the callback must carry a state issued by AuthorizeURL
+	RequiresState() bool
+	ExchangeCode(code string) (*entities.OAuthToken, error)
+	// GetIdentity resolves the account behind a token. nonce is the value passed
+	// to AuthorizeURL, checked by providers that issue ID tokens.
+	GetIdentity(token *entities.OAuthToken, nonce string) (*entities.OAuthIdentity, error)
+}
+
+func newOAuthHTTPClient() *http.Client {
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+// endpoint joins a configurable base URL, e.g. a local fake server, with an API path
+func endpoint(baseURL string, path string) string {
+	return strings.TrimRight(baseURL, "/") + path
+}
+
+// doJSON sends the request and decodes a JSON response into out
+func doJSON(client *http.Client, req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
@@ -44,6 +44,15 @@ func NewAwsS3() IAwsS3Upload {
 	}
 }
 
+// S3BucketHost is the host of the public URLs returned by UploadS3FromString
+func S3BucketHost() string {
+	return bucketHost(os.Getenv("AWS_BUCKET_NAME"), os.Getenv("AWS_REGION"))
+}
+
+func bucketHost(bucket, region string) string {
+	return fmt.Sprintf("%s.s3.%s.amazonaws.com", bucket, region)
+}
+
 func (s3 *AwsS3Upload) HashString(id string) string {
 	byteID := []byte(id)
 	hashObject := sha256.Sum256(byteID)
@@ -71,7 +80,7 @@ func (s *AwsS3Upload) UploadS3FromString(fileName []byte, keyName string, conten
 		return "", err
 	}
 
-	fullURL := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, keyName)
+	fullURL := fmt.Sprintf("https://%s/%s", bucketHost(s.bucket, s.region), keyName)
 
 	return fullURL, nil
 }
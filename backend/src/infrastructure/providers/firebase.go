@@ -19,6 +19,8 @@ type FirebaseProvider struct {
 
 type IFirebaseProvider interface {
 	GetUserFirebaseAuth(userID string) (auth.UserRecord, error)
+	// VerifyIDToken checks a Firebase ID token's signature and expiry and returns the UID it was issued to
+	VerifyIDToken(idToken string) (string, error)
 }
 
 func NewFirebaseProvider() IFirebaseProvider {
@@ -58,3 +60,11 @@ func (f *FirebaseProvider) GetUserFirebaseAuth(userID string) (auth.UserRecord,
 	}
 	return *data, nil
 }
+
+func (f *FirebaseProvider) VerifyIDToken(idToken string) (string, error) {
+	token, err := f.Auth.VerifyIDToken(f.Context, idToken)
+	if err != nil {
+		return "", fmt.Errorf("invalid firebase id token: %v", err)
+	}
+	return token.UID, nil
+}
@@ -24,8 +24,12 @@ type S3Provider struct {
 
 type IS3Provider interface {
 	UploadImage(imageData []byte, filename string, contentType string, userID string) (string, error)
+	UploadImageToFolder(imageData []byte, filename string, contentType string, folder string, ownerID string) (string, error)
 	DeleteImage(imageURL string) error
 	GetImageURL(key string) string
+	UploadPrivateFile(data []byte, filename string, contentType string, folder string, ownerID string) (string, error)
+	GetPresignedURL(key string, expiry time.Duration) (string, error)
+	DeleteObject(key string) error
 }
 
 func NewS3Provider() IS3Provider {
@@ -65,8 +69,12 @@ func NewS3Provider() IS3Provider {
 }
 
 func (s *S3Provider) UploadImage(imageData []byte, filename string, contentType string, userID string) (string, error) {
+	return s.UploadImageToFolder(imageData, filename, contentType, "profile-images", userID)
+}
+
+func (s *S3Provider) UploadImageToFolder(imageData []byte, filename string, contentType string, folder string, ownerID string) (string, error) {
 	// Generate unique key for the image
-	key := s.generateImageKey(filename, userID)
+	key := s.generateImageKey(folder, filename, ownerID)
 
 	// Upload to S3
 	_, err := s.service.PutObject(&s3.PutObjectInput{
@@ -88,6 +96,51 @@ func (s *S3Provider) UploadImage(imageData []byte, filename string, contentType
 	return url, nil
 }
 
+// UploadPrivateFile stores a file that is not publicly readable and returns its key.
+// Use GetPresignedURL to hand out temporary access.
+func (s *S3Provider) UploadPrivateFile(data []byte, filename string, contentType string, folder string, ownerID string) (string, error) {
+	key := s.generateImageKey(folder, filename, ownerID)
+
+	_, err := s.service.PutObject(&s3.PutObjectInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(key),
+		Body:                 bytes.NewReader(data),
+		ContentType:          aws.String(contentType),
+		ContentLength:        aws.Int64(int64(len(data))),
+		ACL:                  aws.String("private"),
+		ServerSideEncryption: aws.String("AES256"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload file to S3: %v", err)
+	}
+
+	return key, nil
+}
+
+// GetPresignedURL returns a URL that grants read access to a private object until it expires
+func (s *S3Provider) GetPresignedURL(key string, expiry time.Duration) (string, error) {
+	req, _ := s.service.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	url, err := req.Presign(expiry)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign S3 URL: %v", err)
+	}
+	return url, nil
+}
+
+func (s *S3Provider) DeleteObject(key string) error {
+	_, err := s.service.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete object from S3: %v", err)
+	}
+	return nil
+}
+
 func (s *S3Provider) DeleteImage(imageURL string) error {
 	// Extract key from URL
 	key := s.extractKeyFromURL(imageURL)
@@ -113,7 +166,7 @@ func (s *S3Provider) GetImageURL(key string) string {
 	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, key)
 }
 
-func (s *S3Provider) generateImageKey(filename string, userID string) string {
+func (s *S3Provider) generateImageKey(folder string, filename string, ownerID string) string {
 	// Get file extension
 	ext := filepath.Ext(filename)
 
@@ -123,8 +176,8 @@ func (s *S3Provider) generateImageKey(filename string, userID string) string {
 	// Create timestamp for organization
 	timestamp := time.Now().Format("2006/01/02")
 
-	// Generate key: profile-images/YYYY/MM/DD/userID/uuid.ext
-	key := fmt.Sprintf("profile-images/%s/%s/%s%s", timestamp, userID, uniqueID, ext)
+	// Generate key: folder/YYYY/MM/DD/ownerID/uuid.ext
+	key := fmt.Sprintf("%s/%s/%s/%s%s", folder, timestamp, ownerID, uniqueID, ext)
 
 	return key
 }
@@ -0,0 +1,71 @@
+package providers
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// ISMSSender delivers text messages to a phone number in E.164 format
+type ISMSSender interface {
+	Send(phone string, message string) error
+}
+
+// NewSMSSenderFromEnv picks the sender from SMS_PROVIDER. "console" logs
+// messages, "file" appends them to SMS_OUTBOX_FILE; both are meant for local
+// development only, so one of them must be chosen explicitly and the server
+// refuses to start when SMS_PROVIDER is unset.
+func NewSMSSenderFromEnv() ISMSSender {
+	switch os.Getenv("SMS_PROVIDER") {
+	case "file":
+		path := os.Getenv("SMS_OUTBOX_FILE")
+		if path == "" {
+			path = "sms_outbox.log"
+		}
+		return NewFileSMSSender(path)
+	case "console":
+		return NewConsoleSMSSender()
+	case "":
+		panic("SMS_PROVIDER is not set; use \"console\" or \"file\" for local development")
+	default:
+		panic(fmt.Sprintf("unsupported SMS_PROVIDER: %s", os.Getenv("SMS_PROVIDER")))
+	}
+}
+
+type consoleSMSSender struct{}
+
+func NewConsoleSMSSender() ISMSSender {
+	return &consoleSMSSender{}
+}
+
+func (s *consoleSMSSender) Send(phone string, message string) error {
+	log.Printf("[SMS] to %s: %s", phone, message)
+	return nil
+}
+
+type fileSMSSender struct {
+	path  string
+	mutex sync.Mutex
+}
+
+func NewFileSMSSender(path string) ISMSSender {
+	return &fileSMSSender{path: path}
+}
+
+func (s *fileSMSSender) Send(phone string, message string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening SMS outbox: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(file, "%s\t%s\t%s\n", time.Now().Format(time.RFC3339), phone, message); err != nil {
+		return fmt.Errorf("error writing SMS outbox: %v", err)
+	}
+	return nil
+}
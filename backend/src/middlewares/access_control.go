@@ -0,0 +1,155 @@
+package middlewares
+
+import (
+	"fmt"
+	"recycle-waste-management-backend/src/domain/entities"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// IUserLookup loads a user to resolve their role
+type IUserLookup interface {
+	GetUser(userID string) (*entities.UserDataFormat, error)
+}
+
+// IShopLookup loads a shop to check who owns it
+type IShopLookup interface {
+	GetByShopID(shopID string) (*entities.ShopModel, error)
+}
+
+var (
+	accessUsers IUserLookup
+	accessShops IShopLookup
+)
+
+// InitAccessControl wires the lookups RequirePermission and the ownership checks depend on
+func InitAccessControl(users IUserLookup, shops IShopLookup) {
+	accessUsers = users
+	accessShops = shops
+}
+
+const actorLocalsKey = "actor"
+
+// RequirePermission allows the request only if the caller holds every listed permission.
+// It must run after SetJWtHeaderHandler.
+func RequirePermission(permissions ...string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		actor, err := loadActor(ctx)
+		if err != nil {
+			return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+		}
+		for _, p := range permissions {
+			if !actor.HasPermission(p) {
+				return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "access denied - missing permission " + p})
+			}
+		}
+		return ctx.Next()
+	}
+}
+
+// RequireAnyPermission allows the request if the caller holds at least one of the permissions
+func RequireAnyPermission(permissions ...string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		actor, err := loadActor(ctx)
+		if err != nil {
+			return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "unauthorized"})
+		}
+		for _, p := range permissions {
+			if actor.HasPermission(p) {
+				return ctx.Next()
+			}
+		}
+		return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: "access denied"})
+	}
+}
+
+// RequireShopAccess checks that the caller may act for the shop in the given route param
+func RequireShopAccess(param string) fiber.Handler {
+	return RequireOwnership(func(ctx *fiber.Ctx) (string, error) {
+		return ctx.Params(param), nil
+	})
+}
+
+// RequireOwnership checks access to a shop-scoped resource. resolveShop returns
+// the shop that owns the resource addressed by the request.
+func RequireOwnership(resolveShop func(ctx *fiber.Ctx) (string, error)) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		shopID, err := resolveShop(ctx)
+		if err != nil {
+			return ctx.Status(fiber.StatusNotFound).JSON(entities.ResponseMessage{Message: err.Error()})
+		}
+		if err := CheckShopAccess(ctx, shopID); err != nil {
+			return ctx.Status(fiber.StatusForbidden).JSON(entities.ResponseMessage{Message: err.Error()})
+		}
+		return ctx.Next()
+	}
+}
+
+// CheckShopAccess reports whether the caller may act for shopID: employees for
+// their own shop, users for the shops they own, and holders of shops:manage_any for any shop.
+func CheckShopAccess(ctx *fiber.Ctx, shopID string) error {
+	actor, err := loadActor(ctx)
+	if err != nil {
+		return fmt.Errorf("unauthorized")
+	}
+	if actor.HasPermission(entities.PermissionShopManageAny) {
+		return nil
+	}
+	if shopID == "" {
+		return fmt.Errorf("access denied - resource does not belong to a shop")
+	}
+
+	if actor.IsShopBound() {
+		if actor.ShopID == shopID {
+			return nil
+		}
+		return fmt.Errorf("access denied - you don't work for this shop")
+	}
+
+	if accessShops != nil {
+		if shop, err := accessShops.GetByShopID(shopID); err == nil && shop.UserID == actor.ID {
+			return nil
+		}
+	}
+	return fmt.Errorf("access denied - you don't own this shop")
+}
+
+// CurrentActor returns the caller with their effective permissions
+func CurrentActor(ctx *fiber.Ctx) (entities.Actor, error) {
+	return loadActor(ctx)
+}
+
+// HasPermission reports whether the caller holds the permission
+func HasPermission(ctx *fiber.Ctx, permission string) bool {
+	actor, err := loadActor(ctx)
+	return err == nil && actor.HasPermission(permission)
+}
+
+// loadActor resolves the caller once per request. Employee permissions come
+// from the token; user permissions from their current role, and their active
+// shop from the X-Shop-ID header.
+func loadActor(ctx *fiber.Ctx) (entities.Actor, error) {
+	if actor, ok := ctx.Locals(actorLocalsKey).(entities.Actor); ok {
+		return actor, nil
+	}
+
+	tokenDetails, err := DecodeJWTToken(ctx)
+	if err != nil || tokenDetails == nil {
+		return entities.Actor{}, fmt.Errorf("unauthorized")
+	}
+	actor := tokenDetails.Actor()
+
+	if !actor.IsEmployee() {
+		role := string(entities.UserRoleUser)
+		if accessUsers != nil {
+			if user, err := accessUsers.GetUser(actor.ID); err == nil {
+				role = user.Role
+			}
+		}
+		actor.Permissions = entities.PermissionsForRole(role)
+		actor.ActiveShopID = ctx.Get(entities.ActiveShopHeader)
+	}
+
+	ctx.Locals(actorLocalsKey, actor)
+	return actor, nil
+}
@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"recycle-waste-management-backend/src/domain/entities"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// IAPIKeyAuthenticator resolves the shop-scoped actor behind an API key
+type IAPIKeyAuthenticator interface {
+	Authenticate(key string) (entities.Actor, error)
+}
+
+var apiKeyAuthenticator IAPIKeyAuthenticator
+
+// SetAPIKeyAuthenticator enables "Authorization: ApiKey <key>" in SetJWTOrAPIKeyHandler
+func SetAPIKeyAuthenticator(authenticator IAPIKeyAuthenticator) {
+	apiKeyAuthenticator = authenticator
+}
+
+const apiKeyScheme = "apikey "
+
+// SetJWTOrAPIKeyHandler accepts either a bearer JWT or a shop API key. It is
+// used on the routes integrations may call; the permission checks after it
+// limit a key to its scopes and its shop.
+func SetJWTOrAPIKeyHandler() fiber.Handler {
+	jwtHandler := SetJWtHeaderHandler()
+
+	return func(ctx *fiber.Ctx) error {
+		header := ctx.Get(fiber.HeaderAuthorization)
+		if len(header) < len(apiKeyScheme) || !strings.EqualFold(header[:len(apiKeyScheme)], apiKeyScheme) {
+			return jwtHandler(ctx)
+		}
+		if apiKeyAuthenticator == nil {
+			return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "api keys are not enabled"})
+		}
+
+		actor, err := apiKeyAuthenticator.Authenticate(strings.TrimSpace(header[len(apiKeyScheme):]))
+		if err != nil {
+			return ctx.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "Invalid API key."})
+		}
+
+		ctx.Locals(actorLocalsKey, actor)
+		return ctx.Next()
+	}
+}
@@ -11,27 +11,74 @@ import (
 	jwtware "github.com/gofiber/contrib/jwt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
+// ITokenRevocationList answers whether an access token (by jti) was revoked before it expired
+type ITokenRevocationList interface {
+	IsRevoked(jti string) (bool, error)
+}
+
+var revocationList ITokenRevocationList
+
+// SetTokenRevocationList enables the revocation check in the JWT middlewares
+func SetTokenRevocationList(list ITokenRevocationList) {
+	revocationList = list
+}
+
 func SetJWtHeaderHandler() fiber.Handler {
 	return jwtware.New(jwtware.Config{
 		SigningKey: jwtware.SigningKey{
 			Key:    []byte(os.Getenv("JWT_SECRET_KEY")),
 			JWTAlg: jwtware.HS256,
 		},
+		SuccessHandler: checkTokenRevoked,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			return c.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "Unauthorization Token."})
 		},
 	})
 }
 
+// checkTokenRevoked rejects a valid signature whose jti is on the revocation list.
+// Tokens issued before jti was introduced carry none and simply expire.
+func checkTokenRevoked(c *fiber.Ctx) error {
+	if revocationList == nil {
+		return c.Next()
+	}
+
+	token, ok := c.Locals("user").(*jwt.Token)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "Unauthorization Token."})
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "Unauthorization Token."})
+	}
+
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return c.Next()
+	}
+
+	revoked, err := revocationList.IsRevoked(jti)
+	if err != nil {
+		log.Println("Error checking token revocation: ", err)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(entities.ResponseMessage{Message: "cannot verify token"})
+	}
+	if revoked {
+		return c.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "Token has been revoked."})
+	}
+	return c.Next()
+}
+
 func SetWebSocketJWTMiddleware() fiber.Handler {
 	return jwtware.New(jwtware.Config{
 		SigningKey: jwtware.SigningKey{
 			Key:    []byte(os.Getenv("JWT_SECRET_KEY")),
 			JWTAlg: jwtware.HS256,
 		},
-		TokenLookup: "query:token",
+		TokenLookup:    "query:token",
+		SuccessHandler: checkTokenRevoked,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			return c.Status(fiber.StatusUnauthorized).JSON(entities.ResponseMessage{Message: "Unauthorization Token."})
 		},
@@ -39,16 +86,33 @@ func SetWebSocketJWTMiddleware() fiber.Handler {
 }
 
 type TokenDetails struct {
-	Token     *string `json:"token"`
-	UserID    string  `json:"user_id"`
-	UID       string  `json:"uid"`
-	ExpiresIn *int64  `json:"exp"`
+	Token       *string  `json:"token"`
+	UserID      string   `json:"user_id"` // user_id, or employee_id for employee tokens
+	UID         string   `json:"uid"`
+	JTI         string   `json:"jti"`
+	SessionID   string   `json:"sid"`
+	Type        string   `json:"typ"` // entities.ActorTypeUser or entities.ActorTypeEmployee
+	ShopID      string   `json:"shop_id"`
+	Permissions []string `json:"permissions"`
+	ExpiresIn   *int64   `json:"exp"`
+}
+
+// Actor returns the acting party the token was issued for
+func (td *TokenDetails) Actor() entities.Actor {
+	return entities.Actor{
+		ID:          td.UserID,
+		Type:        td.Type,
+		ShopID:      td.ShopID,
+		Permissions: td.Permissions,
+	}
 }
 
 func DecodeJWTToken(ctx *fiber.Ctx) (*TokenDetails, error) {
 
 	td := &TokenDetails{
-		Token: new(string),
+		Token:     new(string),
+		Type:      entities.ActorTypeUser, // Tokens issued before typ existed were all user tokens
+		ExpiresIn: new(int64),
 	}
 
 	token, status := ctx.Locals("user").(*jwt.Token)
@@ -63,33 +127,87 @@ func DecodeJWTToken(ctx *fiber.Ctx) (*TokenDetails, error) {
 
 	for key, value := range claims {
 		if key == "user_id" || key == "sub" {
-			td.UserID = value.(string)
+			td.UserID, _ = value.(string)
 		}
 		if key == "uid" {
-			td.UID = value.(string)
+			td.UID, _ = value.(string)
+		}
+		if key == "jti" {
+			td.JTI, _ = value.(string)
+		}
+		if key == "sid" {
+			td.SessionID, _ = value.(string)
+		}
+		if key == "typ" {
+			if typ, ok := value.(string); ok && typ != "" {
+				td.Type = typ
+			}
+		}
+		if key == "shop_id" {
+			td.ShopID, _ = value.(string)
+		}
+		if key == "permissions" {
+			if list, ok := value.([]interface{}); ok {
+				for _, p := range list {
+					if permission, ok := p.(string); ok {
+						td.Permissions = append(td.Permissions, permission)
+					}
+				}
+			}
+		}
+		if key == "exp" {
+			if exp, ok := value.(float64); ok {
+				*td.ExpiresIn = int64(exp)
+			}
 		}
 	}
 	*td.Token = token.Raw
 	return td, nil
 }
 
-func GenerateJWTToken(userID string) (*TokenDetails, error) {
+// AccessTokenTTL is the lifetime of access tokens, JWT_ACCESS_TOKEN_TTL (default 1h)
+func AccessTokenTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("JWT_ACCESS_TOKEN_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return time.Hour
+}
+
+// GenerateJWTToken issues an access token for a session. Every token gets a
+// unique jti so it can be revoked individually. Employee tokens also carry
+// the shop they work for and their permissions.
+func GenerateJWTToken(actor entities.Actor, sessionID string) (*TokenDetails, error) {
 	now := time.Now().UTC()
 
 	td := &TokenDetails{
 		ExpiresIn: new(int64),
 		Token:     new(string),
 	}
-	*td.ExpiresIn = now.Add(time.Hour * 6).Unix()
-	td.UserID = userID
+	*td.ExpiresIn = now.Add(AccessTokenTTL()).Unix()
+	td.UserID = actor.ID
+	td.JTI = uuid.New().String()
+	td.SessionID = sessionID
+	td.Type = actor.Type
+	if td.Type == "" {
+		td.Type = entities.ActorTypeUser
+	}
 
 	SigningKey := []byte(os.Getenv("JWT_SECRET_KEY"))
 
 	atClaims := make(jwt.MapClaims)
-	atClaims["user_id"] = userID
-	atClaims["exp"] = time.Now().Add(time.Hour * 6).Unix()
-	atClaims["iat"] = time.Now().Unix()
-	atClaims["nbf"] = time.Now().Unix()
+	atClaims["user_id"] = actor.ID
+	atClaims["typ"] = td.Type
+	atClaims["jti"] = td.JTI
+	atClaims["sid"] = sessionID
+	if actor.IsEmployee() {
+		td.ShopID = actor.ShopID
+		td.Permissions = actor.Permissions
+		atClaims["shop_id"] = actor.ShopID
+		atClaims["permissions"] = actor.Permissions
+	}
+	atClaims["exp"] = *td.ExpiresIn
+	atClaims["iat"] = now.Unix()
+	atClaims["nbf"] = now.Unix()
 
 	log.Println("New claims: ", atClaims)
 
@@ -0,0 +1,144 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	ds "recycle-waste-management-backend/src/domain/datasources"
+	"recycle-waste-management-backend/src/domain/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IAPIKeyRepository interface {
+	Create(apiKey *models.APIKey) error
+	GetByHash(keyHash string) (*models.APIKey, error)
+	GetByShopID(shopID string) (*[]models.APIKey, error)
+	Revoke(shopID string, keyID string) error
+	RevokeAllByShopID(shopID string) error
+	DeleteByShopID(shopID string) error
+	// TouchLastUsed records a use, at most once per interval to spare writes
+	TouchLastUsed(keyID string, interval time.Duration) error
+}
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+type apiKeyRepository struct {
+	Collection *mongo.Collection
+	Context    context.Context
+}
+
+func NewAPIKeyRepository(db *ds.MongoDB) IAPIKeyRepository {
+	repo := &apiKeyRepository{
+		Collection: db.MongoDB.Database(os.Getenv("DATABASE_NAME")).Collection("api_keys"),
+		Context:    db.Context,
+	}
+
+	repo.ensureIndexes()
+
+	return repo
+}
+
+func (repo *apiKeyRepository) ensureIndexes() {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "key_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "shop_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+	}
+
+	if _, err := repo.Collection.Indexes().CreateMany(repo.Context, indexModels); err != nil {
+		fmt.Printf("Warning: Could not create api_keys indexes: %v\n", err)
+	}
+}
+
+func (repo *apiKeyRepository) Create(apiKey *models.APIKey) error {
+	if _, err := repo.Collection.InsertOne(repo.Context, apiKey); err != nil {
+		return fmt.Errorf("error inserting api key: %v", err)
+	}
+	return nil
+}
+
+func (repo *apiKeyRepository) GetByHash(keyHash string) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	err := repo.Collection.FindOne(repo.Context, bson.M{"key_hash": keyHash}).Decode(&apiKey)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting api key: %v", err)
+	}
+	return &apiKey, nil
+}
+
+func (repo *apiKeyRepository) GetByShopID(shopID string) (*[]models.APIKey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := repo.Collection.Find(repo.Context, bson.M{"shop_id": shopID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding api keys: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	apiKeys := []models.APIKey{}
+	if err := cursor.All(repo.Context, &apiKeys); err != nil {
+		return nil, fmt.Errorf("error decoding api keys: %v", err)
+	}
+	return &apiKeys, nil
+}
+
+func (repo *apiKeyRepository) Revoke(shopID string, keyID string) error {
+	filter := bson.M{"key_id": keyID, "shop_id": shopID, "revoked_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"revoked_at": time.Now()}}
+
+	result, err := repo.Collection.UpdateOne(repo.Context, filter, update)
+	if err != nil {
+		return fmt.Errorf("error revoking api key: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (repo *apiKeyRepository) TouchLastUsed(keyID string, interval time.Duration) error {
+	now := time.Now()
+	filter := bson.M{
+		"key_id": keyID,
+		"$or": bson.A{
+			bson.M{"last_used_at": bson.M{"$exists": false}},
+			bson.M{"last_used_at": bson.M{"$lt": now.Add(-interval)}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"last_used_at": now}}
+	if _, err := repo.Collection.UpdateOne(repo.Context, filter, update); err != nil {
+		return fmt.Errorf("error updating api key: %v", err)
+	}
+	return nil
+}
+
+func (repo *apiKeyRepository) RevokeAllByShopID(shopID string) error {
+	filter := bson.M{"shop_id": shopID, "revoked_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"revoked_at": time.Now()}}
+	if _, err := repo.Collection.UpdateMany(repo.Context, filter, update); err != nil {
+		return fmt.Errorf("error revoking api keys of shop: %v", err)
+	}
+	return nil
+}
+
+func (repo *apiKeyRepository) DeleteByShopID(shopID string) error {
+	if _, err := repo.Collection.DeleteMany(repo.Context, bson.M{"shop_id": shopID}); err != nil {
+		return fmt.Errorf("error deleting api keys of shop: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	ds "recycle-waste-management-backend/src/domain/datasources"
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IAuditEventRepository is append-only: events are never updated or deleted
+type IAuditEventRepository interface {
+	// Append inserts the event; ErrAuditSequenceTaken means another event got its sequence first
+	Append(event *models.AuditEvent) error
+	GetLast() (*models.AuditEvent, error)
+	Find(query entities.AuditQuery, page, limit int) (*[]models.AuditEvent, int64, error)
+	// GetFromSequence returns up to limit events starting at sequence, in chain order
+	GetFromSequence(sequence int64, limit int) (*[]models.AuditEvent, error)
+}
+
+var ErrAuditSequenceTaken = errors.New("audit sequence already taken")
+
+type auditEventRepository struct {
+	Collection *mongo.Collection
+	Context    context.Context
+}
+
+func NewAuditEventRepository(db *ds.MongoDB) IAuditEventRepository {
+	repo := &auditEventRepository{
+		Collection: db.MongoDB.Database(os.Getenv("DATABASE_NAME")).Collection("audit_events"),
+		Context:    db.Context,
+	}
+
+	repo.ensureIndexes()
+
+	return repo
+}
+
+func (repo *auditEventRepository) ensureIndexes() {
+	indexModels := []mongo.IndexModel{
+		{
+			// Serializes the chain: two writers cannot both extend the same event
+			Keys:    bson.D{{Key: "sequence", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "shop_id", Value: 1}, {Key: "sequence", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "sequence", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}, {Key: "sequence", Value: -1}},
+		},
+	}
+
+	if _, err := repo.Collection.Indexes().CreateMany(repo.Context, indexModels); err != nil {
+		fmt.Printf("Warning: Could not create audit_events indexes: %v\n", err)
+	}
+}
+
+func (repo *auditEventRepository) Append(event *models.AuditEvent) error {
+	if _, err := repo.Collection.InsertOne(repo.Context, event); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrAuditSequenceTaken
+		}
+		return fmt.Errorf("error inserting audit event: %v", err)
+	}
+	return nil
+}
+
+func (repo *auditEventRepository) GetLast() (*models.AuditEvent, error) {
+	var event models.AuditEvent
+	opts := options.FindOne().SetSort(bson.D{{Key: "sequence", Value: -1}})
+	err := repo.Collection.FindOne(repo.Context, bson.M{}, opts).Decode(&event)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting last audit event: %v", err)
+	}
+	return &event, nil
+}
+
+func (repo *auditEventRepository) Find(query entities.AuditQuery, page, limit int) (*[]models.AuditEvent, int64, error) {
+	filter := bson.M{}
+	if query.ShopID != "" {
+		filter["shop_id"] = query.ShopID
+	}
+	if query.ActorID != "" {
+		filter["actor_id"] = query.ActorID
+	}
+	if query.Action != "" {
+		filter["action"] = query.Action
+	}
+	if query.TargetType != "" {
+		filter["target_type"] = query.TargetType
+	}
+	if query.TargetID != "" {
+		filter["target_id"] = query.TargetID
+	}
+	if query.From != nil || query.To != nil {
+		createdAt := bson.M{}
+		if query.From != nil {
+			createdAt["$gte"] = *query.From
+		}
+		if query.To != nil {
+			createdAt["$lt"] = *query.To
+		}
+		filter["created_at"] = createdAt
+	}
+
+	total, err := repo.Collection.CountDocuments(repo.Context, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error counting audit events: %v", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "sequence", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := repo.Collection.Find(repo.Context, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error finding audit events: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	events := []models.AuditEvent{}
+	if err := cursor.All(repo.Context, &events); err != nil {
+		return nil, 0, fmt.Errorf("error decoding audit events: %v", err)
+	}
+	return &events, total, nil
+}
+
+func (repo *auditEventRepository) GetFromSequence(sequence int64, limit int) (*[]models.AuditEvent, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "sequence", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := repo.Collection.Find(repo.Context, bson.M{"sequence": bson.M{"$gte": sequence}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding audit events: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	events := []models.AuditEvent{}
+	if err := cursor.All(repo.Context, &events); err != nil {
+		return nil, fmt.Errorf("error decoding audit events: %v", err)
+	}
+	return &events, nil
+}
@@ -2,32 +2,112 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	ds "recycle-waste-management-backend/src/domain/datasources"
 	"recycle-waste-management-backend/src/domain/entities"
 
+	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ICategoryWasteRepository interface {
+	// FindAll lists the active categories in display order
 	FindAll() (*[]entities.CategoryWasteModel, error)
+	FindAllIncludingInactive() (*[]entities.CategoryWasteModel, error)
+	GetByID(categoryID string) (*entities.CategoryWasteModel, error)
+	GetByName(name string) (*entities.CategoryWasteModel, error)
+	Create(category *entities.CategoryWasteModel) error
+	Update(category *entities.CategoryWasteModel) error
+	// SetOrder numbers the categories in the given order
+	SetOrder(categoryIDs []string) error
+	Delete(categoryID string) error
 }
 
+var (
+	ErrCategoryNotFound  = errors.New("category not found")
+	ErrCategoryNameTaken = errors.New("category name already exists")
+)
+
 type CategoryWasteRepository struct {
 	Collection *mongo.Collection
 	Context    context.Context
 }
 
 func NewCategoryWasteRepository(db *ds.MongoDB) ICategoryWasteRepository {
-	return &CategoryWasteRepository{
+	repo := &CategoryWasteRepository{
 		Collection: db.MongoDB.Database(os.Getenv("DATABASE_NAME")).Collection("category_waste"),
 		Context:    db.Context,
 	}
+
+	repo.migrateSeededCategories()
+	repo.ensureIndexes()
+
+	return repo
+}
+
+func (r *CategoryWasteRepository) ensureIndexes() {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "category_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+	if _, err := r.Collection.Indexes().CreateMany(r.Context, indexModels); err != nil {
+		fmt.Printf("Warning: Could not create category indexes: %v\n", err)
+	}
+}
+
+// migrateSeededCategories gives categories seeded directly in MongoDB an ID,
+// labels, a position and the active flag
+func (r *CategoryWasteRepository) migrateSeededCategories() {
+	cursor, err := r.Collection.Find(r.Context, bson.M{"category_id": bson.M{"$exists": false}})
+	if err != nil {
+		fmt.Printf("Warning: Could not read seeded categories: %v\n", err)
+		return
+	}
+	defer cursor.Close(r.Context)
+
+	var seeded []bson.M
+	if err := cursor.All(r.Context, &seeded); err != nil {
+		fmt.Printf("Warning: Could not decode seeded categories: %v\n", err)
+		return
+	}
+	for i, doc := range seeded {
+		name, _ := doc["name"].(string)
+		update := bson.M{"$set": bson.M{
+			"category_id": uuid.NewString(),
+			"name_th":     name,
+			"name_en":     name,
+			"sort_order":  i,
+			"active":      true,
+		}}
+		// _id may be an ObjectID or a string, so it is passed back as read
+		if _, err := r.Collection.UpdateOne(r.Context, bson.M{"_id": doc["_id"]}, update); err != nil {
+			fmt.Printf("Warning: Could not migrate category %s: %v\n", name, err)
+		}
+	}
 }
+
 func (r *CategoryWasteRepository) FindAll() (*[]entities.CategoryWasteModel, error) {
+	return r.find(bson.M{"active": true})
+}
+
+func (r *CategoryWasteRepository) FindAllIncludingInactive() (*[]entities.CategoryWasteModel, error) {
+	return r.find(bson.M{})
+}
+
+func (r *CategoryWasteRepository) find(filter bson.M) (*[]entities.CategoryWasteModel, error) {
 	var categoryWaste []entities.CategoryWasteModel
-	cursor, err := r.Collection.Find(r.Context, bson.M{})
+	opts := options.Find().SetSort(bson.D{{Key: "sort_order", Value: 1}, {Key: "name", Value: 1}})
+	cursor, err := r.Collection.Find(r.Context, filter, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -36,3 +116,77 @@ func (r *CategoryWasteRepository) FindAll() (*[]entities.CategoryWasteModel, err
 	}
 	return &categoryWaste, nil
 }
+
+func (r *CategoryWasteRepository) GetByID(categoryID string) (*entities.CategoryWasteModel, error) {
+	return r.findOne(bson.M{"category_id": categoryID})
+}
+
+func (r *CategoryWasteRepository) GetByName(name string) (*entities.CategoryWasteModel, error) {
+	return r.findOne(bson.M{"name": name})
+}
+
+func (r *CategoryWasteRepository) findOne(filter bson.M) (*entities.CategoryWasteModel, error) {
+	var category entities.CategoryWasteModel
+	if err := r.Collection.FindOne(r.Context, filter).Decode(&category); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, fmt.Errorf("error finding category: %v", err)
+	}
+	return &category, nil
+}
+
+func (r *CategoryWasteRepository) Create(category *entities.CategoryWasteModel) error {
+	if _, err := r.Collection.InsertOne(r.Context, category); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrCategoryNameTaken
+		}
+		return fmt.Errorf("error inserting category: %v", err)
+	}
+	return nil
+}
+
+func (r *CategoryWasteRepository) Update(category *entities.CategoryWasteModel) error {
+	update := bson.M{"$set": bson.M{
+		"name":       category.Name,
+		"name_th":    category.NameTH,
+		"name_en":    category.NameEN,
+		"icon_url":   category.IconURL,
+		"active":     category.Active,
+		"updated_at": category.UpdatedAt,
+	}}
+	result, err := r.Collection.UpdateOne(r.Context, bson.M{"category_id": category.CategoryID}, update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrCategoryNameTaken
+		}
+		return fmt.Errorf("error updating category: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+func (r *CategoryWasteRepository) SetOrder(categoryIDs []string) error {
+	writes := make([]mongo.WriteModel, 0, len(categoryIDs))
+	for i, id := range categoryIDs {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"category_id": id}).
+			SetUpdate(bson.M{"$set": bson.M{"sort_order": i}}))
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+	if _, err := r.Collection.BulkWrite(r.Context, writes); err != nil {
+		return fmt.Errorf("error reordering categories: %v", err)
+	}
+	return nil
+}
+
+func (r *CategoryWasteRepository) Delete(categoryID string) error {
+	if _, err := r.Collection.DeleteOne(r.Context, bson.M{"category_id": categoryID}); err != nil {
+		return fmt.Errorf("error deleting category: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"os"
+	ds "recycle-waste-management-backend/src/domain/datasources"
+	"recycle-waste-management-backend/src/domain/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IChatMessageRepository interface {
+	Create(message *models.ChatMessageModel) error
+	MarkDelivered(customerRequestID string, messageIDs []string, userID string) error
+	MarkRead(customerRequestID string, messageIDs []string, userID string) error
+	GetRoomIDsBySender(userID string) ([]string, error)
+	CountUnreadByRoom(userID string, customerRequestIDs []string) (map[string]int64, error)
+	GetByMessageID(messageID string) (*models.ChatMessageModel, error)
+	AddReport(messageID string, report models.ChatMessageReport) error
+	GetByReviewStatus(status string) (*[]models.ChatMessageModel, error)
+	SetReviewStatus(messageID string, status string, reviewerID string) error
+	GetByRooms(customerRequestIDs []string) (*[]models.ChatMessageModel, error)
+	GetBySender(userID string) (*[]models.ChatMessageModel, error)
+	DeleteByRooms(customerRequestIDs []string) error
+	// AnonymizeBySender clears the content of every message userID sent and
+	// replaces the sender, so the other side of a conversation keeps its context
+	AnonymizeBySender(userID string, placeholderUserID string) error
+}
+
+type chatMessageRepository struct {
+	Collection *mongo.Collection
+	Context    context.Context
+}
+
+func NewChatMessageRepository(db *ds.MongoDB) IChatMessageRepository {
+	repo := &chatMessageRepository{
+		Collection: db.MongoDB.Database(os.Getenv("DATABASE_NAME")).Collection("chat_messages"),
+		Context:    db.Context,
+	}
+
+	repo.ensureIndexes()
+
+	return repo
+}
+
+func (repo *chatMessageRepository) ensureIndexes() {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "message_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "customer_request_id", Value: 1}, {Key: "created_at", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "sender_id", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "review_status", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetSparse(true),
+		},
+	}
+
+	if _, err := repo.Collection.Indexes().CreateMany(repo.Context, indexModels); err != nil {
+		fmt.Printf("Warning: Could not create chat_messages indexes: %v\n", err)
+	}
+}
+
+func (repo *chatMessageRepository) Create(message *models.ChatMessageModel) error {
+	if message.DeliveredTo == nil {
+		message.DeliveredTo = []string{}
+	}
+	if message.ReadBy == nil {
+		message.ReadBy = []string{}
+	}
+	if _, err := repo.Collection.InsertOne(repo.Context, message); err != nil {
+		return fmt.Errorf("error inserting chat message: %v", err)
+	}
+	return nil
+}
+
+// MarkDelivered records that userID received the messages. A sender never acknowledges their own messages.
+func (repo *chatMessageRepository) MarkDelivered(customerRequestID string, messageIDs []string, userID string) error {
+	filter := bson.M{
+		"customer_request_id": customerRequestID,
+		"message_id":          bson.M{"$in": messageIDs},
+		"sender_id":           bson.M{"$ne": userID},
+	}
+	update := bson.M{"$addToSet": bson.M{"delivered_to": userID}}
+
+	if _, err := repo.Collection.UpdateMany(repo.Context, filter, update); err != nil {
+		return fmt.Errorf("error marking chat messages delivered: %v", err)
+	}
+	return nil
+}
+
+// MarkRead records that userID read the messages. Reading implies delivery.
+func (repo *chatMessageRepository) MarkRead(customerRequestID string, messageIDs []string, userID string) error {
+	filter := bson.M{
+		"customer_request_id": customerRequestID,
+		"message_id":          bson.M{"$in": messageIDs},
+		"sender_id":           bson.M{"$ne": userID},
+	}
+	update := bson.M{"$addToSet": bson.M{"delivered_to": userID, "read_by": userID}}
+
+	if _, err := repo.Collection.UpdateMany(repo.Context, filter, update); err != nil {
+		return fmt.Errorf("error marking chat messages read: %v", err)
+	}
+	return nil
+}
+
+func (repo *chatMessageRepository) GetRoomIDsBySender(userID string) ([]string, error) {
+	values, err := repo.Collection.Distinct(repo.Context, "customer_request_id", bson.M{"sender_id": userID})
+	if err != nil {
+		return nil, fmt.Errorf("error getting chat rooms: %v", err)
+	}
+
+	var roomIDs []string
+	for _, v := range values {
+		if roomID, ok := v.(string); ok {
+			roomIDs = append(roomIDs, roomID)
+		}
+	}
+	return roomIDs, nil
+}
+
+// CountUnreadByRoom counts messages from other participants that userID has not read, grouped by room
+func (repo *chatMessageRepository) CountUnreadByRoom(userID string, customerRequestIDs []string) (map[string]int64, error) {
+	result := make(map[string]int64)
+	if len(customerRequestIDs) == 0 {
+		return result, nil
+	}
+
+	matchStage := bson.D{{Key: "$match", Value: bson.M{
+		"customer_request_id": bson.M{"$in": customerRequestIDs},
+		"sender_id":           bson.M{"$ne": userID},
+		"read_by":             bson.M{"$ne": userID},
+	}}}
+	groupStage := bson.D{{Key: "$group", Value: bson.M{
+		"_id":   "$customer_request_id",
+		"count": bson.M{"$sum": 1},
+	}}}
+
+	cursor, err := repo.Collection.Aggregate(repo.Context, mongo.Pipeline{matchStage, groupStage})
+	if err != nil {
+		return nil, fmt.Errorf("error counting unread chat messages: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	var rows []struct {
+		RoomID string `bson:"_id"`
+		Count  int64  `bson:"count"`
+	}
+	if err := cursor.All(repo.Context, &rows); err != nil {
+		return nil, fmt.Errorf("error decoding unread chat counts: %v", err)
+	}
+
+	for _, row := range rows {
+		result[row.RoomID] = row.Count
+	}
+	return result, nil
+}
+
+func (repo *chatMessageRepository) GetByMessageID(messageID string) (*models.ChatMessageModel, error) {
+	var result models.ChatMessageModel
+	if err := repo.Collection.FindOne(repo.Context, bson.M{"message_id": messageID}).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error finding chat message: %v", err)
+	}
+	return &result, nil
+}
+
+// AddReport attaches a report and queues the message for admin review.
+// Each user can report a message once; a repeated report is a no-op.
+func (repo *chatMessageRepository) AddReport(messageID string, report models.ChatMessageReport) error {
+	filter := bson.M{
+		"message_id":          messageID,
+		"reports.reporter_id": bson.M{"$ne": report.ReporterID},
+		"review_status":       bson.M{"$ne": models.ChatReviewRemoved},
+	}
+	update := bson.M{
+		"$push": bson.M{"reports": report},
+		"$set":  bson.M{"review_status": models.ChatReviewPending},
+	}
+
+	if _, err := repo.Collection.UpdateOne(repo.Context, filter, update); err != nil {
+		return fmt.Errorf("error reporting chat message: %v", err)
+	}
+	return nil
+}
+
+func (repo *chatMessageRepository) GetByReviewStatus(status string) (*[]models.ChatMessageModel, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := repo.Collection.Find(repo.Context, bson.M{"review_status": status}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding reported chat messages: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	result := []models.ChatMessageModel{}
+	if err := cursor.All(repo.Context, &result); err != nil {
+		return nil, fmt.Errorf("error decoding reported chat messages: %v", err)
+	}
+	return &result, nil
+}
+
+// SetReviewStatus records an admin decision. Removing a message also clears its content.
+func (repo *chatMessageRepository) SetReviewStatus(messageID string, status string, reviewerID string) error {
+	set := bson.M{
+		"review_status": status,
+		"reviewed_by":   reviewerID,
+		"reviewed_at":   time.Now(),
+	}
+	update := bson.M{"$set": set}
+	if status == models.ChatReviewRemoved {
+		set["message"] = ""
+		update["$unset"] = bson.M{"image_url": "", "location": "", "price_offer": ""}
+	}
+
+	result, err := repo.Collection.UpdateOne(repo.Context, bson.M{"message_id": messageID}, update)
+	if err != nil {
+		return fmt.Errorf("error reviewing chat message: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("chat message not found")
+	}
+	return nil
+}
+
+func (repo *chatMessageRepository) GetByRooms(customerRequestIDs []string) (*[]models.ChatMessageModel, error) {
+	result := []models.ChatMessageModel{}
+	if len(customerRequestIDs) == 0 {
+		return &result, nil
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := repo.Collection.Find(repo.Context, bson.M{"customer_request_id": bson.M{"$in": customerRequestIDs}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding chat messages: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	if err := cursor.All(repo.Context, &result); err != nil {
+		return nil, fmt.Errorf("error decoding chat messages: %v", err)
+	}
+	return &result, nil
+}
+
+func (repo *chatMessageRepository) GetBySender(userID string) (*[]models.ChatMessageModel, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := repo.Collection.Find(repo.Context, bson.M{"sender_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding chat messages: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	result := []models.ChatMessageModel{}
+	if err := cursor.All(repo.Context, &result); err != nil {
+		return nil, fmt.Errorf("error decoding chat messages: %v", err)
+	}
+	return &result, nil
+}
+
+func (repo *chatMessageRepository) DeleteByRooms(customerRequestIDs []string) error {
+	if len(customerRequestIDs) == 0 {
+		return nil
+	}
+	if _, err := repo.Collection.DeleteMany(repo.Context, bson.M{"customer_request_id": bson.M{"$in": customerRequestIDs}}); err != nil {
+		return fmt.Errorf("error deleting chat messages: %v", err)
+	}
+	return nil
+}
+
+func (repo *chatMessageRepository) AnonymizeBySender(userID string, placeholderUserID string) error {
+	update := bson.M{
+		"$set":   bson.M{"sender_id": placeholderUserID, "message": ""},
+		"$unset": bson.M{"image_url": "", "location": "", "price_offer": "", "reports": ""},
+	}
+	if _, err := repo.Collection.UpdateMany(repo.Context, bson.M{"sender_id": userID}, update); err != nil {
+		return fmt.Errorf("error anonymizing chat messages: %v", err)
+	}
+	return nil
+}
@@ -16,12 +16,20 @@ type ICustomerRequestRepository interface {
 	AddCustomerRequest(body models.CustomerRequestModel) error
 	GetCustomerRequests(userID string) (*[]models.CustomerRequestModel, error)
 	GetCustomerRequestByID(customerRequestID string) (*models.CustomerRequestModel, error)
+	// GetCustomerRequestIDsByShopIDs lists the requests assigned to any of the shops
+	GetCustomerRequestIDsByShopIDs(shopIDs []string) ([]string, error)
 	CheckCustomerRequestAlreadyExist(userID string) error
 	DeleteAllCustomerRequest(userID string) error
 	GetCustomerRequestsPublic() (*[]models.CustomerRequestModel, error)
 	UpdateCustomerRequestStatus(customerRequestID string, status models.STATUS_REQUEST) error
 	CancelCustomerRequest(customerRequestID string, cancelReason string) error
+	// AcceptCustomerRequest assigns the request to shopID unless another shop already has it
+	AcceptCustomerRequest(customerRequestID string, shopID string) error
 	CompleteCustomerRequest(customerRequestID string, shopID string) error
+	DeleteCustomerRequest(customerRequestID string) error
+	// AnonymizeCustomerRequest keeps the request for the receipt it belongs to but
+	// drops everything that identifies the customer
+	AnonymizeCustomerRequest(customerRequestID string, placeholderUserID string) error
 }
 
 type customerRequestRepository struct {
@@ -72,6 +80,23 @@ func (repo *customerRequestRepository) GetCustomerRequestByID(customerRequestID
 	return &customerRequest, nil
 }
 
+func (repo *customerRequestRepository) GetCustomerRequestIDsByShopIDs(shopIDs []string) ([]string, error) {
+	if len(shopIDs) == 0 {
+		return []string{}, nil
+	}
+	values, err := repo.Collection.Distinct(repo.Context, "customer_request_id", bson.M{"shop_id": bson.M{"$in": shopIDs}})
+	if err != nil {
+		return nil, fmt.Errorf("error getting customer requests of shops: %v", err)
+	}
+	requestIDs := make([]string, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(string); ok {
+			requestIDs = append(requestIDs, id)
+		}
+	}
+	return requestIDs, nil
+}
+
 func (repo *customerRequestRepository) CheckCustomerRequestAlreadyExist(userID string) error {
 	cursor, err := repo.Collection.Find(repo.Context, bson.M{"user_id": userID, "status": "pending"})
 	if err != nil {
@@ -159,7 +184,14 @@ func (repo *customerRequestRepository) CancelCustomerRequest(customerRequestID s
 }
 
 func (repo *customerRequestRepository) CompleteCustomerRequest(customerRequestID string, shopID string) error {
-	filter := bson.M{"customer_request_id": customerRequestID}
+	filter := bson.M{
+		"customer_request_id": customerRequestID,
+		"$or": bson.A{
+			bson.M{"shop_id": bson.M{"$exists": false}},
+			bson.M{"shop_id": ""},
+			bson.M{"shop_id": shopID},
+		},
+	}
 	update := bson.M{
 		"$set": bson.M{
 			"status":     models.CR_DONE,
@@ -174,7 +206,66 @@ func (repo *customerRequestRepository) CompleteCustomerRequest(customerRequestID
 	}
 
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("customer request not found")
+		if _, err := repo.GetCustomerRequestByID(customerRequestID); err != nil {
+			return fmt.Errorf("customer request not found")
+		}
+		return fmt.Errorf("customer request belongs to another shop")
+	}
+
+	return nil
+}
+
+func (repo *customerRequestRepository) DeleteCustomerRequest(customerRequestID string) error {
+	if _, err := repo.Collection.DeleteOne(repo.Context, bson.M{"customer_request_id": customerRequestID}); err != nil {
+		return fmt.Errorf("error deleting customer request: %v", err)
+	}
+	return nil
+}
+
+func (repo *customerRequestRepository) AnonymizeCustomerRequest(customerRequestID string, placeholderUserID string) error {
+	update := bson.M{
+		"$set": bson.M{
+			"user_id":     placeholderUserID,
+			"latitude":    0,
+			"longitude":   0,
+			"description": "",
+			"updated_at":  time.Now().UTC(),
+		},
+		"$unset": bson.M{"cancel_reason": ""},
+	}
+	if _, err := repo.Collection.UpdateOne(repo.Context, bson.M{"customer_request_id": customerRequestID}, update); err != nil {
+		return fmt.Errorf("error anonymizing customer request: %v", err)
+	}
+	return nil
+}
+
+func (repo *customerRequestRepository) AcceptCustomerRequest(customerRequestID string, shopID string) error {
+	filter := bson.M{
+		"customer_request_id": customerRequestID,
+		"$or": bson.A{
+			bson.M{"shop_id": bson.M{"$exists": false}},
+			bson.M{"shop_id": ""},
+			bson.M{"shop_id": shopID},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     models.CR_ACCEPTED,
+			"shop_id":    shopID,
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := repo.Collection.UpdateOne(repo.Context, filter, update)
+	if err != nil {
+		return fmt.Errorf("error accepting customer request: %v", err)
+	}
+
+	if result.MatchedCount == 0 {
+		if _, err := repo.GetCustomerRequestByID(customerRequestID); err != nil {
+			return fmt.Errorf("customer request not found")
+		}
+		return fmt.Errorf("customer request belongs to another shop")
 	}
 
 	return nil
@@ -27,6 +27,8 @@ type IEmployeeRepository interface {
 	UpdateEmployee(employeeID string, employee *models.Employee) error
 	DeleteEmployee(employeeID string) error
 	CountEmployeesByShopID(shopID string) (int, error)
+	GetAllEmployeesByShopID(shopID string) ([]models.Employee, error)
+	DeleteEmployeesByShopID(shopID string) error
 }
 
 func NewEmployeeRepository(db *ds.MongoDB) IEmployeeRepository {
@@ -134,3 +136,24 @@ func (repo *employeeRepository) CountEmployeesByShopID(shopID string) (int, erro
 	}
 	return int(count), nil
 }
+
+func (repo *employeeRepository) GetAllEmployeesByShopID(shopID string) ([]models.Employee, error) {
+	cursor, err := repo.Collection.Find(repo.Context, bson.M{"shop_id": shopID})
+	if err != nil {
+		return nil, fmt.Errorf("error finding employees: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	employees := []models.Employee{}
+	if err := cursor.All(repo.Context, &employees); err != nil {
+		return nil, fmt.Errorf("error decoding employees: %v", err)
+	}
+	return employees, nil
+}
+
+func (repo *employeeRepository) DeleteEmployeesByShopID(shopID string) error {
+	if _, err := repo.Collection.DeleteMany(repo.Context, bson.M{"shop_id": shopID}); err != nil {
+		return fmt.Errorf("error deleting employees of shop: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	ds "recycle-waste-management-backend/src/domain/datasources"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ILoginAttemptRepository counts failed logins and holds temporary lockouts in Redis
+type ILoginAttemptRepository interface {
+	// LockRemaining returns how long the key is still locked, zero if it is not
+	LockRemaining(key string) (time.Duration, error)
+	// RecordFailure counts a failure within window and returns the failures so far
+	RecordFailure(key string, window time.Duration) (int64, error)
+	Lock(key string, duration time.Duration) error
+	Reset(key string) error
+}
+
+type loginAttemptRepository struct {
+	Client  *redis.Client
+	Context context.Context
+}
+
+func NewLoginAttemptRepository(rdb *ds.RedisConnection) ILoginAttemptRepository {
+	return &loginAttemptRepository{
+		Client:  rdb.RedisWR,
+		Context: rdb.Context,
+	}
+}
+
+func loginFailuresKey(key string) string { return "login:failures:" + key }
+func loginLockKey(key string) string     { return "login:lock:" + key }
+
+func (repo *loginAttemptRepository) LockRemaining(key string) (time.Duration, error) {
+	ttl, err := repo.Client.PTTL(repo.Context, loginLockKey(key)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("error getting login lock: %v", err)
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (repo *loginAttemptRepository) RecordFailure(key string, window time.Duration) (int64, error) {
+	failuresKey := loginFailuresKey(key)
+	count, err := repo.Client.Incr(repo.Context, failuresKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("error recording login failure: %v", err)
+	}
+	if count == 1 {
+		repo.Client.Expire(repo.Context, failuresKey, window)
+	}
+	return count, nil
+}
+
+func (repo *loginAttemptRepository) Lock(key string, duration time.Duration) error {
+	if err := repo.Client.Set(repo.Context, loginLockKey(key), 1, duration).Err(); err != nil {
+		return fmt.Errorf("error setting login lock: %v", err)
+	}
+	return nil
+}
+
+func (repo *loginAttemptRepository) Reset(key string) error {
+	if err := repo.Client.Del(repo.Context, loginFailuresKey(key), loginLockKey(key)).Err(); err != nil {
+		return fmt.Errorf("error resetting login failures: %v", err)
+	}
+	return nil
+}
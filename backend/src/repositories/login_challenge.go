@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	ds "recycle-waste-management-backend/src/domain/datasources"
+	"recycle-waste-management-backend/src/domain/entities"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/redis/go-redis/v9"
+)
+
+// ILoginChallengeRepository keeps logins that passed the first factor and are
+// waiting for a two-factor code
+type ILoginChallengeRepository interface {
+	Save(token string, challenge entities.LoginChallenge, ttl time.Duration) error
+	Get(token string) (*entities.LoginChallenge, error)
+	// Delete removes the challenge and reports whether it still existed
+	Delete(token string) (bool, error)
+}
+
+// ErrLoginChallengeNotFound is returned for unknown, expired or completed challenges
+var ErrLoginChallengeNotFound = errors.New("unknown or expired login challenge")
+
+type loginChallengeRepository struct {
+	Client  *redis.Client
+	Context context.Context
+}
+
+func NewLoginChallengeRepository(rdb *ds.RedisConnection) ILoginChallengeRepository {
+	return &loginChallengeRepository{
+		Client:  rdb.RedisWR,
+		Context: rdb.Context,
+	}
+}
+
+func loginChallengeKey(token string) string {
+	return "login:challenge:" + token
+}
+
+func (repo *loginChallengeRepository) Save(token string, challenge entities.LoginChallenge, ttl time.Duration) error {
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return fmt.Errorf("error encoding login challenge: %v", err)
+	}
+
+	if err := repo.Client.Set(repo.Context, loginChallengeKey(token), data, ttl).Err(); err != nil {
+		return fmt.Errorf("error saving login challenge: %v", err)
+	}
+	return nil
+}
+
+func (repo *loginChallengeRepository) Get(token string) (*entities.LoginChallenge, error) {
+	data, err := repo.Client.Get(repo.Context, loginChallengeKey(token)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrLoginChallengeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting login challenge: %v", err)
+	}
+
+	var challenge entities.LoginChallenge
+	if err := json.Unmarshal(data, &challenge); err != nil {
+		return nil, fmt.Errorf("error decoding login challenge: %v", err)
+	}
+	return &challenge, nil
+}
+
+func (repo *loginChallengeRepository) Delete(token string) (bool, error) {
+	deleted, err := repo.Client.Del(repo.Context, loginChallengeKey(token)).Result()
+	if err != nil {
+		return false, fmt.Errorf("error deleting login challenge: %v", err)
+	}
+	return deleted > 0, nil
+}
@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	ds "recycle-waste-management-backend/src/domain/datasources"
+	"recycle-waste-management-backend/src/domain/entities"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IMaterialRepository interface {
+	Create(material *entities.MaterialModel) error
+	GetByID(materialID string) (*entities.MaterialModel, error)
+	GetByIDs(materialIDs []string) (*[]entities.MaterialModel, error)
+	// GetAll lists the catalog ordered by code; an empty category lists everything
+	GetAll(category string) (*[]entities.MaterialModel, error)
+	// FindByName returns the material whose Thai or English name or alias is name, ignoring case
+	FindByName(name string) (*entities.MaterialModel, error)
+	Update(material *entities.MaterialModel) error
+	Delete(materialID string) error
+	RenameCategory(from, to string) (int64, error)
+}
+
+var (
+	ErrMaterialNotFound  = errors.New("material not found")
+	ErrMaterialCodeTaken = errors.New("material code already exists")
+)
+
+type materialRepository struct {
+	Collection *mongo.Collection
+	Context    context.Context
+}
+
+func NewMaterialRepository(db *ds.MongoDB) IMaterialRepository {
+	repo := &materialRepository{
+		Collection: db.MongoDB.Database(os.Getenv("DATABASE_NAME")).Collection("materials"),
+		Context:    db.Context,
+	}
+
+	repo.ensureIndexes()
+
+	return repo
+}
+
+func (repo *materialRepository) ensureIndexes() {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "material_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "code", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "category", Value: 1}},
+		},
+	}
+	if _, err := repo.Collection.Indexes().CreateMany(repo.Context, indexModels); err != nil {
+		fmt.Printf("Warning: Could not create materials indexes: %v\n", err)
+	}
+}
+
+func (repo *materialRepository) Create(material *entities.MaterialModel) error {
+	if _, err := repo.Collection.InsertOne(repo.Context, material); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrMaterialCodeTaken
+		}
+		return fmt.Errorf("error inserting material: %v", err)
+	}
+	return nil
+}
+
+func (repo *materialRepository) GetByID(materialID string) (*entities.MaterialModel, error) {
+	var material entities.MaterialModel
+	if err := repo.Collection.FindOne(repo.Context, bson.M{"material_id": materialID}).Decode(&material); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrMaterialNotFound
+		}
+		return nil, fmt.Errorf("error finding material: %v", err)
+	}
+	return &material, nil
+}
+
+func (repo *materialRepository) GetByIDs(materialIDs []string) (*[]entities.MaterialModel, error) {
+	return repo.find(bson.M{"material_id": bson.M{"$in": materialIDs}})
+}
+
+func (repo *materialRepository) GetAll(category string) (*[]entities.MaterialModel, error) {
+	filter := bson.M{}
+	if category != "" {
+		filter["category"] = category
+	}
+	return repo.find(filter)
+}
+
+func (repo *materialRepository) FindByName(name string) (*entities.MaterialModel, error) {
+	pattern := bson.M{"$regex": "^" + regexp.QuoteMeta(name) + "$", "$options": "i"}
+	filter := bson.M{"$or": bson.A{
+		bson.M{"name_th": pattern},
+		bson.M{"name_en": pattern},
+		bson.M{"aliases": pattern},
+	}}
+	var material entities.MaterialModel
+	if err := repo.Collection.FindOne(repo.Context, filter).Decode(&material); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrMaterialNotFound
+		}
+		return nil, fmt.Errorf("error finding material by name: %v", err)
+	}
+	return &material, nil
+}
+
+func (repo *materialRepository) Update(material *entities.MaterialModel) error {
+	result, err := repo.Collection.ReplaceOne(repo.Context, bson.M{"material_id": material.MaterialID}, material)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrMaterialCodeTaken
+		}
+		return fmt.Errorf("error updating material: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrMaterialNotFound
+	}
+	return nil
+}
+
+func (repo *materialRepository) Delete(materialID string) error {
+	result, err := repo.Collection.DeleteOne(repo.Context, bson.M{"material_id": materialID})
+	if err != nil {
+		return fmt.Errorf("error deleting material: %v", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrMaterialNotFound
+	}
+	return nil
+}
+
+func (repo *materialRepository) find(filter bson.M) (*[]entities.MaterialModel, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "code", Value: 1}})
+	cursor, err := repo.Collection.Find(repo.Context, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding materials: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	materials := []entities.MaterialModel{}
+	if err := cursor.All(repo.Context, &materials); err != nil {
+		return nil, fmt.Errorf("error decoding materials: %v", err)
+	}
+	return &materials, nil
+}
+
+func (repo *materialRepository) RenameCategory(from, to string) (int64, error) {
+	result, err := repo.Collection.UpdateMany(repo.Context, bson.M{"category": from}, bson.M{"$set": bson.M{"category": to}})
+	if err != nil {
+		return 0, fmt.Errorf("error renaming category of materials: %v", err)
+	}
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	ds "recycle-waste-management-backend/src/domain/datasources"
+	"recycle-waste-management-backend/src/domain/entities"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/redis/go-redis/v9"
+)
+
+// IOAuthStateRepository keeps short-lived login data in Redis: the state of
+// OAuth logins in flight, so callbacks can only complete a login this server
+// started, and pending account links offered after an email match.
+type IOAuthStateRepository interface {
+	Save(provider string, state string, data entities.OAuthState, ttl time.Duration) error
+	// Consume returns the data stored with the state and removes it
+	Consume(provider string, state string) (*entities.OAuthState, error)
+	SavePendingLink(token string, identity entities.OAuthIdentity, ttl time.Duration) error
+	ConsumePendingLink(token string) (*entities.OAuthIdentity, error)
+}
+
+// ErrOAuthStateNotFound is returned for unknown, expired or already used states and link tokens
+var ErrOAuthStateNotFound = errors.New("unknown or expired oauth state")
+
+type oauthStateRepository struct {
+	Client  *redis.Client
+	Context context.Context
+}
+
+func NewOAuthStateRepository(rdb *ds.RedisConnection) IOAuthStateRepository {
+	return &oauthStateRepository{
+		Client:  rdb.RedisWR,
+		Context: rdb.Context,
+	}
+}
+
+func oauthStateKey(provider string, state string) string {
+	return "oauth:state:" + provider + ":" + state
+}
+
+func pendingLinkKey(token string) string {
+	return "oauth:link:" + token
+}
+
+func (repo *oauthStateRepository) Save(provider string, state string, data entities.OAuthState, ttl time.Duration) error {
+	return repo.setJSON(oauthStateKey(provider, state), data, ttl)
+}
+
+func (repo *oauthStateRepository) Consume(provider string, state string) (*entities.OAuthState, error) {
+	var data entities.OAuthState
+	if err := repo.getDelJSON(oauthStateKey(provider, state), &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (repo *oauthStateRepository) SavePendingLink(token string, identity entities.OAuthIdentity, ttl time.Duration) error {
+	return repo.setJSON(pendingLinkKey(token), identity, ttl)
+}
+
+func (repo *oauthStateRepository) ConsumePendingLink(token string) (*entities.OAuthIdentity, error) {
+	var identity entities.OAuthIdentity
+	if err := repo.getDelJSON(pendingLinkKey(token), &identity); err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (repo *oauthStateRepository) setJSON(key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("error encoding oauth state: %v", err)
+	}
+	if err := repo.Client.Set(repo.Context, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("error saving oauth state: %v", err)
+	}
+	return nil
+}
+
+func (repo *oauthStateRepository) getDelJSON(key string, out interface{}) error {
+	data, err := repo.Client.GetDel(repo.Context, key).Bytes()
+	if err == redis.Nil {
+		return ErrOAuthStateNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error getting oauth state: %v", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("error decoding oauth state: %v", err)
+	}
+	return nil
+}
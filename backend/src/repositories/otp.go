@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	ds "recycle-waste-management-backend/src/domain/datasources"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IOTPRepository keeps one-time login codes in Redis. Only a hash of the
+// code is stored; keys expire on their own.
+type IOTPRepository interface {
+	SaveCode(phone string, codeHash string, ttl time.Duration) error
+	GetCode(phone string) (codeHash string, attempts int64, err error)
+	IncrementAttempts(phone string) (int64, error)
+	// DeleteCode reports whether a code was removed, so a code can only be consumed once
+	DeleteCode(phone string) (bool, error)
+	// AcquireCooldown reports false while a previous code was sent less than cooldown ago
+	AcquireCooldown(phone string, cooldown time.Duration) (bool, error)
+	CooldownRemaining(phone string) (time.Duration, error)
+	// IncrementSendCount counts codes sent to the phone within the current window
+	IncrementSendCount(phone string, window time.Duration) (int64, error)
+}
+
+// ErrOTPNotFound is returned when no code is pending for the phone
+var ErrOTPNotFound = errors.New("no pending code")
+
+type otpRepository struct {
+	Client  *redis.Client
+	Context context.Context
+}
+
+func NewOTPRepository(rdb *ds.RedisConnection) IOTPRepository {
+	return &otpRepository{
+		Client:  rdb.RedisWR,
+		Context: rdb.Context,
+	}
+}
+
+func otpCodeKey(phone string) string     { return "otp:code:" + phone }
+func otpCooldownKey(phone string) string { return "otp:cooldown:" + phone }
+func otpSendsKey(phone string) string    { return "otp:sends:" + phone }
+
+func (repo *otpRepository) SaveCode(phone string, codeHash string, ttl time.Duration) error {
+	key := otpCodeKey(phone)
+	pipe := repo.Client.TxPipeline()
+	pipe.Del(repo.Context, key)
+	pipe.HSet(repo.Context, key, "hash", codeHash, "attempts", 0)
+	pipe.Expire(repo.Context, key, ttl)
+	if _, err := pipe.Exec(repo.Context); err != nil {
+		return fmt.Errorf("error saving otp: %v", err)
+	}
+	return nil
+}
+
+func (repo *otpRepository) GetCode(phone string) (string, int64, error) {
+	values, err := repo.Client.HGetAll(repo.Context, otpCodeKey(phone)).Result()
+	if err != nil {
+		return "", 0, fmt.Errorf("error getting otp: %v", err)
+	}
+	hash, ok := values["hash"]
+	if !ok {
+		return "", 0, ErrOTPNotFound
+	}
+	var attempts int64
+	fmt.Sscan(values["attempts"], &attempts)
+	return hash, attempts, nil
+}
+
+func (repo *otpRepository) IncrementAttempts(phone string) (int64, error) {
+	attempts, err := repo.Client.HIncrBy(repo.Context, otpCodeKey(phone), "attempts", 1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("error counting otp attempts: %v", err)
+	}
+	return attempts, nil
+}
+
+func (repo *otpRepository) DeleteCode(phone string) (bool, error) {
+	deleted, err := repo.Client.Del(repo.Context, otpCodeKey(phone)).Result()
+	if err != nil {
+		return false, fmt.Errorf("error deleting otp: %v", err)
+	}
+	return deleted > 0, nil
+}
+
+func (repo *otpRepository) AcquireCooldown(phone string, cooldown time.Duration) (bool, error) {
+	ok, err := repo.Client.SetNX(repo.Context, otpCooldownKey(phone), 1, cooldown).Result()
+	if err != nil {
+		return false, fmt.Errorf("error setting otp cooldown: %v", err)
+	}
+	return ok, nil
+}
+
+func (repo *otpRepository) CooldownRemaining(phone string) (time.Duration, error) {
+	ttl, err := repo.Client.TTL(repo.Context, otpCooldownKey(phone)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("error getting otp cooldown: %v", err)
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (repo *otpRepository) IncrementSendCount(phone string, window time.Duration) (int64, error) {
+	key := otpSendsKey(phone)
+	count, err := repo.Client.Incr(repo.Context, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("error counting otp sends: %v", err)
+	}
+	if count == 1 {
+		repo.Client.Expire(repo.Context, key, window)
+	}
+	return count, nil
+}
@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"os"
+	ds "recycle-waste-management-backend/src/domain/datasources"
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/domain/models"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IPriceHistoryRepository interface {
+	Create(entry *models.PriceHistoryEntry) error
+	// CreateMany records several entries at once, e.g. the removals of an archived shop's items
+	CreateMany(entries []models.PriceHistoryEntry) error
+	// GetByWasteID returns the entries of an item changed in [from, to), oldest first
+	GetByWasteID(wasteID string, from, to time.Time) (*[]models.PriceHistoryEntry, error)
+	// GetWasteIDsByMaterial lists the items that were ever recorded under a name (case-insensitive) and optional category
+	GetWasteIDsByMaterial(name, category string, before time.Time) ([]string, error)
+	// GetWasteIDsByMaterialID lists the items that were ever mapped to a catalog material
+	GetWasteIDsByMaterialID(materialID string, before time.Time) ([]string, error)
+	// GetByWasteIDs returns the entries of several items changed before a time, oldest first
+	GetByWasteIDs(wasteIDs []string, before time.Time) (*[]models.PriceHistoryEntry, error)
+	// RenameCategory moves every entry from one category name to another, so the
+	// market series of a renamed or merged category stays continuous
+	RenameCategory(from, to string) (int64, error)
+	DeleteByShopID(shopID string) error
+}
+
+type priceHistoryRepository struct {
+	Collection *mongo.Collection
+	Context    context.Context
+}
+
+func NewPriceHistoryRepository(db *ds.MongoDB) IPriceHistoryRepository {
+	database := db.MongoDB.Database(os.Getenv("DATABASE_NAME"))
+	repo := &priceHistoryRepository{
+		Collection: database.Collection("price_history"),
+		Context:    db.Context,
+	}
+
+	repo.seedFromItems(database.Collection("recyclable_items"))
+	repo.ensureIndexes()
+
+	return repo
+}
+
+func (repo *priceHistoryRepository) ensureIndexes() {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "waste_id", Value: 1}, {Key: "changed_at", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "name", Value: 1}, {Key: "category", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "shop_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "material_id", Value: 1}},
+		},
+	}
+	if _, err := repo.Collection.Indexes().CreateMany(repo.Context, indexModels); err != nil {
+		fmt.Printf("Warning: Could not create price history indexes: %v\n", err)
+	}
+}
+
+// seedFromItems starts the history of every listed item at its current
+// price the first time the collection is used
+func (repo *priceHistoryRepository) seedFromItems(items *mongo.Collection) {
+	count, err := repo.Collection.EstimatedDocumentCount(repo.Context)
+	if err != nil || count > 0 {
+		return
+	}
+	cursor, err := items.Find(repo.Context, publicItemsFilter)
+	if err != nil {
+		fmt.Printf("Warning: Could not read recyclable items to seed price history: %v\n", err)
+		return
+	}
+	defer cursor.Close(repo.Context)
+
+	var current []entities.RecyclableItemsModel
+	if err := cursor.All(repo.Context, &current); err != nil {
+		fmt.Printf("Warning: Could not decode recyclable items to seed price history: %v\n", err)
+		return
+	}
+	documents := make([]interface{}, 0, len(current))
+	for _, item := range current {
+		documents = append(documents, models.PriceHistoryEntry{
+			WasteID:    item.WasteID,
+			ShopID:     item.ShopID,
+			MaterialID: item.MaterialID,
+			Name:       item.Name,
+			Category:   item.Category,
+			Price:      item.Price,
+			ChangedAt:  item.LastUpdate,
+		})
+	}
+	if len(documents) == 0 {
+		return
+	}
+	if _, err := repo.Collection.InsertMany(repo.Context, documents); err != nil {
+		fmt.Printf("Warning: Could not seed price history: %v\n", err)
+	}
+}
+
+func (repo *priceHistoryRepository) Create(entry *models.PriceHistoryEntry) error {
+	if _, err := repo.Collection.InsertOne(repo.Context, entry); err != nil {
+		return fmt.Errorf("error inserting price history: %v", err)
+	}
+	return nil
+}
+
+func (repo *priceHistoryRepository) CreateMany(entries []models.PriceHistoryEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	documents := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		documents = append(documents, entry)
+	}
+	if _, err := repo.Collection.InsertMany(repo.Context, documents); err != nil {
+		return fmt.Errorf("error inserting price history: %v", err)
+	}
+	return nil
+}
+
+func (repo *priceHistoryRepository) GetByWasteID(wasteID string, from, to time.Time) (*[]models.PriceHistoryEntry, error) {
+	filter := bson.M{"waste_id": wasteID, "changed_at": bson.M{"$gte": from, "$lt": to}}
+	return repo.find(filter)
+}
+
+func (repo *priceHistoryRepository) GetWasteIDsByMaterial(name, category string, before time.Time) ([]string, error) {
+	filter := bson.M{
+		"name":       bson.M{"$regex": "^" + regexp.QuoteMeta(name) + "$", "$options": "i"},
+		"changed_at": bson.M{"$lt": before},
+	}
+	if category != "" {
+		filter["category"] = category
+	}
+	return repo.distinctWasteIDs(filter)
+}
+
+func (repo *priceHistoryRepository) GetWasteIDsByMaterialID(materialID string, before time.Time) ([]string, error) {
+	return repo.distinctWasteIDs(bson.M{"material_id": materialID, "changed_at": bson.M{"$lt": before}})
+}
+
+func (repo *priceHistoryRepository) distinctWasteIDs(filter bson.M) ([]string, error) {
+	values, err := repo.Collection.Distinct(repo.Context, "waste_id", filter)
+	if err != nil {
+		return nil, fmt.Errorf("error finding price history by material: %v", err)
+	}
+	wasteIDs := make([]string, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(string); ok {
+			wasteIDs = append(wasteIDs, id)
+		}
+	}
+	return wasteIDs, nil
+}
+
+func (repo *priceHistoryRepository) GetByWasteIDs(wasteIDs []string, before time.Time) (*[]models.PriceHistoryEntry, error) {
+	filter := bson.M{"waste_id": bson.M{"$in": wasteIDs}, "changed_at": bson.M{"$lt": before}}
+	return repo.find(filter)
+}
+
+func (repo *priceHistoryRepository) find(filter bson.M) (*[]models.PriceHistoryEntry, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "changed_at", Value: 1}})
+	cursor, err := repo.Collection.Find(repo.Context, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding price history: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	entries := []models.PriceHistoryEntry{}
+	if err := cursor.All(repo.Context, &entries); err != nil {
+		return nil, fmt.Errorf("error decoding price history: %v", err)
+	}
+	return &entries, nil
+}
+
+func (repo *priceHistoryRepository) RenameCategory(from, to string) (int64, error) {
+	result, err := repo.Collection.UpdateMany(repo.Context, bson.M{"category": from}, bson.M{"$set": bson.M{"category": to}})
+	if err != nil {
+		return 0, fmt.Errorf("error renaming category of price history: %v", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+func (repo *priceHistoryRepository) DeleteByShopID(shopID string) error {
+	if _, err := repo.Collection.DeleteMany(repo.Context, bson.M{"shop_id": shopID}); err != nil {
+		return fmt.Errorf("error deleting price history of shop: %v", err)
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	ds "recycle-waste-management-backend/src/domain/datasources"
 	"recycle-waste-management-backend/src/domain/entities"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -17,6 +18,8 @@ type IReceiptRepository interface {
 	FindByID(receiptID string) (*entities.Receipt, error)
 	FindByCustomerRequestID(requestID string) (*entities.Receipt, error)
 	FindByShopID(shopID string) ([]entities.Receipt, error)
+	Void(receiptID string, reason string, voidedAt time.Time) (bool, error)
+	CountByShopID(shopID string) (int64, error)
 }
 
 type receiptRepository struct {
@@ -84,3 +87,27 @@ func (repo *receiptRepository) FindByShopID(shopID string) ([]entities.Receipt,
 
 	return receipts, nil
 }
+
+// Void marks a completed receipt as voided. It reports false when the receipt
+// was not completed, so a receipt is only reversed once.
+func (repo *receiptRepository) Void(receiptID string, reason string, voidedAt time.Time) (bool, error) {
+	filter := bson.M{"_id": receiptID, "status": "completed"}
+	update := bson.M{"$set": bson.M{
+		"status":      "voided",
+		"voided_at":   voidedAt,
+		"void_reason": reason,
+	}}
+	result, err := repo.Collection.UpdateOne(repo.Context, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("error voiding receipt: %v", err)
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+func (repo *receiptRepository) CountByShopID(shopID string) (int64, error) {
+	count, err := repo.Collection.CountDocuments(repo.Context, bson.M{"shop_id": shopID})
+	if err != nil {
+		return 0, fmt.Errorf("error counting receipts: %v", err)
+	}
+	return count, nil
+}
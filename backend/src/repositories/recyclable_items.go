@@ -2,12 +2,16 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	ds "recycle-waste-management-backend/src/domain/datasources"
 	"recycle-waste-management-backend/src/domain/entities"
+	"regexp"
+	"strings"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -18,27 +22,71 @@ type IRecyclableItemsRepository interface {
 	Create(data *entities.RecyclableItemsModel) error
 	Delete(wasteID string) error
 	Update(wasteID string, data *entities.RecyclableItemsModel) error
-	FindAllPaginated(page, limit int) (*[]entities.RecyclableItemsModel, int64, error)
-	FindByShopIDPaginated(shopID string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error)
-	FindByShopIDAndCategoryPaginated(shopID, category string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error)
+	// The paginated finders take an optional search, which orders results by relevance
+	FindAllPaginated(search string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error)
+	FindByShopIDPaginated(shopID, search string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error)
+	FindByShopIDAndCategoryPaginated(shopID, category, search string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error)
+	// SuggestNames lists the item names matching a partial query, most relevant and widely sold first
+	SuggestNames(query string, limit int) ([]entities.SearchSuggestion, error)
 	UpdateStock(wasteID string, quantity float64) error
+	// FindByMaterial lists the items mapped to a material, with a name (case-insensitive) and/or
+	// category; empty arguments match anything
+	FindByMaterial(materialID, name, category string) (*[]entities.RecyclableItemsModel, error)
+	FindAllByShopID(shopID string) (*[]entities.RecyclableItemsModel, error)
+	// ArchiveByShopID hides the items of an archived shop from the public listings
+	ArchiveByShopID(shopID string) error
+	DeleteByShopID(shopID string) error
+	// CountOtherByURL counts items outside a shop that use an image URL
+	CountOtherByURL(url string, excludeShopID string) (int64, error)
+	// MapUnmappedByNames assigns a material to unmapped items sold under one of its names
+	MapUnmappedByNames(materialID string, names []string) (int64, error)
+	// UnmapMaterial detaches every item from a material
+	UnmapMaterial(materialID string) error
+	// RenameCategory moves every item from one category name to another
+	RenameCategory(from, to string) (int64, error)
+	// ApplyPriceList creates and updates items in one transaction, so either all of them change or none.
+	// A standalone MongoDB has no transactions: the writes are then applied in order, stopping at the
+	// first failure, and atomic is false. written counts the applied writes, creates first.
+	ApplyPriceList(creates, updates []entities.RecyclableItemsModel) (written int, atomic bool, err error)
 }
 
+// publicItemsFilter leaves out the items of archived shops
+var publicItemsFilter = bson.M{"shop_archived": bson.M{"$ne": true}}
+
 type recyclableItemsRepository struct {
 	Collection *mongo.Collection
 	Context    context.Context
 }
 
 func NewRecyclableItemsRepository(db *ds.MongoDB) IRecyclableItemsRepository {
-	return &recyclableItemsRepository{
+	repo := &recyclableItemsRepository{
 		Collection: db.MongoDB.Database(os.Getenv("DATABASE_NAME")).Collection("recyclable_items"),
 		Context:    db.Context,
 	}
+
+	// Items written before search was added get their search terms
+	if err := repo.refreshSearchTerms(bson.M{"search_terms": bson.M{"$exists": false}}); err != nil {
+		fmt.Printf("Warning: Could not backfill search terms: %v\n", err)
+	}
+	repo.ensureIndexes()
+
+	return repo
+}
+
+func (repo *recyclableItemsRepository) ensureIndexes() {
+	indexModel := mongo.IndexModel{Keys: bson.D{{Key: "search_terms", Value: 1}}}
+	if _, err := repo.Collection.Indexes().CreateOne(repo.Context, indexModel); err != nil {
+		fmt.Printf("Warning: Could not create search_terms index: %v\n", err)
+	}
+	materialIndex := mongo.IndexModel{Keys: bson.D{{Key: "material_id", Value: 1}}}
+	if _, err := repo.Collection.Indexes().CreateOne(repo.Context, materialIndex); err != nil {
+		fmt.Printf("Warning: Could not create material_id index: %v\n", err)
+	}
 }
 
 func (repo *recyclableItemsRepository) FindAll() (*[]entities.RecyclableItemsModel, error) {
 
-	cursor, err := repo.Collection.Find(repo.Context, bson.M{})
+	cursor, err := repo.Collection.Find(repo.Context, publicItemsFilter)
 	if err != nil {
 		return nil, fmt.Errorf("error finding recyclable items: %v", err)
 	}
@@ -61,6 +109,7 @@ func (repo *recyclableItemsRepository) FindAll() (*[]entities.RecyclableItemsMod
 }
 
 func (repo *recyclableItemsRepository) Create(data *entities.RecyclableItemsModel) error {
+	data.SearchTerms = searchTerms(data.Name, data.Category)
 	_, err := repo.Collection.InsertOne(repo.Context, data)
 	if err != nil {
 		return fmt.Errorf("error inserting recyclable item: %v", err)
@@ -88,13 +137,15 @@ func (repo *recyclableItemsRepository) FindByWasteID(wasteID string) (*entities.
 func (repo *recyclableItemsRepository) Update(wasteID string, data *entities.RecyclableItemsModel) error {
 	filter := bson.M{"waste_id": wasteID}
 	update := bson.M{"$set": bson.M{
-		"shop_id":    data.ShopID,
-		"name":       data.Name,
-		"category":   data.Category,
-		"price":      data.Price,
-		"lastupdate": data.LastUpdate,
-		"hours":      data.Hours,
-		"url":        data.URL,
+		"shop_id":      data.ShopID,
+		"name":         data.Name,
+		"category":     data.Category,
+		"price":        data.Price,
+		"lastupdate":   data.LastUpdate,
+		"hours":        data.Hours,
+		"url":          data.URL,
+		"material_id":  data.MaterialID,
+		"search_terms": searchTerms(data.Name, data.Category),
 	}}
 	if _, err := repo.Collection.UpdateOne(repo.Context, filter, update); err != nil {
 		return fmt.Errorf("error updating recyclable item: %v", err)
@@ -102,13 +153,16 @@ func (repo *recyclableItemsRepository) Update(wasteID string, data *entities.Rec
 	return nil
 }
 
-func (repo *recyclableItemsRepository) FindAllPaginated(page, limit int) (*[]entities.RecyclableItemsModel, int64, error) {
+func (repo *recyclableItemsRepository) FindAllPaginated(search string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error) {
+	if strings.TrimSpace(search) != "" {
+		return repo.searchPaginated(publicItemsFilter, search, page, limit)
+	}
 	skip := int64((page - 1) * limit)
 	limit64 := int64(limit)
 
 	cursor, err := repo.Collection.Find(
 		repo.Context,
-		bson.M{},
+		publicItemsFilter,
 		&options.FindOptions{
 			Skip:  &skip,
 			Limit: &limit64,
@@ -133,7 +187,7 @@ func (repo *recyclableItemsRepository) FindAllPaginated(page, limit int) (*[]ent
 	}
 
 	// Get total count
-	totalCount, err := repo.Collection.CountDocuments(repo.Context, bson.M{})
+	totalCount, err := repo.Collection.CountDocuments(repo.Context, publicItemsFilter)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error counting recyclable items: %v", err)
 	}
@@ -141,11 +195,14 @@ func (repo *recyclableItemsRepository) FindAllPaginated(page, limit int) (*[]ent
 	return &recyclableItems, totalCount, nil
 }
 
-func (repo *recyclableItemsRepository) FindByShopIDPaginated(shopID string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error) {
+func (repo *recyclableItemsRepository) FindByShopIDPaginated(shopID, search string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error) {
 	skip := int64((page - 1) * limit)
 	limit64 := int64(limit)
 
 	filter := bson.M{"shop_id": shopID}
+	if strings.TrimSpace(search) != "" {
+		return repo.searchPaginated(filter, search, page, limit)
+	}
 	cursor, err := repo.Collection.Find(
 		repo.Context,
 		filter,
@@ -181,11 +238,14 @@ func (repo *recyclableItemsRepository) FindByShopIDPaginated(shopID string, page
 	return &recyclableItems, totalCount, nil
 }
 
-func (repo *recyclableItemsRepository) FindByShopIDAndCategoryPaginated(shopID, category string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error) {
+func (repo *recyclableItemsRepository) FindByShopIDAndCategoryPaginated(shopID, category, search string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error) {
 	skip := int64((page - 1) * limit)
 	limit64 := int64(limit)
 
 	filter := bson.M{"shop_id": shopID, "category": category}
+	if strings.TrimSpace(search) != "" {
+		return repo.searchPaginated(filter, search, page, limit)
+	}
 	cursor, err := repo.Collection.Find(
 		repo.Context,
 		filter,
@@ -230,3 +290,289 @@ func (repo *recyclableItemsRepository) UpdateStock(wasteID string, quantity floa
 	}
 	return nil
 }
+
+func (repo *recyclableItemsRepository) FindByMaterial(materialID, name, category string) (*[]entities.RecyclableItemsModel, error) {
+	filter := bson.M{"shop_archived": bson.M{"$ne": true}}
+	if materialID != "" {
+		filter["material_id"] = materialID
+	}
+	if name != "" {
+		filter["name"] = bson.M{"$regex": "^" + regexp.QuoteMeta(name) + "$", "$options": "i"}
+	}
+	if category != "" {
+		filter["category"] = category
+	}
+	cursor, err := repo.Collection.Find(repo.Context, filter)
+	if err != nil {
+		return nil, fmt.Errorf("error finding recyclable items by material: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	items := []entities.RecyclableItemsModel{}
+	if err := cursor.All(repo.Context, &items); err != nil {
+		return nil, fmt.Errorf("error decoding recyclable items: %v", err)
+	}
+	return &items, nil
+}
+
+func (repo *recyclableItemsRepository) FindAllByShopID(shopID string) (*[]entities.RecyclableItemsModel, error) {
+	cursor, err := repo.Collection.Find(repo.Context, bson.M{"shop_id": shopID})
+	if err != nil {
+		return nil, fmt.Errorf("error finding recyclable items of shop: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	items := []entities.RecyclableItemsModel{}
+	if err := cursor.All(repo.Context, &items); err != nil {
+		return nil, fmt.Errorf("error decoding recyclable items: %v", err)
+	}
+	return &items, nil
+}
+
+func (repo *recyclableItemsRepository) ArchiveByShopID(shopID string) error {
+	update := bson.M{"$set": bson.M{"shop_archived": true}}
+	if _, err := repo.Collection.UpdateMany(repo.Context, bson.M{"shop_id": shopID}, update); err != nil {
+		return fmt.Errorf("error archiving recyclable items: %v", err)
+	}
+	return nil
+}
+
+func (repo *recyclableItemsRepository) DeleteByShopID(shopID string) error {
+	if _, err := repo.Collection.DeleteMany(repo.Context, bson.M{"shop_id": shopID}); err != nil {
+		return fmt.Errorf("error deleting recyclable items of shop: %v", err)
+	}
+	return nil
+}
+
+func (repo *recyclableItemsRepository) CountOtherByURL(url string, excludeShopID string) (int64, error) {
+	count, err := repo.Collection.CountDocuments(repo.Context, bson.M{"url": url, "shop_id": bson.M{"$ne": excludeShopID}})
+	if err != nil {
+		return 0, fmt.Errorf("error counting recyclable items by image: %v", err)
+	}
+	return count, nil
+}
+
+func (repo *recyclableItemsRepository) MapUnmappedByNames(materialID string, names []string) (int64, error) {
+	if len(names) == 0 {
+		return 0, nil
+	}
+	patterns := bson.A{}
+	for _, name := range names {
+		patterns = append(patterns, primitive.Regex{Pattern: "^" + regexp.QuoteMeta(name) + "$", Options: "i"})
+	}
+	filter := bson.M{
+		"name": bson.M{"$in": patterns},
+		"$or":  bson.A{bson.M{"material_id": bson.M{"$exists": false}}, bson.M{"material_id": ""}},
+	}
+	result, err := repo.Collection.UpdateMany(repo.Context, filter, bson.M{"$set": bson.M{"material_id": materialID}})
+	if err != nil {
+		return 0, fmt.Errorf("error mapping recyclable items to material: %v", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+func (repo *recyclableItemsRepository) UnmapMaterial(materialID string) error {
+	update := bson.M{"$unset": bson.M{"material_id": ""}}
+	if _, err := repo.Collection.UpdateMany(repo.Context, bson.M{"material_id": materialID}, update); err != nil {
+		return fmt.Errorf("error unmapping recyclable items from material: %v", err)
+	}
+	return nil
+}
+
+func (repo *recyclableItemsRepository) RenameCategory(from, to string) (int64, error) {
+	result, err := repo.Collection.UpdateMany(repo.Context, bson.M{"category": from}, bson.M{"$set": bson.M{"category": to}})
+	if err != nil {
+		return 0, fmt.Errorf("error renaming category of recyclable items: %v", err)
+	}
+	if err := repo.refreshSearchTerms(bson.M{"category": to}); err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (repo *recyclableItemsRepository) ApplyPriceList(creates, updates []entities.RecyclableItemsModel) (int, bool, error) {
+	writes := make([]mongo.WriteModel, 0, len(creates)+len(updates))
+	for i := range creates {
+		creates[i].SearchTerms = searchTerms(creates[i].Name, creates[i].Category)
+		writes = append(writes, mongo.NewInsertOneModel().SetDocument(creates[i]))
+	}
+	for _, item := range updates {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"waste_id": item.WasteID, "shop_id": item.ShopID}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"name":         item.Name,
+				"category":     item.Category,
+				"material_id":  item.MaterialID,
+				"price":        item.Price,
+				"last_update":  item.LastUpdate,
+				"search_terms": searchTerms(item.Name, item.Category),
+			}}))
+	}
+	if len(writes) == 0 {
+		return 0, true, nil
+	}
+
+	session, err := repo.Collection.Database().Client().StartSession()
+	if err != nil {
+		return 0, true, fmt.Errorf("error starting price list session: %v", err)
+	}
+	defer session.EndSession(repo.Context)
+
+	_, err = session.WithTransaction(repo.Context, func(sc mongo.SessionContext) (interface{}, error) {
+		return repo.Collection.BulkWrite(sc, writes)
+	})
+	if err == nil {
+		return len(writes), true, nil
+	}
+	if !isStandaloneTransactionError(err) {
+		return 0, true, fmt.Errorf("error applying price list: %v", err)
+	}
+
+	fmt.Printf("Warning: MongoDB is a standalone server without transactions, applying price list without one\n")
+	result, err := repo.Collection.BulkWrite(repo.Context, writes, options.BulkWrite().SetOrdered(true))
+	if err == nil {
+		return len(writes), false, nil
+	}
+	// Ordered writes stop at the first failure, so everything before it was applied
+	written := 0
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0 {
+		written = bulkErr.WriteErrors[0].Index
+	} else if result != nil {
+		written = int(result.InsertedCount + result.MatchedCount)
+	}
+	return written, false, fmt.Errorf("error applying price list: %v", err)
+}
+
+// isStandaloneTransactionError reports whether err is the IllegalOperation a
+// standalone mongod returns for a transaction; only replica sets and sharded
+// clusters support them
+func isStandaloneTransactionError(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == 20 &&
+		strings.Contains(cmdErr.Message, "Transaction numbers are only allowed on a replica set member or mongos")
+}
+
+// searchPaginated finds the items within filter that match a search, the
+// best matching names first, and counts all matches
+func (repo *recyclableItemsRepository) searchPaginated(filter bson.M, search string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error) {
+	terms := queryTerms(search)
+	if len(terms) == 0 {
+		return &[]entities.RecyclableItemsModel{}, 0, nil
+	}
+	match := bson.M{"search_terms": bson.M{"$all": terms}}
+	for key, value := range filter {
+		match[key] = value
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$addFields", Value: bson.M{"score": searchScore("$name", normalizeQuery(search))}}},
+		{{Key: "$sort", Value: bson.D{{Key: "score", Value: -1}, {Key: "name", Value: 1}, {Key: "waste_id", Value: 1}}}},
+		{{Key: "$facet", Value: bson.M{
+			"items": bson.A{
+				bson.M{"$skip": (page - 1) * limit},
+				bson.M{"$limit": limit},
+			},
+			"total": bson.A{bson.M{"$count": "count"}},
+		}}},
+	}
+	cursor, err := repo.Collection.Aggregate(repo.Context, pipeline)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error searching recyclable items: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	var results []struct {
+		Items []entities.RecyclableItemsModel `bson:"items"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err := cursor.All(repo.Context, &results); err != nil {
+		return nil, 0, fmt.Errorf("error decoding recyclable items: %v", err)
+	}
+	items := []entities.RecyclableItemsModel{}
+	var totalCount int64
+	if len(results) > 0 {
+		items = results[0].Items
+		if len(results[0].Total) > 0 {
+			totalCount = results[0].Total[0].Count
+		}
+	}
+	return &items, totalCount, nil
+}
+
+func (repo *recyclableItemsRepository) SuggestNames(query string, limit int) ([]entities.SearchSuggestion, error) {
+	suggestions := []entities.SearchSuggestion{}
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return suggestions, nil
+	}
+	match := bson.M{"search_terms": bson.M{"$all": terms}}
+	for key, value := range publicItemsFilter {
+		match[key] = value
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         bson.M{"$toLower": "$name"},
+			"name":        bson.M{"$first": "$name"},
+			"material_id": bson.M{"$max": "$material_id"},
+			"shops":       bson.M{"$addToSet": "$shop_id"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":         0,
+			"name":        1,
+			"material_id": 1,
+			"shops":       bson.M{"$size": "$shops"},
+			"score":       searchScore("$name", normalizeQuery(query)),
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "score", Value: -1}, {Key: "shops", Value: -1}, {Key: "name", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := repo.Collection.Aggregate(repo.Context, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("error suggesting recyclable item names: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	if err := cursor.All(repo.Context, &suggestions); err != nil {
+		return nil, fmt.Errorf("error decoding name suggestions: %v", err)
+	}
+	return suggestions, nil
+}
+
+// refreshSearchTerms recomputes the search terms of the items matching filter
+func (repo *recyclableItemsRepository) refreshSearchTerms(filter bson.M) error {
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "name": 1, "category": 1})
+	cursor, err := repo.Collection.Find(repo.Context, filter, opts)
+	if err != nil {
+		return fmt.Errorf("error finding recyclable items to index: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	var items []struct {
+		ID       interface{} `bson:"_id"`
+		Name     string      `bson:"name"`
+		Category string      `bson:"category"`
+	}
+	if err := cursor.All(repo.Context, &items); err != nil {
+		return fmt.Errorf("error decoding recyclable items to index: %v", err)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(items))
+	for _, item := range items {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": item.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"search_terms": searchTerms(item.Name, item.Category)}}))
+	}
+	if _, err := repo.Collection.BulkWrite(repo.Context, writes); err != nil {
+		return fmt.Errorf("error indexing recyclable items: %v", err)
+	}
+	return nil
+}
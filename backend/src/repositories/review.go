@@ -19,6 +19,12 @@ type IReviewRepository interface {
 	GetReviewsByShopID(ctx context.Context, shopID string, page, pageSize int) ([]models.ReviewModel, int64, error)
 	CheckReviewExists(ctx context.Context, customerRequestID string) (bool, error)
 	GetShopRatingStats(ctx context.Context, shopID string) (float64, int64, error)
+	// GetRatingStatsByShopIDs returns the rating summaries of several shops keyed by shop ID
+	GetRatingStatsByShopIDs(ctx context.Context, shopIDs []string) (map[string]models.ShopRatingStats, error)
+	GetReviewsByUserID(ctx context.Context, userID string) ([]models.ReviewModel, error)
+	// AnonymizeByUserID keeps the ratings of a user for the shop averages but removes their comments
+	AnonymizeByUserID(ctx context.Context, userID string, placeholderUserID string) error
+	DeleteByShopID(ctx context.Context, shopID string) error
 }
 
 type reviewRepository struct {
@@ -162,3 +168,62 @@ func (r *reviewRepository) GetShopRatingStats(ctx context.Context, shopID string
 
 	return 0, 0, nil
 }
+
+func (r *reviewRepository) GetRatingStatsByShopIDs(ctx context.Context, shopIDs []string) (map[string]models.ShopRatingStats, error) {
+	stats := map[string]models.ShopRatingStats{}
+	if len(shopIDs) == 0 {
+		return stats, nil
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"shop_id": bson.M{"$in": shopIDs}, "is_skipped": false}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":            "$shop_id",
+			"average_rating": bson.M{"$avg": "$rating"},
+			"total_reviews":  bson.M{"$sum": 1},
+		}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []models.ShopRatingStats
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		stats[result.ShopID] = result
+	}
+	return stats, nil
+}
+
+func (r *reviewRepository) GetReviewsByUserID(ctx context.Context, userID string) ([]models.ReviewModel, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reviews := []models.ReviewModel{}
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+func (r *reviewRepository) AnonymizeByUserID(ctx context.Context, userID string, placeholderUserID string) error {
+	update := bson.M{"$set": bson.M{
+		"user_id":    placeholderUserID,
+		"comment":    "",
+		"updated_at": time.Now(),
+	}}
+	_, err := r.collection.UpdateMany(ctx, bson.M{"user_id": userID}, update)
+	return err
+}
+
+func (r *reviewRepository) DeleteByShopID(ctx context.Context, shopID string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"shop_id": shopID})
+	return err
+}
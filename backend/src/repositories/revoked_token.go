@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"os"
+	ds "recycle-waste-management-backend/src/domain/datasources"
+	"recycle-waste-management-backend/src/domain/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IRevokedTokenRepository interface {
+	Revoke(jti string, expiresAt time.Time) error
+	IsRevoked(jti string) (bool, error)
+}
+
+type revokedTokenRepository struct {
+	Collection *mongo.Collection
+	Context    context.Context
+}
+
+func NewRevokedTokenRepository(db *ds.MongoDB) IRevokedTokenRepository {
+	repo := &revokedTokenRepository{
+		Collection: db.MongoDB.Database(os.Getenv("DATABASE_NAME")).Collection("revoked_tokens"),
+		Context:    db.Context,
+	}
+
+	repo.ensureIndexes()
+
+	return repo
+}
+
+func (repo *revokedTokenRepository) ensureIndexes() {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "jti", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			// Entries are only needed until the token would have expired anyway
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	if _, err := repo.Collection.Indexes().CreateMany(repo.Context, indexModels); err != nil {
+		fmt.Printf("Warning: Could not create revoked_tokens indexes: %v\n", err)
+	}
+}
+
+func (repo *revokedTokenRepository) Revoke(jti string, expiresAt time.Time) error {
+	if jti == "" || !expiresAt.After(time.Now()) {
+		return nil
+	}
+
+	filter := bson.M{"jti": jti}
+	update := bson.M{"$set": models.RevokedToken{JTI: jti, ExpiresAt: expiresAt}}
+	opts := options.Update().SetUpsert(true)
+	if _, err := repo.Collection.UpdateOne(repo.Context, filter, update, opts); err != nil {
+		return fmt.Errorf("error revoking token: %v", err)
+	}
+	return nil
+}
+
+func (repo *revokedTokenRepository) IsRevoked(jti string) (bool, error) {
+	count, err := repo.Collection.CountDocuments(repo.Context, bson.M{"jti": jti}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("error checking revoked token: %v", err)
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Thai is written without spaces between words and MongoDB's text index
+// does not segment it, so items are searched by the character unigrams and
+// bigrams of their name and category instead. A query matches an item that
+// has every bigram of the query, so "ขวด" finds "ขวดแก้ว" and "ขวด PET".
+
+// searchWords lowercases text and splits it on anything but letters, digits
+// and combining marks, which Thai vowels and tone marks are
+func searchWords(text string) [][]rune {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r)
+	})
+	result := make([][]rune, 0, len(words))
+	for _, word := range words {
+		result = append(result, []rune(word))
+	}
+	return result
+}
+
+// searchTerms lists the unigrams and bigrams of the texts, for indexing
+func searchTerms(texts ...string) []string {
+	seen := map[string]bool{}
+	for _, text := range texts {
+		for _, word := range searchWords(text) {
+			for i := range word {
+				seen[string(word[i])] = true
+				if i+1 < len(word) {
+					seen[string(word[i:i+2])] = true
+				}
+			}
+		}
+	}
+	terms := make([]string, 0, len(seen))
+	for term := range seen {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+	return terms
+}
+
+// queryTerms lists the terms an item needs to match a query: the bigrams of
+// each word, or the letter itself for one-letter words
+func queryTerms(query string) []string {
+	seen := map[string]bool{}
+	terms := []string{}
+	add := func(term string) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	for _, word := range searchWords(query) {
+		if len(word) == 1 {
+			add(string(word))
+			continue
+		}
+		for i := 0; i+1 < len(word); i++ {
+			add(string(word[i : i+2]))
+		}
+	}
+	return terms
+}
+
+// searchScore ranks a match by how the lowercased field compares to the
+// query: 3 for the whole field, 2 for a prefix, 1 for a substring, else 0
+func searchScore(field, query string) bson.M {
+	lower := bson.M{"$toLower": field}
+	position := bson.M{"$indexOfCP": bson.A{lower, query}}
+	return bson.M{"$switch": bson.M{
+		"branches": bson.A{
+			bson.M{"case": bson.M{"$eq": bson.A{lower, query}}, "then": 3},
+			bson.M{"case": bson.M{"$eq": bson.A{position, 0}}, "then": 2},
+			bson.M{"case": bson.M{"$gt": bson.A{position, 0}}, "then": 1},
+		},
+		"default": 0,
+	}}
+}
+
+// normalizeQuery is the form of a query compared against lowercased names
+func normalizeQuery(query string) string {
+	return strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"os"
+	ds "recycle-waste-management-backend/src/domain/datasources"
+	"recycle-waste-management-backend/src/domain/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ISecurityEventRepository interface {
+	Create(event *models.SecurityEvent) error
+	GetByShopID(shopID string, page, limit int) (*[]models.SecurityEvent, int64, error)
+}
+
+type securityEventRepository struct {
+	Collection *mongo.Collection
+	Context    context.Context
+}
+
+func NewSecurityEventRepository(db *ds.MongoDB) ISecurityEventRepository {
+	repo := &securityEventRepository{
+		Collection: db.MongoDB.Database(os.Getenv("DATABASE_NAME")).Collection("security_events"),
+		Context:    db.Context,
+	}
+
+	repo.ensureIndexes()
+
+	return repo
+}
+
+func (repo *securityEventRepository) ensureIndexes() {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "shop_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			// Alerts are kept for 90 days
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(90 * 24 * 60 * 60),
+		},
+	}
+
+	if _, err := repo.Collection.Indexes().CreateMany(repo.Context, indexModels); err != nil {
+		fmt.Printf("Warning: Could not create security_events indexes: %v\n", err)
+	}
+}
+
+func (repo *securityEventRepository) Create(event *models.SecurityEvent) error {
+	if _, err := repo.Collection.InsertOne(repo.Context, event); err != nil {
+		return fmt.Errorf("error inserting security event: %v", err)
+	}
+	return nil
+}
+
+func (repo *securityEventRepository) GetByShopID(shopID string, page, limit int) (*[]models.SecurityEvent, int64, error) {
+	filter := bson.M{"shop_id": shopID}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := repo.Collection.Find(repo.Context, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error finding security events: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	events := []models.SecurityEvent{}
+	if err := cursor.All(repo.Context, &events); err != nil {
+		return nil, 0, fmt.Errorf("error decoding security events: %v", err)
+	}
+
+	total, err := repo.Collection.CountDocuments(repo.Context, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error counting security events: %v", err)
+	}
+	return &events, total, nil
+}
@@ -0,0 +1,192 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"os"
+	ds "recycle-waste-management-backend/src/domain/datasources"
+	"recycle-waste-management-backend/src/domain/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ISessionRepository interface {
+	Create(session *models.Session) error
+	GetByID(sessionID string) (*models.Session, error)
+	GetByRefreshHash(hash string) (*models.Session, error)
+	GetByPreviousHash(hash string) (*models.Session, error)
+	Rotate(sessionID string, oldHash string, newHash string, accessJTI string, accessExpiry time.Time, ip string) error
+	GetActiveBySubject(subjectID string) (*[]models.Session, error)
+	Revoke(sessionID string) error
+	RevokeAllBySubject(subjectID string) error
+	// GetAllBySubject includes revoked and expired sessions
+	GetAllBySubject(subjectID string) (*[]models.Session, error)
+	DeleteAllBySubject(subjectID string) error
+}
+
+type sessionRepository struct {
+	Collection *mongo.Collection
+	Context    context.Context
+}
+
+func NewSessionRepository(db *ds.MongoDB) ISessionRepository {
+	repo := &sessionRepository{
+		Collection: db.MongoDB.Database(os.Getenv("DATABASE_NAME")).Collection("sessions"),
+		Context:    db.Context,
+	}
+
+	repo.ensureIndexes()
+
+	return repo
+}
+
+func (repo *sessionRepository) ensureIndexes() {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "session_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "refresh_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "previous_hash", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys: bson.D{{Key: "subject_id", Value: 1}, {Key: "last_used_at", Value: -1}},
+		},
+		{
+			// Expired sessions are removed by MongoDB
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	if _, err := repo.Collection.Indexes().CreateMany(repo.Context, indexModels); err != nil {
+		fmt.Printf("Warning: Could not create sessions indexes: %v\n", err)
+	}
+}
+
+func (repo *sessionRepository) Create(session *models.Session) error {
+	if _, err := repo.Collection.InsertOne(repo.Context, session); err != nil {
+		return fmt.Errorf("error inserting session: %v", err)
+	}
+	return nil
+}
+
+func (repo *sessionRepository) GetByID(sessionID string) (*models.Session, error) {
+	var session models.Session
+	if err := repo.Collection.FindOne(repo.Context, bson.M{"session_id": sessionID}).Decode(&session); err != nil {
+		return nil, fmt.Errorf("error getting session: %v", err)
+	}
+	return &session, nil
+}
+
+func (repo *sessionRepository) GetByRefreshHash(hash string) (*models.Session, error) {
+	var session models.Session
+	if err := repo.Collection.FindOne(repo.Context, bson.M{"refresh_hash": hash}).Decode(&session); err != nil {
+		return nil, fmt.Errorf("error getting session: %v", err)
+	}
+	return &session, nil
+}
+
+func (repo *sessionRepository) GetByPreviousHash(hash string) (*models.Session, error) {
+	var session models.Session
+	if err := repo.Collection.FindOne(repo.Context, bson.M{"previous_hash": hash}).Decode(&session); err != nil {
+		return nil, fmt.Errorf("error getting session: %v", err)
+	}
+	return &session, nil
+}
+
+// Rotate swaps the refresh token of a session. It only succeeds if oldHash is
+// still current, so two concurrent refreshes with the same token cannot both win.
+func (repo *sessionRepository) Rotate(sessionID string, oldHash string, newHash string, accessJTI string, accessExpiry time.Time, ip string) error {
+	filter := bson.M{
+		"session_id":   sessionID,
+		"refresh_hash": oldHash,
+		"revoked_at":   bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{
+		"refresh_hash":  newHash,
+		"previous_hash": oldHash,
+		"access_jti":    accessJTI,
+		"access_expiry": accessExpiry,
+		"ip_address":    ip,
+		"last_used_at":  time.Now(),
+	}}
+
+	result, err := repo.Collection.UpdateOne(repo.Context, filter, update)
+	if err != nil {
+		return fmt.Errorf("error rotating session: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("session is no longer valid")
+	}
+	return nil
+}
+
+func (repo *sessionRepository) GetActiveBySubject(subjectID string) (*[]models.Session, error) {
+	filter := bson.M{
+		"subject_id": subjectID,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "last_used_at", Value: -1}})
+
+	cursor, err := repo.Collection.Find(repo.Context, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding sessions: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	sessions := []models.Session{}
+	if err := cursor.All(repo.Context, &sessions); err != nil {
+		return nil, fmt.Errorf("error decoding sessions: %v", err)
+	}
+	return &sessions, nil
+}
+
+func (repo *sessionRepository) Revoke(sessionID string) error {
+	filter := bson.M{"session_id": sessionID, "revoked_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"revoked_at": time.Now()}}
+	if _, err := repo.Collection.UpdateOne(repo.Context, filter, update); err != nil {
+		return fmt.Errorf("error revoking session: %v", err)
+	}
+	return nil
+}
+
+func (repo *sessionRepository) RevokeAllBySubject(subjectID string) error {
+	filter := bson.M{"subject_id": subjectID, "revoked_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"revoked_at": time.Now()}}
+	if _, err := repo.Collection.UpdateMany(repo.Context, filter, update); err != nil {
+		return fmt.Errorf("error revoking sessions: %v", err)
+	}
+	return nil
+}
+
+func (repo *sessionRepository) GetAllBySubject(subjectID string) (*[]models.Session, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := repo.Collection.Find(repo.Context, bson.M{"subject_id": subjectID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding sessions: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	sessions := []models.Session{}
+	if err := cursor.All(repo.Context, &sessions); err != nil {
+		return nil, fmt.Errorf("error decoding sessions: %v", err)
+	}
+	return &sessions, nil
+}
+
+func (repo *sessionRepository) DeleteAllBySubject(subjectID string) error {
+	if _, err := repo.Collection.DeleteMany(repo.Context, bson.M{"subject_id": subjectID}); err != nil {
+		return fmt.Errorf("error deleting sessions: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"os"
+	ds "recycle-waste-management-backend/src/domain/datasources"
+	"recycle-waste-management-backend/src/domain/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IShopVerificationRepository interface {
+	Create(application *models.ShopVerification) error
+	GetByID(applicationID string) (*models.ShopVerification, error)
+	GetLatestByUserID(userID string) (*models.ShopVerification, error)
+	GetByStatus(status string, page, limit int) (*[]models.ShopVerification, int64, error)
+	Review(applicationID string, status string, reviewerID string, rejectReason string) error
+	GetByUserID(userID string) (*[]models.ShopVerification, error)
+	DeleteByUserID(userID string) error
+}
+
+type shopVerificationRepository struct {
+	Collection *mongo.Collection
+	Context    context.Context
+}
+
+func NewShopVerificationRepository(db *ds.MongoDB) IShopVerificationRepository {
+	repo := &shopVerificationRepository{
+		Collection: db.MongoDB.Database(os.Getenv("DATABASE_NAME")).Collection("shop_verifications"),
+		Context:    db.Context,
+	}
+
+	repo.ensureIndexes()
+
+	return repo
+}
+
+func (repo *shopVerificationRepository) ensureIndexes() {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "application_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+		},
+		{
+			// At most one open application per user
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+				"status": models.ShopVerificationPending,
+			}).SetName("user_id_pending_unique"),
+		},
+	}
+
+	if _, err := repo.Collection.Indexes().CreateMany(repo.Context, indexModels); err != nil {
+		fmt.Printf("Warning: Could not create shop_verifications indexes: %v\n", err)
+	}
+}
+
+func (repo *shopVerificationRepository) Create(application *models.ShopVerification) error {
+	if _, err := repo.Collection.InsertOne(repo.Context, application); err != nil {
+		return fmt.Errorf("error inserting shop verification: %v", err)
+	}
+	return nil
+}
+
+func (repo *shopVerificationRepository) GetByID(applicationID string) (*models.ShopVerification, error) {
+	var application models.ShopVerification
+	if err := repo.Collection.FindOne(repo.Context, bson.M{"application_id": applicationID}).Decode(&application); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, mongo.ErrNoDocuments
+		}
+		return nil, fmt.Errorf("error finding shop verification: %v", err)
+	}
+	return &application, nil
+}
+
+func (repo *shopVerificationRepository) GetLatestByUserID(userID string) (*models.ShopVerification, error) {
+	var application models.ShopVerification
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	if err := repo.Collection.FindOne(repo.Context, bson.M{"user_id": userID}, opts).Decode(&application); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, mongo.ErrNoDocuments
+		}
+		return nil, fmt.Errorf("error finding shop verification: %v", err)
+	}
+	return &application, nil
+}
+
+// GetByStatus lists applications oldest first, so the admin queue is worked in order
+func (repo *shopVerificationRepository) GetByStatus(status string, page, limit int) (*[]models.ShopVerification, int64, error) {
+	filter := bson.M{"status": status}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := repo.Collection.Find(repo.Context, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error finding shop verifications: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	applications := []models.ShopVerification{}
+	if err := cursor.All(repo.Context, &applications); err != nil {
+		return nil, 0, fmt.Errorf("error decoding shop verifications: %v", err)
+	}
+
+	total, err := repo.Collection.CountDocuments(repo.Context, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error counting shop verifications: %v", err)
+	}
+	return &applications, total, nil
+}
+
+// Review closes a pending application. It fails with mongo.ErrNoDocuments if
+// the application does not exist or was already reviewed.
+func (repo *shopVerificationRepository) Review(applicationID string, status string, reviewerID string, rejectReason string) error {
+	now := time.Now()
+	filter := bson.M{"application_id": applicationID, "status": models.ShopVerificationPending}
+	set := bson.M{
+		"status":      status,
+		"reviewed_by": reviewerID,
+		"reviewed_at": now,
+		"updated_at":  now,
+	}
+	if rejectReason != "" {
+		set["reject_reason"] = rejectReason
+	}
+
+	result, err := repo.Collection.UpdateOne(repo.Context, filter, bson.M{"$set": set})
+	if err != nil {
+		return fmt.Errorf("error reviewing shop verification: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (repo *shopVerificationRepository) GetByUserID(userID string) (*[]models.ShopVerification, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := repo.Collection.Find(repo.Context, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding shop verifications: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	applications := []models.ShopVerification{}
+	if err := cursor.All(repo.Context, &applications); err != nil {
+		return nil, fmt.Errorf("error decoding shop verifications: %v", err)
+	}
+	return &applications, nil
+}
+
+func (repo *shopVerificationRepository) DeleteByUserID(userID string) error {
+	if _, err := repo.Collection.DeleteMany(repo.Context, bson.M{"user_id": userID}); err != nil {
+		return fmt.Errorf("error deleting shop verifications: %v", err)
+	}
+	return nil
+}
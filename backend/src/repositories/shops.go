@@ -4,6 +4,10 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	ds "recycle-waste-management-backend/src/domain/datasources"
 	"recycle-waste-management-backend/src/domain/entities"
@@ -16,10 +20,21 @@ import (
 type IShopRepository interface {
 	Create(data *entities.ShopModel) error
 	GetByShopID(shopID string) (*entities.ShopModel, error)
-	GetByUserID(userID string) (*entities.ShopModel, error)
+	GetAllByUserID(userID string) (*[]entities.ShopModel, error)
 	GetByShopCode(shopCode string) (*entities.ShopModel, error)
 	GetAll(page, limit int) (*[]entities.ShopModel, int64, error)
+	// GetOpenAt pages through the shops open on a schedule day at an HH:MM clock
+	// time and not closed on a YYYY-MM-DD date
+	GetOpenAt(day, clock, date string, page, limit int) (*[]entities.ShopModel, int64, error)
+	// FindNearby lists shops within maxDistanceMeters of a point, nearest first.
+	// A non-nil shopIDs limits the search to those shops.
+	FindNearby(latitude, longitude, maxDistanceMeters float64, shopIDs []string) (*[]entities.ShopDistance, error)
 	Update(shopID string, data *entities.ShopModel) error
+	SetVerified(shopID string, verified bool) error
+	// Archive hides a shop from public listings while keeping its records
+	Archive(shopID string, archivedAt time.Time) error
+	// CountOtherByImageURL counts other shops that use an image URL
+	CountOtherByImageURL(url string, excludeShopID string) (int64, error)
 	Delete(shopID string) error
 }
 
@@ -35,13 +50,25 @@ func NewShopRepository(db *ds.MongoDB) IShopRepository {
 		Context:    db.Context,
 	}
 
+	repo.migrateLegacyOpeningTimes()
+	repo.backfillLocations()
 	// Create unique indexes
 	repo.ensureIndexes()
 
 	return repo
 }
 
+// setLocation keeps the GeoJSON location in step with latitude/longitude
+func setLocation(data *entities.ShopModel) {
+	if data.Latitude == 0 && data.Longitude == 0 {
+		data.Location = nil
+		return
+	}
+	data.Location = entities.NewGeoPoint(data.Latitude, data.Longitude)
+}
+
 func (repo *shopRepository) Create(data *entities.ShopModel) error {
+	setLocation(data)
 	_, err := repo.Collection.InsertOne(repo.Context, data)
 	if err != nil {
 		return fmt.Errorf("error inserting shop: %v", err)
@@ -61,16 +88,20 @@ func (repo *shopRepository) GetByShopID(shopID string) (*entities.ShopModel, err
 	return &shop, nil
 }
 
-func (repo *shopRepository) GetByUserID(userID string) (*entities.ShopModel, error) {
-	var shop entities.ShopModel
-	filter := bson.M{"user_id": userID}
-	if err := repo.Collection.FindOne(repo.Context, filter).Decode(&shop); err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, mongo.ErrNoDocuments
-		}
-		return nil, fmt.Errorf("error finding shop by user ID: %v", err)
+// GetAllByUserID lists the shops of an owner, oldest first
+func (repo *shopRepository) GetAllByUserID(userID string) (*[]entities.ShopModel, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := repo.Collection.Find(repo.Context, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding shops by user ID: %v", err)
 	}
-	return &shop, nil
+	defer cursor.Close(repo.Context)
+
+	shops := []entities.ShopModel{}
+	if err := cursor.All(repo.Context, &shops); err != nil {
+		return nil, fmt.Errorf("error decoding shops: %v", err)
+	}
+	return &shops, nil
 }
 
 func (repo *shopRepository) GetByShopCode(shopCode string) (*entities.ShopModel, error) {
@@ -97,15 +128,132 @@ func (repo *shopRepository) ensureIndexes() {
 	if err != nil {
 		fmt.Printf("Warning: Could not create shop_code index: %v\n", err)
 	}
+
+	locationIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "location", Value: "2dsphere"}},
+	}
+	if _, err := repo.Collection.Indexes().CreateOne(repo.Context, locationIndex); err != nil {
+		fmt.Printf("Warning: Could not create shop location index: %v\n", err)
+	}
+
+	ownerIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}},
+	}
+	if _, err := repo.Collection.Indexes().CreateOne(repo.Context, ownerIndex); err != nil {
+		fmt.Printf("Warning: Could not create shop owner index: %v\n", err)
+	}
+}
+
+// backfillLocations adds the GeoJSON location to shops saved before it existed
+func (repo *shopRepository) backfillLocations() {
+	filter := bson.M{
+		"location":  bson.M{"$exists": false},
+		"latitude":  bson.M{"$exists": true, "$gte": -90, "$lte": 90},
+		"longitude": bson.M{"$exists": true, "$gte": -180, "$lte": 180},
+	}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"location": bson.M{
+			"type":        "Point",
+			"coordinates": bson.A{"$longitude", "$latitude"},
+		}}}},
+	}
+	if _, err := repo.Collection.UpdateMany(repo.Context, filter, update); err != nil {
+		fmt.Printf("Warning: Could not backfill shop locations: %v\n", err)
+	}
+}
+
+func (repo *shopRepository) FindNearby(latitude, longitude, maxDistanceMeters float64, shopIDs []string) (*[]entities.ShopDistance, error) {
+	geoNear := bson.M{
+		"near":          entities.NewGeoPoint(latitude, longitude),
+		"distanceField": "distance",
+		"maxDistance":   maxDistanceMeters,
+		"spherical":     true,
+	}
+	query := bson.M{"archived_at": bson.M{"$exists": false}}
+	if shopIDs != nil {
+		query["shop_id"] = bson.M{"$in": shopIDs}
+	}
+	geoNear["query"] = query
+	cursor, err := repo.Collection.Aggregate(repo.Context, mongo.Pipeline{{{Key: "$geoNear", Value: geoNear}}})
+	if err != nil {
+		return nil, fmt.Errorf("error finding nearby shops: %v", err)
+	}
+	defer cursor.Close(repo.Context)
+
+	shops := []entities.ShopDistance{}
+	if err := cursor.All(repo.Context, &shops); err != nil {
+		return nil, fmt.Errorf("error decoding nearby shops: %v", err)
+	}
+	return &shops, nil
+}
+
+var legacyClockPattern = regexp.MustCompile(`^(\d{1,2})[:.](\d{2})`)
+
+// migrateLegacyOpeningTimes turns the free-text opening_time/closing_time of
+// older shops into an every-day schedule. Values that can't be read as a
+// daytime range are left in place for the owner to re-enter.
+func (repo *shopRepository) migrateLegacyOpeningTimes() {
+	filter := bson.M{"opening_time": bson.M{"$exists": true}, "opening_hours": bson.M{"$exists": false}}
+	cursor, err := repo.Collection.Find(repo.Context, filter)
+	if err != nil {
+		fmt.Printf("Warning: Could not read legacy shop opening times: %v\n", err)
+		return
+	}
+	defer cursor.Close(repo.Context)
+
+	var legacy []struct {
+		ShopID      string `bson:"shop_id"`
+		OpeningTime string `bson:"opening_time"`
+		ClosingTime string `bson:"closing_time"`
+	}
+	if err := cursor.All(repo.Context, &legacy); err != nil {
+		fmt.Printf("Warning: Could not decode legacy shop opening times: %v\n", err)
+		return
+	}
+
+	for _, shop := range legacy {
+		open, closing := legacyClock(shop.OpeningTime), legacyClock(shop.ClosingTime)
+		if open == "" || closing == "" || open >= closing {
+			continue
+		}
+		schedule := entities.WeeklySchedule{}
+		for _, day := range entities.ScheduleDays {
+			schedule[day] = []entities.OpeningRange{{Open: open, Close: closing}}
+		}
+		update := bson.M{
+			"$set":   bson.M{"opening_hours": schedule},
+			"$unset": bson.M{"opening_time": "", "closing_time": ""},
+		}
+		if _, err := repo.Collection.UpdateOne(repo.Context, bson.M{"shop_id": shop.ShopID}, update); err != nil {
+			fmt.Printf("Warning: Could not migrate opening times of shop %s: %v\n", shop.ShopID, err)
+		}
+	}
 }
 
+// legacyClock reads times like "8:00" or "08.30 น." as "HH:MM", or returns ""
+func legacyClock(value string) string {
+	match := legacyClockPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return ""
+	}
+	hour, _ := strconv.Atoi(match[1])
+	minute, _ := strconv.Atoi(match[2])
+	if hour > 24 || minute > 59 || (hour == 24 && minute != 0) {
+		return ""
+	}
+	return fmt.Sprintf("%02d:%02d", hour, minute)
+}
+
+// publicShopsFilter leaves out archived shops
+var publicShopsFilter = bson.M{"archived_at": bson.M{"$exists": false}}
+
 func (repo *shopRepository) GetAll(page, limit int) (*[]entities.ShopModel, int64, error) {
 	skip := int64((page - 1) * limit)
 	limit64 := int64(limit)
 
 	cursor, err := repo.Collection.Find(
 		repo.Context,
-		bson.M{},
+		publicShopsFilter,
 		&options.FindOptions{
 			Skip:  &skip,
 			Limit: &limit64,
@@ -130,7 +278,7 @@ func (repo *shopRepository) GetAll(page, limit int) (*[]entities.ShopModel, int6
 	}
 
 	// Get total count
-	totalCount, err := repo.Collection.CountDocuments(repo.Context, bson.M{})
+	totalCount, err := repo.Collection.CountDocuments(repo.Context, publicShopsFilter)
 	if err != nil {
 		return nil, 0, fmt.Errorf("error counting shops: %v", err)
 	}
@@ -138,15 +286,97 @@ func (repo *shopRepository) GetAll(page, limit int) (*[]entities.ShopModel, int6
 	return &shops, totalCount, nil
 }
 
+// GetOpenAt compares the stored HH:MM and YYYY-MM-DD strings directly, which
+// order like the times and dates they hold
+func (repo *shopRepository) GetOpenAt(day, clock, date string, page, limit int) (*[]entities.ShopModel, int64, error) {
+	filter := bson.M{
+		"archived_at": bson.M{"$exists": false},
+		"opening_hours." + day: bson.M{"$elemMatch": bson.M{
+			"open":  bson.M{"$lte": clock},
+			"close": bson.M{"$gt": clock},
+		}},
+		"closures": bson.M{"$not": bson.M{"$elemMatch": bson.M{
+			"start_date": bson.M{"$lte": date},
+			"end_date":   bson.M{"$gte": date},
+		}}},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := repo.Collection.Find(repo.Context, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error finding shops open on %s at %s: %v", day, clock, err)
+	}
+	defer cursor.Close(repo.Context)
+
+	shops := []entities.ShopModel{}
+	if err := cursor.All(repo.Context, &shops); err != nil {
+		return nil, 0, fmt.Errorf("error decoding shops: %v", err)
+	}
+
+	totalCount, err := repo.Collection.CountDocuments(repo.Context, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error counting open shops: %v", err)
+	}
+	return &shops, totalCount, nil
+}
+
 func (repo *shopRepository) Update(shopID string, data *entities.ShopModel) error {
+	setLocation(data)
 	filter := bson.M{"shop_id": shopID}
 	update := bson.M{"$set": data}
+	// Empty schedules and closure lists are omitted from $set, so clear them explicitly
+	unset := bson.M{}
+	if len(data.OpeningHours) == 0 {
+		unset["opening_hours"] = ""
+	}
+	if len(data.Closures) == 0 {
+		unset["closures"] = ""
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
 	if _, err := repo.Collection.UpdateOne(repo.Context, filter, update); err != nil {
 		return fmt.Errorf("error updating shop: %v", err)
 	}
 	return nil
 }
 
+// SetVerified records the outcome of a shop verification
+func (repo *shopRepository) SetVerified(shopID string, verified bool) error {
+	filter := bson.M{"shop_id": shopID}
+	update := bson.M{"$set": bson.M{"verified": verified}, "$unset": bson.M{"verified_at": ""}}
+	if verified {
+		update = bson.M{"$set": bson.M{"verified": true, "verified_at": time.Now()}}
+	}
+	result, err := repo.Collection.UpdateOne(repo.Context, filter, update)
+	if err != nil {
+		return fmt.Errorf("error updating shop verification: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (repo *shopRepository) Archive(shopID string, archivedAt time.Time) error {
+	filter := bson.M{"shop_id": shopID}
+	update := bson.M{"$set": bson.M{"archived_at": archivedAt}}
+	if _, err := repo.Collection.UpdateOne(repo.Context, filter, update); err != nil {
+		return fmt.Errorf("error archiving shop: %v", err)
+	}
+	return nil
+}
+
+func (repo *shopRepository) CountOtherByImageURL(url string, excludeShopID string) (int64, error) {
+	count, err := repo.Collection.CountDocuments(repo.Context, bson.M{"image_url": url, "shop_id": bson.M{"$ne": excludeShopID}})
+	if err != nil {
+		return 0, fmt.Errorf("error counting shops by image: %v", err)
+	}
+	return count, nil
+}
+
 func (repo *shopRepository) Delete(shopID string) error {
 	filter := bson.M{"shop_id": shopID}
 	if _, err := repo.Collection.DeleteOne(repo.Context, filter); err != nil {
@@ -18,6 +18,9 @@ type IStockRepository interface {
 	GetStock(shopID, wasteID string) (*entities.Stock, error)
 	GetStocksByShopID(shopID string) ([]entities.Stock, error)
 	DeleteByWasteID(wasteID string) error
+	DeleteByShopID(shopID string) error
+	// RenameCategory rewrites the category snapshot of every stock entry
+	RenameCategory(from, to string) (int64, error)
 }
 
 type stockRepository struct {
@@ -103,3 +106,18 @@ func (repo *stockRepository) GetStocksByShopID(shopID string) ([]entities.Stock,
 
 	return stocks, nil
 }
+
+func (repo *stockRepository) DeleteByShopID(shopID string) error {
+	if _, err := repo.Collection.DeleteMany(repo.Context, bson.M{"shop_id": shopID}); err != nil {
+		return fmt.Errorf("error deleting stocks of shop: %v", err)
+	}
+	return nil
+}
+
+func (repo *stockRepository) RenameCategory(from, to string) (int64, error) {
+	result, err := repo.Collection.UpdateMany(repo.Context, bson.M{"category": from}, bson.M{"$set": bson.M{"category": to}})
+	if err != nil {
+		return 0, fmt.Errorf("error renaming category of stocks: %v", err)
+	}
+	return result.ModifiedCount, nil
+}
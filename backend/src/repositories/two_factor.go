@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	ds "recycle-waste-management-backend/src/domain/datasources"
+	"recycle-waste-management-backend/src/domain/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ITwoFactorRepository interface {
+	GetBySubject(subjectID string) (*models.TwoFactor, error)
+	// SavePending replaces an enrollment that has not been enabled yet
+	SavePending(twoFactor *models.TwoFactor) error
+	Enable(subjectID string, recoveryCodes []string, step int64) error
+	// UseStep records an accepted code; it fails if the step was already used
+	UseStep(subjectID string, step int64) error
+	// UseRecoveryCode removes a recovery code; it fails if the code is unknown or used
+	UseRecoveryCode(subjectID string, codeHash string) error
+	SetRecoveryCodes(subjectID string, recoveryCodes []string) error
+	Delete(subjectID string) error
+}
+
+var (
+	ErrTwoFactorNotFound = errors.New("two-factor authentication is not set up")
+	ErrTwoFactorCodeUsed = errors.New("code was already used")
+)
+
+type twoFactorRepository struct {
+	Collection *mongo.Collection
+	Context    context.Context
+}
+
+func NewTwoFactorRepository(db *ds.MongoDB) ITwoFactorRepository {
+	repo := &twoFactorRepository{
+		Collection: db.MongoDB.Database(os.Getenv("DATABASE_NAME")).Collection("two_factor"),
+		Context:    db.Context,
+	}
+
+	repo.ensureIndexes()
+
+	return repo
+}
+
+func (repo *twoFactorRepository) ensureIndexes() {
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "subject_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	if _, err := repo.Collection.Indexes().CreateOne(repo.Context, indexModel); err != nil {
+		fmt.Printf("Warning: Could not create two_factor indexes: %v\n", err)
+	}
+}
+
+func (repo *twoFactorRepository) GetBySubject(subjectID string) (*models.TwoFactor, error) {
+	var twoFactor models.TwoFactor
+	err := repo.Collection.FindOne(repo.Context, bson.M{"subject_id": subjectID}).Decode(&twoFactor)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrTwoFactorNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting two-factor settings: %v", err)
+	}
+	return &twoFactor, nil
+}
+
+func (repo *twoFactorRepository) SavePending(twoFactor *models.TwoFactor) error {
+	filter := bson.M{"subject_id": twoFactor.SubjectID, "enabled": false}
+	opts := options.Replace().SetUpsert(true)
+	if _, err := repo.Collection.ReplaceOne(repo.Context, filter, twoFactor, opts); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("two-factor authentication is already enabled")
+		}
+		return fmt.Errorf("error saving two-factor settings: %v", err)
+	}
+	return nil
+}
+
+func (repo *twoFactorRepository) Enable(subjectID string, recoveryCodes []string, step int64) error {
+	filter := bson.M{"subject_id": subjectID, "enabled": false}
+	update := bson.M{"$set": bson.M{
+		"enabled":        true,
+		"enabled_at":     time.Now(),
+		"recovery_codes": recoveryCodes,
+		"last_used_step": step,
+	}}
+
+	result, err := repo.Collection.UpdateOne(repo.Context, filter, update)
+	if err != nil {
+		return fmt.Errorf("error enabling two-factor authentication: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrTwoFactorNotFound
+	}
+	return nil
+}
+
+func (repo *twoFactorRepository) UseStep(subjectID string, step int64) error {
+	filter := bson.M{"subject_id": subjectID, "last_used_step": bson.M{"$lt": step}}
+	update := bson.M{"$set": bson.M{"last_used_step": step}}
+
+	result, err := repo.Collection.UpdateOne(repo.Context, filter, update)
+	if err != nil {
+		return fmt.Errorf("error updating two-factor settings: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrTwoFactorCodeUsed
+	}
+	return nil
+}
+
+func (repo *twoFactorRepository) UseRecoveryCode(subjectID string, codeHash string) error {
+	filter := bson.M{"subject_id": subjectID, "enabled": true, "recovery_codes": codeHash}
+	update := bson.M{"$pull": bson.M{"recovery_codes": codeHash}}
+
+	result, err := repo.Collection.UpdateOne(repo.Context, filter, update)
+	if err != nil {
+		return fmt.Errorf("error updating two-factor settings: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrTwoFactorCodeUsed
+	}
+	return nil
+}
+
+func (repo *twoFactorRepository) SetRecoveryCodes(subjectID string, recoveryCodes []string) error {
+	filter := bson.M{"subject_id": subjectID, "enabled": true}
+	update := bson.M{"$set": bson.M{"recovery_codes": recoveryCodes}}
+	if _, err := repo.Collection.UpdateOne(repo.Context, filter, update); err != nil {
+		return fmt.Errorf("error updating recovery codes: %v", err)
+	}
+	return nil
+}
+
+func (repo *twoFactorRepository) Delete(subjectID string) error {
+	if _, err := repo.Collection.DeleteOne(repo.Context, bson.M{"subject_id": subjectID}); err != nil {
+		return fmt.Errorf("error deleting two-factor settings: %v", err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 
@@ -22,18 +23,61 @@ type IUsersRepository interface {
 	InsertNewUser(data *entities.UserDataFormat) error
 	FindAll() (*[]entities.UserDataFormat, error)
 	UpdateUser(userID string, data *entities.UserDataFormat) error
-	UpdateUserJWT(userID string, jwt string) error
 	DeleteUser(userID string) error
 	GetUser(userID string) (*entities.UserDataFormat, error)
+	GetUserByPhone(phone string) (*entities.UserDataFormat, error)
+	GetUserByIdentity(provider string, providerUserID string) (*entities.UserDataFormat, error)
+	GetUsersByEmail(email string) (*[]entities.UserDataFormat, error)
+	AddIdentity(userID string, identity entities.LinkedIdentity) error
+	RemoveIdentity(userID string, provider string) error
 }
 
+// ErrIdentityAlreadyLinked is returned when the user already has an account of that provider linked
+var ErrIdentityAlreadyLinked = errors.New("an account of this provider is already linked")
+
 func NewUsersRepository(db *ds.MongoDB) IUsersRepository {
 	collection := db.MongoDB.Database(os.Getenv("DATABASE_NAME")).Collection("users")
 
-	return &usersRepository{
+	repo := &usersRepository{
 		Collection: collection,
 		Context:    db.Context,
 	}
+
+	repo.removeStoredJWTs()
+	repo.ensureIndexes()
+
+	return repo
+}
+
+func (repo *usersRepository) ensureIndexes() {
+	indexModels := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "phone", Value: 1}},
+			Options: options.Index().SetUnique(true).SetSparse(true),
+		},
+		{
+			// A provider account belongs to at most one user
+			Keys: bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.provider_user_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+				"identities.provider": bson.M{"$exists": true},
+			}),
+		},
+		{
+			Keys: bson.D{{Key: "email", Value: 1}},
+		},
+	}
+	if _, err := repo.Collection.Indexes().CreateMany(repo.Context, indexModels); err != nil {
+		fmt.Printf("Warning: Could not create users indexes: %v\n", err)
+	}
+}
+
+// removeStoredJWTs clears access tokens that older versions saved on user documents
+func (repo *usersRepository) removeStoredJWTs() {
+	filter := bson.M{"jwt": bson.M{"$exists": true}}
+	update := bson.M{"$unset": bson.M{"jwt": ""}}
+	if _, err := repo.Collection.UpdateMany(repo.Context, filter, update); err != nil {
+		fmt.Printf("Warning: Could not remove stored JWTs from users: %v\n", err)
+	}
 }
 
 func (repo *usersRepository) InsertNewUser(data *entities.UserDataFormat) error {
@@ -91,17 +135,76 @@ func (repo *usersRepository) GetUser(userID string) (*entities.UserDataFormat, e
 	var user entities.UserDataFormat
 	filter := bson.M{"user_id": userID}
 	if err := repo.Collection.FindOne(repo.Context, filter).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, mongo.ErrNoDocuments
+		}
 		return nil, fmt.Errorf("error getting user: %v", err)
 	}
 	return &user, nil
 }
 
-func (repo *usersRepository) UpdateUserJWT(userID string, jwt string) error {
+func (repo *usersRepository) GetUserByPhone(phone string) (*entities.UserDataFormat, error) {
+	var user entities.UserDataFormat
+	filter := bson.M{"phone": phone}
+	if err := repo.Collection.FindOne(repo.Context, filter).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, mongo.ErrNoDocuments
+		}
+		return nil, fmt.Errorf("error getting user by phone: %v", err)
+	}
+	return &user, nil
+}
+
+func (repo *usersRepository) GetUserByIdentity(provider string, providerUserID string) (*entities.UserDataFormat, error) {
+	var user entities.UserDataFormat
+	filter := bson.M{"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "provider_user_id": providerUserID}}}
+	if err := repo.Collection.FindOne(repo.Context, filter).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, mongo.ErrNoDocuments
+		}
+		return nil, fmt.Errorf("error getting user by identity: %v", err)
+	}
+	return &user, nil
+}
+
+func (repo *usersRepository) GetUsersByEmail(email string) (*[]entities.UserDataFormat, error) {
+	users := []entities.UserDataFormat{}
+	cursor, err := repo.Collection.Find(repo.Context, bson.M{"email": email})
+	if err != nil {
+		return nil, fmt.Errorf("error finding users by email: %v", err)
+	}
+	if err := cursor.All(repo.Context, &users); err != nil {
+		return nil, fmt.Errorf("error decoding users: %v", err)
+	}
+	return &users, nil
+}
+
+// AddIdentity attaches a provider account, one per provider. A provider
+// account linked to another user fails on the unique index.
+func (repo *usersRepository) AddIdentity(userID string, identity entities.LinkedIdentity) error {
+	filter := bson.M{"user_id": userID, "identities.provider": bson.M{"$ne": identity.Provider}}
+	update := bson.M{"$push": bson.M{"identities": identity}}
+	result, err := repo.Collection.UpdateOne(repo.Context, filter, update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("this %s account is already linked to another user", identity.Provider)
+		}
+		return fmt.Errorf("error linking identity: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		if _, err := repo.GetUser(userID); err != nil {
+			return err
+		}
+		return ErrIdentityAlreadyLinked
+	}
+	return nil
+}
+
+func (repo *usersRepository) RemoveIdentity(userID string, provider string) error {
 	filter := bson.M{"user_id": userID}
-	update := bson.M{"$set": bson.M{"jwt": jwt}}
-	opts := options.Update().SetUpsert(true)
-	if _, err := repo.Collection.UpdateOne(repo.Context, filter, update, opts); err != nil {
-		return fmt.Errorf("error updating user: %v", err)
+	update := bson.M{"$pull": bson.M{"identities": bson.M{"provider": provider}}}
+	if _, err := repo.Collection.UpdateOne(repo.Context, filter, update); err != nil {
+		return fmt.Errorf("error unlinking identity: %v", err)
 	}
 	return nil
 }
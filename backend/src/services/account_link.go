@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/repositories"
+	"strings"
+)
+
+var (
+	ErrIdentityNotLinked  = errors.New("this provider is not linked to your account")
+	ErrLastLoginMethod    = errors.New("cannot unlink your only way to log in")
+	ErrIdentityLinkedElse = errors.New("this provider account is already linked to another user")
+	ErrReloginBeforeLink  = errors.New("please log in again before linking accounts")
+)
+
+func (s *authService) GetIdentities(userID string) ([]entities.LinkedIdentity, error) {
+	user, err := s.UserRepo.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Identities == nil {
+		return []entities.LinkedIdentity{}, nil
+	}
+	return user.Identities, nil
+}
+
+// StartLink returns the provider login URL that links the account to userID
+func (s *authService) StartLink(userID string, providerName string) (string, error) {
+	if err := s.checkCanLink(userID); err != nil {
+		return "", err
+	}
+	return s.startOAuth(providerName, userID)
+}
+
+// LinkGoogle links a Firebase Authentication (Google) user to userID. The UID
+// is taken from a verified ID token, which proves the caller signed in as that user.
+func (s *authService) LinkGoogle(userID string, idToken string) error {
+	if err := s.checkCanLink(userID); err != nil {
+		return err
+	}
+	uid, err := s.Firebase.VerifyIDToken(idToken)
+	if err != nil {
+		return err
+	}
+	identity, err := s.googleIdentity(uid)
+	if err != nil {
+		return err
+	}
+	return s.linkIdentity(userID, *identity)
+}
+
+// ConfirmLink links the provider account behind a link token, issued at login
+// after an email match, to the logged-in user
+func (s *authService) ConfirmLink(userID string, linkToken string) error {
+	if err := s.checkCanLink(userID); err != nil {
+		return err
+	}
+	identity, err := s.consumeLinkToken(linkToken)
+	if err != nil {
+		return err
+	}
+	return s.linkIdentity(userID, *identity)
+}
+
+// DeclineLink keeps the provider account behind a link token separate: it
+// creates a new user for it and logs in
+func (s *authService) DeclineLink(linkToken string, client entities.ClientInfo) (*entities.LoginResult, error) {
+	identity, err := s.consumeLinkToken(linkToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.findUserByIdentity(*identity)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		if user, err = s.createIdentityUser(*identity); err != nil {
+			return nil, err
+		}
+	}
+	return s.TwoFactor.Login(entities.Actor{ID: user.UserID, Type: entities.ActorTypeUser}, client)
+}
+
+func (s *authService) UnlinkIdentity(userID string, provider string) error {
+	user, err := s.UserRepo.GetUser(userID)
+	if err != nil {
+		return err
+	}
+
+	linked := false
+	for _, identity := range user.Identities {
+		if identity.Provider == provider {
+			linked = true
+			break
+		}
+	}
+	if !linked {
+		return ErrIdentityNotLinked
+	}
+
+	remaining := len(user.Identities) - 1
+	if user.Phone != "" {
+		remaining++
+	}
+	if remaining < 1 {
+		return ErrLastLoginMethod
+	}
+	return s.UserRepo.RemoveIdentity(userID, provider)
+}
+
+func (s *authService) consumeLinkToken(linkToken string) (*entities.OAuthIdentity, error) {
+	if linkToken == "" {
+		return nil, ErrInvalidOAuthState
+	}
+	identity, err := s.OAuthStateRepo.ConsumePendingLink(linkToken)
+	if err != nil {
+		if errors.Is(err, repositories.ErrOAuthStateNotFound) {
+			return nil, ErrInvalidOAuthState
+		}
+		return nil, err
+	}
+	return identity, nil
+}
+
+// checkCanLink refuses users created before account linking that have not
+// logged in since: their own provider is not recorded yet, and linking another
+// one would stop them from being migrated.
+func (s *authService) checkCanLink(userID string) error {
+	user, err := s.UserRepo.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	if len(user.Identities) == 0 && user.Phone == "" {
+		return ErrReloginBeforeLink
+	}
+	return nil
+}
+
+func (s *authService) linkIdentity(userID string, identity entities.OAuthIdentity) error {
+	owner, err := s.findUserByIdentity(identity)
+	if err != nil {
+		return err
+	}
+	if owner != nil {
+		if owner.UserID == userID {
+			return nil
+		}
+		return ErrIdentityLinkedElse
+	}
+
+	if err := s.UserRepo.AddIdentity(userID, newLinkedIdentity(identity)); err != nil {
+		if errors.Is(err, repositories.ErrIdentityAlreadyLinked) {
+			return fmt.Errorf("a %s account is already linked, unlink it first", identity.Provider)
+		}
+		return err
+	}
+	return nil
+}
+
+// maskEmail hides most of the local part, e.g. somchai@gmail.com -> so*****@gmail.com
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	local := []rune(email[:at])
+	visible := 2
+	if len(local) <= visible {
+		visible = 1
+	}
+	if len(local) <= 1 {
+		return strings.Repeat("*", len(local)) + email[at:]
+	}
+	return string(local[:visible]) + strings.Repeat("*", len(local)-visible) + email[at:]
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/repositories"
+)
+
+// ErrActiveShopRequired is returned when an owner of several shops did not say which one they act for
+var ErrActiveShopRequired = errors.New("you own several shops - choose one with the " + entities.ActiveShopHeader + " header")
+
+// ResolveActingShop returns the shop the actor works for. Employees and API
+// keys carry it with them. Users act for the shop chosen with the X-Shop-ID
+// header, which they must own, or for their only shop. Archived shops are skipped.
+func ResolveActingShop(actor entities.Actor, shopRepo repositories.IShopRepository) (string, error) {
+	if actor.IsShopBound() {
+		if actor.ShopID == "" {
+			return "", fmt.Errorf("employee is not assigned to a shop")
+		}
+		return actor.ShopID, nil
+	}
+
+	if actor.ActiveShopID != "" {
+		shop, err := shopRepo.GetByShopID(actor.ActiveShopID)
+		if err != nil {
+			return "", fmt.Errorf("active shop not found")
+		}
+		if shop.UserID != actor.ID && !actor.HasPermission(entities.PermissionShopManageAny) {
+			return "", fmt.Errorf("you don't own the active shop")
+		}
+		if shop.ArchivedAt != nil {
+			return "", fmt.Errorf("the active shop is archived")
+		}
+		return shop.ShopID, nil
+	}
+
+	shops, err := shopRepo.GetAllByUserID(actor.ID)
+	if err != nil {
+		return "", err
+	}
+	// Archived shops keep their records but can't be acted for
+	active := []string{}
+	for _, shop := range *shops {
+		if shop.ArchivedAt == nil {
+			active = append(active, shop.ShopID)
+		}
+	}
+	switch len(active) {
+	case 0:
+		return "", fmt.Errorf("user does not own a shop")
+	case 1:
+		return active[0], nil
+	default:
+		return "", ErrActiveShopRequired
+	}
+}
+
+// requireEmployeePermission fails for employees missing the permission; users pass through
+func requireEmployeePermission(actor entities.Actor, permission string) error {
+	if actor.IsEmployee() && !actor.HasPermission(permission) {
+		return fmt.Errorf("employee lacks permission %s", permission)
+	}
+	return nil
+}
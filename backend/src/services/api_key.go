@@ -0,0 +1,152 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/domain/models"
+	"recycle-waste-management-backend/src/repositories"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	apiKeyTokenPrefix      = "rwm_"
+	apiKeyDefaultLifetime  = 90
+	apiKeyMaxLifetime      = 365
+	apiKeyLastUsedInterval = time.Minute
+)
+
+var (
+	ErrInvalidAPIKey  = errors.New("invalid or expired api key")
+	ErrAPIKeyNotFound = errors.New("api key not found")
+)
+
+type IAPIKeyService interface {
+	CreateAPIKey(shopID string, createdBy string, req entities.CreateAPIKeyRequest) (*CreatedAPIKeyResponse, error)
+	GetAPIKeys(shopID string) (*[]models.APIKey, error)
+	RevokeAPIKey(shopID string, keyID string) error
+	// Authenticate resolves the actor behind a key presented in an ApiKey header
+	Authenticate(key string) (entities.Actor, error)
+}
+
+// CreatedAPIKeyResponse is the only time the full key is shown
+type CreatedAPIKeyResponse struct {
+	Key    string         `json:"key"`
+	APIKey *models.APIKey `json:"api_key"`
+}
+
+type apiKeyService struct {
+	APIKeyRepo repositories.IAPIKeyRepository
+}
+
+func NewAPIKeyService(apiKeyRepo repositories.IAPIKeyRepository) IAPIKeyService {
+	return &apiKeyService{
+		APIKeyRepo: apiKeyRepo,
+	}
+}
+
+func (s *apiKeyService) CreateAPIKey(shopID string, createdBy string, req entities.CreateAPIKeyRequest) (*CreatedAPIKeyResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if len(req.Scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range req.Scopes {
+		if !entities.IsAPIKeyScope(scope) {
+			return nil, fmt.Errorf("invalid scope: %s", scope)
+		}
+	}
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = apiKeyDefaultLifetime
+	}
+	if days < 1 || days > apiKeyMaxLifetime {
+		return nil, fmt.Errorf("expires_in_days must be between 1 and %d", apiKeyMaxLifetime)
+	}
+
+	// Key format: rwm_<8 hex prefix>_<secret>; the prefix stays visible in listings
+	prefixBytes := make([]byte, 4)
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return nil, fmt.Errorf("error generating api key: %v", err)
+	}
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, fmt.Errorf("error generating api key: %v", err)
+	}
+	prefix := apiKeyTokenPrefix + hex.EncodeToString(prefixBytes)
+	key := prefix + "_" + base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	now := time.Now()
+	apiKey := &models.APIKey{
+		KeyID:     uuid.New().String(),
+		ShopID:    shopID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hashAPIKey(key),
+		Scopes:    req.Scopes,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		ExpiresAt: now.AddDate(0, 0, days),
+	}
+	if err := s.APIKeyRepo.Create(apiKey); err != nil {
+		return nil, err
+	}
+	return &CreatedAPIKeyResponse{Key: key, APIKey: apiKey}, nil
+}
+
+func (s *apiKeyService) GetAPIKeys(shopID string) (*[]models.APIKey, error) {
+	return s.APIKeyRepo.GetByShopID(shopID)
+}
+
+func (s *apiKeyService) RevokeAPIKey(shopID string, keyID string) error {
+	if err := s.APIKeyRepo.Revoke(shopID, keyID); err != nil {
+		if errors.Is(err, repositories.ErrAPIKeyNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *apiKeyService) Authenticate(key string) (entities.Actor, error) {
+	if !strings.HasPrefix(key, apiKeyTokenPrefix) {
+		return entities.Actor{}, ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.APIKeyRepo.GetByHash(hashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, repositories.ErrAPIKeyNotFound) {
+			return entities.Actor{}, ErrInvalidAPIKey
+		}
+		return entities.Actor{}, err
+	}
+	if apiKey.RevokedAt != nil || time.Now().After(apiKey.ExpiresAt) {
+		return entities.Actor{}, ErrInvalidAPIKey
+	}
+
+	if err := s.APIKeyRepo.TouchLastUsed(apiKey.KeyID, apiKeyLastUsedInterval); err != nil {
+		log.Printf("[APIKey] Could not record use of %s: %v", apiKey.Prefix, err)
+	}
+
+	return entities.Actor{
+		ID:          apiKey.KeyID,
+		Type:        entities.ActorTypeAPIKey,
+		ShopID:      apiKey.ShopID,
+		Permissions: apiKey.Scopes,
+	}, nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/domain/models"
+	"recycle-waste-management-backend/src/repositories"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+)
+
+const (
+	auditAppendRetries = 5
+	auditVerifyBatch   = 500
+)
+
+// auditRedactedFields never have their values written to the audit log; a
+// change to them is recorded without the values
+var auditRedactedFields = map[string]bool{
+	"password": true,
+	"key_hash": true,
+	"secret":   true,
+}
+
+type IAuditService interface {
+	// Record appends an event. Failures are logged rather than returned so an
+	// audit outage does not undo the change being audited.
+	Record(meta entities.RequestMeta, record entities.AuditRecord)
+	GetEvents(query entities.AuditQuery, page, limit int) (*[]models.AuditEvent, int64, error)
+	// Verify recomputes the hash chain from the first event
+	Verify() (*entities.AuditVerifyResult, error)
+}
+
+type auditService struct {
+	AuditRepo repositories.IAuditEventRepository
+	// Appends from this process are serialized; the unique sequence index
+	// handles other replicas
+	appendMutex sync.Mutex
+}
+
+func NewAuditService(auditRepo repositories.IAuditEventRepository) IAuditService {
+	return &auditService{
+		AuditRepo: auditRepo,
+	}
+}
+
+func (s *auditService) Record(meta entities.RequestMeta, record entities.AuditRecord) {
+	changes, err := auditDiff(record.Before, record.After)
+	if err != nil {
+		log.Printf("[Audit] Could not diff %s %s: %v", record.Action, record.TargetID, err)
+		return
+	}
+
+	event := &models.AuditEvent{
+		EventID:    uuid.New().String(),
+		Action:     record.Action,
+		ShopID:     record.ShopID,
+		ActorID:    meta.Actor.ID,
+		ActorType:  meta.Actor.Type,
+		IPAddress:  meta.IPAddress,
+		TargetType: record.TargetType,
+		TargetID:   record.TargetID,
+		Changes:    changes,
+		// MongoDB keeps milliseconds; hash what will be read back
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	if err := s.append(event); err != nil {
+		log.Printf("[Audit] Could not record %s %s: %v", record.Action, record.TargetID, err)
+	}
+}
+
+// append links the event to the current end of the chain, retrying when
+// another writer extended the chain in the meantime
+func (s *auditService) append(event *models.AuditEvent) error {
+	s.appendMutex.Lock()
+	defer s.appendMutex.Unlock()
+
+	for attempt := 0; attempt < auditAppendRetries; attempt++ {
+		last, err := s.AuditRepo.GetLast()
+		if err != nil {
+			return err
+		}
+		event.Sequence, event.PrevHash = 1, ""
+		if last != nil {
+			event.Sequence, event.PrevHash = last.Sequence+1, last.Hash
+		}
+		if event.Hash, err = auditHash(event); err != nil {
+			return err
+		}
+
+		err = s.AuditRepo.Append(event)
+		if !errors.Is(err, repositories.ErrAuditSequenceTaken) {
+			return err
+		}
+	}
+	return fmt.Errorf("audit chain is busy")
+}
+
+func (s *auditService) GetEvents(query entities.AuditQuery, page, limit int) (*[]models.AuditEvent, int64, error) {
+	return s.AuditRepo.Find(query, page, limit)
+}
+
+func (s *auditService) Verify() (*entities.AuditVerifyResult, error) {
+	result := &entities.AuditVerifyResult{Valid: true}
+	expected := int64(1)
+	prevHash := ""
+
+	for {
+		events, err := s.AuditRepo.GetFromSequence(expected, auditVerifyBatch)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range *events {
+			switch {
+			case event.Sequence != expected:
+				return brokenAudit(result, expected, "event is missing"), nil
+			case event.PrevHash != prevHash:
+				return brokenAudit(result, event.Sequence, "event does not follow the previous one"), nil
+			}
+			hash, err := auditHash(&event)
+			if err != nil {
+				return nil, err
+			}
+			if hash != event.Hash {
+				return brokenAudit(result, event.Sequence, "event was modified"), nil
+			}
+
+			result.Checked++
+			prevHash = event.Hash
+			expected++
+		}
+		if len(*events) < auditVerifyBatch {
+			return result, nil
+		}
+	}
+}
+
+func brokenAudit(result *entities.AuditVerifyResult, sequence int64, reason string) *entities.AuditVerifyResult {
+	result.Valid = false
+	result.BrokenAt = sequence
+	result.Reason = reason
+	return result
+}
+
+// auditHash covers every field of the event except the hash itself
+func auditHash(event *models.AuditEvent) (string, error) {
+	input := struct {
+		Sequence   int64                `json:"sequence"`
+		EventID    string               `json:"event_id"`
+		Action     string               `json:"action"`
+		ShopID     string               `json:"shop_id"`
+		ActorID    string               `json:"actor_id"`
+		ActorType  string               `json:"actor_type"`
+		IPAddress  string               `json:"ip_address"`
+		TargetType string               `json:"target_type"`
+		TargetID   string               `json:"target_id"`
+		Changes    []models.AuditChange `json:"changes"`
+		CreatedAt  string               `json:"created_at"`
+		PrevHash   string               `json:"prev_hash"`
+	}{
+		Sequence:   event.Sequence,
+		EventID:    event.EventID,
+		Action:     event.Action,
+		ShopID:     event.ShopID,
+		ActorID:    event.ActorID,
+		ActorType:  event.ActorType,
+		IPAddress:  event.IPAddress,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		Changes:    event.Changes,
+		CreatedAt:  event.CreatedAt.UTC().Format(time.RFC3339Nano),
+		PrevHash:   event.PrevHash,
+	}
+	if len(input.Changes) == 0 {
+		input.Changes = nil
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("error encoding audit event: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// auditDiff returns the top-level JSON fields that differ between two
+// snapshots, sorted by field name. Either snapshot may be nil.
+func auditDiff(before interface{}, after interface{}) ([]models.AuditChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for name := range beforeFields {
+		names[name] = true
+	}
+	for name := range afterFields {
+		names[name] = true
+	}
+
+	changes := []models.AuditChange{}
+	for name := range names {
+		oldValue, newValue := beforeFields[name], afterFields[name]
+		if bytes.Equal(oldValue, newValue) {
+			continue
+		}
+		change := models.AuditChange{Field: name}
+		if !auditRedactedFields[name] {
+			change.Before = string(oldValue)
+			change.After = string(newValue)
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+func auditFields(snapshot interface{}) (map[string]json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if snapshot == nil {
+		return fields, nil
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(data, []byte("null")) {
+		return fields, nil
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
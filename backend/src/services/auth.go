@@ -1,132 +1,279 @@
 package services
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"os"
 	"recycle-waste-management-backend/src/domain/entities"
 	"recycle-waste-management-backend/src/infrastructure/httpclients"
 	"recycle-waste-management-backend/src/infrastructure/providers"
-	"recycle-waste-management-backend/src/middlewares"
 	"recycle-waste-management-backend/src/repositories"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+const (
+	oauthStateTTL  = 10 * time.Minute
+	pendingLinkTTL = 15 * time.Minute
+)
+
+var (
+	ErrUnknownOAuthProvider = errors.New("unknown login provider")
+	ErrInvalidOAuthState    = errors.New("login session expired, please try again")
+)
+
 type authService struct {
-	authGithub httpclients.IAuthGithub
-	UserRepo   repositories.IUsersRepository
-	Firebase   providers.IFirebaseProvider
+	OAuthProviders map[string]httpclients.IOAuthProvider
+	OAuthStateRepo repositories.IOAuthStateRepository
+	UserRepo       repositories.IUsersRepository
+	Firebase       providers.IFirebaseProvider
+	TwoFactor      ITwoFactorService
+	OTPRepo        repositories.IOTPRepository
+	SMSSender      providers.ISMSSender
 }
 
 type IAuthService interface {
-	AuthGithub(code string) (entities.TokenResponseGithub, error)
-	AuthGoogle(uid string) (entities.TokenResponseGoogle, error)
+	StartOAuth(provider string) (string, error)
+	AuthOAuth(provider string, code string, state string, client entities.ClientInfo) (*entities.LoginResult, error)
+	// AuthGoogle logs in with the Google account a Firebase ID token was issued to
+	AuthGoogle(idToken string, client entities.ClientInfo) (*entities.LoginResult, error)
+	GetIdentities(userID string) ([]entities.LinkedIdentity, error)
+	StartLink(userID string, provider string) (string, error)
+	// LinkGoogle links the Google account a Firebase ID token was issued to
+	LinkGoogle(userID string, idToken string) error
+	ConfirmLink(userID string, linkToken string) error
+	DeclineLink(linkToken string, client entities.ClientInfo) (*entities.LoginResult, error)
+	UnlinkIdentity(userID string, provider string) error
+	RequestPhoneOTP(phone string) (*entities.PhoneOTPResponse, error)
+	VerifyPhoneOTP(phone string, code string, client entities.ClientInfo) (*entities.LoginResult, error)
 }
 
-func NewAuthService(userRepo repositories.IUsersRepository) IAuthService {
+func NewAuthService(userRepo repositories.IUsersRepository, twoFactor ITwoFactorService, otpRepo repositories.IOTPRepository, smsSender providers.ISMSSender, oauthStateRepo repositories.IOAuthStateRepository) IAuthService {
+	oauthProviders := map[string]httpclients.IOAuthProvider{
+		entities.OAuthProviderGithub: httpclients.NewAuthGithub(),
+	}
+	if os.Getenv("LINE_CHANNEL_ID") != "" {
+		oauthProviders[entities.OAuthProviderLine] = httpclients.NewAuthLineFromEnv()
+	}
+
 	return &authService{
-		UserRepo:   userRepo,
-		authGithub: httpclients.NewAuthGithub(),
-		Firebase:   providers.NewFirebaseProvider(),
+		OAuthProviders: oauthProviders,
+		OAuthStateRepo: oauthStateRepo,
+		UserRepo:       userRepo,
+		Firebase:       providers.NewFirebaseProvider(),
+		TwoFactor:      twoFactor,
+		OTPRepo:        otpRepo,
+		SMSSender:      smsSender,
 	}
 }
 
-func (s *authService) AuthGithub(code string) (entities.TokenResponseGithub, error) {
-	data, err := s.authGithub.GitHubAccessToken(code)
-	if err != nil {
-		return data, err
+// StartOAuth returns the provider login URL for a new login, remembering its state and nonce
+func (s *authService) StartOAuth(providerName string) (string, error) {
+	return s.startOAuth(providerName, "")
+}
+
+func (s *authService) startOAuth(providerName string, linkUserID string) (string, error) {
+	provider, ok := s.OAuthProviders[providerName]
+	if !ok {
+		return "", ErrUnknownOAuthProvider
 	}
-	user, err := s.authGithub.GetUserGithub(data.AccessToken)
+
+	state, err := randomToken()
 	if err != nil {
-		return data, err
+		return "", err
 	}
-
-	var userData entities.UserDataFormat
-
-	token, err := middlewares.GenerateJWTToken(user.UserID)
+	nonce, err := randomToken()
 	if err != nil {
-		return data, err
+		return "", err
+	}
+	data := entities.OAuthState{Nonce: nonce, LinkUserID: linkUserID}
+	if err := s.OAuthStateRepo.Save(providerName, state, data, oauthStateTTL); err != nil {
+		return "", err
 	}
-	data.JWT = *token.Token
+	return provider.AuthorizeURL(state, nonce), nil
+}
 
-	go func() {
+// AuthOAuth completes a provider login: it exchanges the code and resolves the
+// account. If the login was started by StartLink the account is linked to that
+// user instead of logging in.
+func (s *authService) AuthOAuth(providerName string, code string, state string, client entities.ClientInfo) (*entities.LoginResult, error) {
+	provider, ok := s.OAuthProviders[providerName]
+	if !ok {
+		return nil, ErrUnknownOAuthProvider
+	}
 
-		userOld, err := s.UserRepo.GetUser(user.UserID)
-		if err == nil && mongo.ErrNoDocuments != err {
-			fmt.Println("User already exists")
+	data := &entities.OAuthState{}
+	if state != "" {
+		var err error
+		data, err = s.OAuthStateRepo.Consume(providerName, state)
+		if err != nil {
+			if errors.Is(err, repositories.ErrOAuthStateNotFound) {
+				return nil, ErrInvalidOAuthState
+			}
+			return nil, err
 		}
+	} else if provider.RequiresState() {
+		return nil, ErrInvalidOAuthState
+	}
 
-		if userOld == nil || user.UserID == "" {
-			if user.UserID != "" {
-				userData = entities.UserDataFormat{
-					UserID:    user.UserID,
-					Username:  user.Username,
-					Email:     user.Email,
-					ImageURL:  user.ImageURL,
-					JWT:       *token.Token,
-					CreatedAt: time.Now().UTC().Add(7 * time.Hour),
-					LastLogin: time.Now().UTC().Add(7 * time.Hour),
-				}
-			}
+	token, err := provider.ExchangeCode(code)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := provider.GetIdentity(token, data.Nonce)
+	if err != nil {
+		return nil, err
+	}
 
-			if err := s.UserRepo.InsertNewUser(&userData); err != nil {
-				fmt.Println(err)
-			}
-		} else {
-			userOld.LastLogin = time.Now().UTC().Add(7 * time.Hour)
-			userOld.JWT = *token.Token
-			if err := s.UserRepo.UpdateUser(user.UserID, userOld); err != nil {
-				fmt.Println(err)
-			}
+	if data.LinkUserID != "" {
+		if err := s.linkIdentity(data.LinkUserID, *identity); err != nil {
+			return nil, err
 		}
+		return &entities.LoginResult{Linked: true, Provider: providerName}, nil
+	}
+	return s.loginWithIdentity(*identity, client)
+}
 
-	}()
-	return data, nil
+// AuthGoogle logs in with a Firebase Authentication (Google) user. As with
+// LinkGoogle, the UID comes from a verified ID token, never from the client.
+func (s *authService) AuthGoogle(idToken string, client entities.ClientInfo) (*entities.LoginResult, error) {
+	uid, err := s.Firebase.VerifyIDToken(idToken)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := s.googleIdentity(uid)
+	if err != nil {
+		return nil, err
+	}
+	return s.loginWithIdentity(*identity, client)
 }
 
-func (s *authService) AuthGoogle(uid string) (entities.TokenResponseGoogle, error) {
-	var userNew entities.UserDataFormat
+func (s *authService) googleIdentity(uid string) (*entities.OAuthIdentity, error) {
 	userData, err := s.Firebase.GetUserFirebaseAuth(uid)
 	if err != nil {
-		return entities.TokenResponseGoogle{}, err
+		return nil, err
+	}
+	if userData.UID == "" {
+		return nil, fmt.Errorf("firebase user has no uid")
 	}
+	return &entities.OAuthIdentity{
+		Provider:       entities.OAuthProviderGoogle,
+		ProviderUserID: userData.UID,
+		Username:       strings.Split(userData.Email, "@")[0],
+		Email:          userData.Email,
+		ImageURL:       userData.PhotoURL,
+	}, nil
+}
 
-	token, err := middlewares.GenerateJWTToken(userData.UID)
+// loginWithIdentity opens a session for the canonical user behind a provider
+// account. Unknown accounts whose email belongs to an existing user get a
+// link token instead, so the person can decide whether it is the same account.
+func (s *authService) loginWithIdentity(identity entities.OAuthIdentity, client entities.ClientInfo) (*entities.LoginResult, error) {
+	user, err := s.findUserByIdentity(identity)
 	if err != nil {
-		return entities.TokenResponseGoogle{}, err
+		return nil, err
 	}
-	go func() {
 
-		userOld, err := s.UserRepo.GetUser(userData.UID)
-		if err == nil && mongo.ErrNoDocuments != err {
-			fmt.Println("User already exists")
+	if user == nil && identity.Email != "" {
+		matches, err := s.UserRepo.GetUsersByEmail(strings.ToLower(identity.Email))
+		if err != nil {
+			return nil, err
 		}
-		username := strings.Split(userData.Email, "@")[0]
-		if userOld == nil || userData.UID == "" {
-			if userData.UID != "" {
-				userNew = entities.UserDataFormat{
-					UserID:    userData.UID,
-					Username:  username,
-					Email:     userData.Email,
-					ImageURL:  userData.PhotoURL,
-					JWT:       *token.Token,
-					CreatedAt: time.Now().UTC().Add(7 * time.Hour),
-					LastLogin: time.Now().UTC().Add(7 * time.Hour),
-				}
-			}
-
-			if err := s.UserRepo.InsertNewUser(&userNew); err != nil {
-				fmt.Println(err)
+		if len(*matches) > 0 {
+			linkToken, err := randomToken()
+			if err != nil {
+				return nil, err
 			}
-		} else {
-			userOld.LastLogin = time.Now().UTC().Add(7 * time.Hour)
-			userOld.JWT = *token.Token
-			if err := s.UserRepo.UpdateUser(userData.UID, userOld); err != nil {
-				fmt.Println(err)
+			if err := s.OAuthStateRepo.SavePendingLink(linkToken, identity, pendingLinkTTL); err != nil {
+				return nil, err
 			}
+			return &entities.LoginResult{
+				LinkToken:    linkToken,
+				Provider:     identity.Provider,
+				MatchedEmail: maskEmail(identity.Email),
+			}, nil
+		}
+	}
+
+	if user == nil {
+		if user, err = s.createIdentityUser(identity); err != nil {
+			return nil, err
+		}
+	} else if err := s.UserRepo.UpdateUser(user.UserID, &entities.UserDataFormat{LastLogin: time.Now().UTC().Add(7 * time.Hour)}); err != nil {
+		return nil, err
+	}
+
+	return s.TwoFactor.Login(entities.Actor{ID: user.UserID, Type: entities.ActorTypeUser}, client)
+}
+
+// findUserByIdentity returns the user a provider account is linked to, or nil.
+// Users created before account linking were keyed by the provider ID itself;
+// they are migrated by attaching the identity on their first login.
+func (s *authService) findUserByIdentity(identity entities.OAuthIdentity) (*entities.UserDataFormat, error) {
+	user, err := s.UserRepo.GetUserByIdentity(identity.Provider, identity.ProviderUserID)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	legacy, err := s.UserRepo.GetUser(identity.ProviderUserID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(legacy.Identities) > 0 {
+		// Already migrated and this provider was unlinked since
+		return nil, nil
+	}
+	if err := s.UserRepo.AddIdentity(legacy.UserID, newLinkedIdentity(identity)); err != nil {
+		return nil, err
+	}
+	return legacy, nil
+}
+
+func (s *authService) createIdentityUser(identity entities.OAuthIdentity) (*entities.UserDataFormat, error) {
+	now := time.Now().UTC().Add(7 * time.Hour)
+	user := &entities.UserDataFormat{
+		UserID:     uuid.New().String(),
+		Username:   identity.Username,
+		Email:      strings.ToLower(identity.Email),
+		ImageURL:   identity.ImageURL,
+		Identities: []entities.LinkedIdentity{newLinkedIdentity(identity)},
+		CreatedAt:  now,
+		LastLogin:  now,
+	}
+	if err := s.UserRepo.InsertNewUser(user); err != nil {
+		// A concurrent login created the user first
+		if existing, getErr := s.UserRepo.GetUserByIdentity(identity.Provider, identity.ProviderUserID); getErr == nil {
+			return existing, nil
 		}
+		return nil, err
+	}
+	return user, nil
+}
 
-	}()
-	return entities.TokenResponseGoogle{JWT: *token.Token}, nil
+func newLinkedIdentity(identity entities.OAuthIdentity) entities.LinkedIdentity {
+	return entities.LinkedIdentity{
+		Provider:       identity.Provider,
+		ProviderUserID: identity.ProviderUserID,
+		Email:          strings.ToLower(identity.Email),
+		LinkedAt:       time.Now(),
+	}
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating random token: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
 }
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/repositories"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	otpCodeLength      = 6
+	otpTTL             = 5 * time.Minute
+	otpMaxAttempts     = 5
+	otpResendCooldown  = 60 * time.Second
+	otpMaxSendsPerHour = 5
+)
+
+var (
+	ErrInvalidPhone        = errors.New("invalid phone number")
+	ErrOTPCooldown         = errors.New("please wait before requesting another code")
+	ErrOTPRateLimited      = errors.New("too many codes requested, try again later")
+	ErrOTPInvalid          = errors.New("code is invalid or expired")
+	ErrOTPTooManyAttempts  = errors.New("too many incorrect attempts, request a new code")
+	e164Pattern            = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+	phoneSeparatorReplacer = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "")
+)
+
+// normalizePhone converts a phone number to E.164. Local Thai numbers
+// (e.g. 081-234-5678) are assumed when no country code is given.
+func normalizePhone(phone string) (string, error) {
+	phone = phoneSeparatorReplacer.Replace(strings.TrimSpace(phone))
+	switch {
+	case strings.HasPrefix(phone, "00"):
+		phone = "+" + phone[2:]
+	case strings.HasPrefix(phone, "0"):
+		phone = "+66" + phone[1:]
+	case strings.HasPrefix(phone, "66"):
+		phone = "+" + phone
+	}
+	if !e164Pattern.MatchString(phone) {
+		return "", ErrInvalidPhone
+	}
+	return phone, nil
+}
+
+// hashOTP binds the code to the phone number so a leaked hash cannot be replayed for another number
+func hashOTP(phone string, code string) string {
+	secret := os.Getenv("OTP_HASH_SECRET")
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET_KEY")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(phone + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func generateOTP() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < otpCodeLength; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", otpCodeLength, n.Int64()), nil
+}
+
+// RequestPhoneOTP sends a login code by SMS. The response is the same whether
+// or not an account exists for the number.
+func (s *authService) RequestPhoneOTP(phone string) (*entities.PhoneOTPResponse, error) {
+	phone, err := normalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &entities.PhoneOTPResponse{
+		Phone:       phone,
+		ExpiresIn:   int64(otpTTL.Seconds()),
+		ResendAfter: int64(otpResendCooldown.Seconds()),
+	}
+
+	ok, err := s.OTPRepo.AcquireCooldown(phone, otpResendCooldown)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		remaining, err := s.OTPRepo.CooldownRemaining(phone)
+		if err != nil {
+			return nil, err
+		}
+		response.ResendAfter = int64(remaining.Seconds()) + 1
+		return response, ErrOTPCooldown
+	}
+
+	sends, err := s.OTPRepo.IncrementSendCount(phone, time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	if sends > otpMaxSendsPerHour {
+		return nil, ErrOTPRateLimited
+	}
+
+	code, err := generateOTP()
+	if err != nil {
+		return nil, fmt.Errorf("error generating otp: %v", err)
+	}
+	if err := s.OTPRepo.SaveCode(phone, hashOTP(phone, code), otpTTL); err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("Your login code is %s. It expires in %d minutes. Do not share this code.", code, int(otpTTL.Minutes()))
+	if err := s.SMSSender.Send(phone, message); err != nil {
+		log.Printf("[Auth] Could not send OTP to %s: %v", phone, err)
+		return nil, fmt.Errorf("could not send code, please try again")
+	}
+	return response, nil
+}
+
+// VerifyPhoneOTP checks a code and logs the user in, creating an account for
+// numbers that have not been seen before.
+func (s *authService) VerifyPhoneOTP(phone string, code string, client entities.ClientInfo) (*entities.LoginResult, error) {
+	phone, err := normalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+	code = strings.TrimSpace(code)
+
+	hash, attempts, err := s.OTPRepo.GetCode(phone)
+	if err != nil {
+		if errors.Is(err, repositories.ErrOTPNotFound) {
+			return nil, ErrOTPInvalid
+		}
+		return nil, err
+	}
+	if attempts >= otpMaxAttempts {
+		s.OTPRepo.DeleteCode(phone)
+		return nil, ErrOTPTooManyAttempts
+	}
+
+	// Count the attempt before comparing so concurrent guesses cannot exceed the limit
+	attempts, err = s.OTPRepo.IncrementAttempts(phone)
+	if err != nil {
+		return nil, err
+	}
+	if attempts > otpMaxAttempts {
+		s.OTPRepo.DeleteCode(phone)
+		return nil, ErrOTPTooManyAttempts
+	}
+
+	if !hmac.Equal([]byte(hash), []byte(hashOTP(phone, code))) {
+		if attempts >= otpMaxAttempts {
+			s.OTPRepo.DeleteCode(phone)
+			return nil, ErrOTPTooManyAttempts
+		}
+		return nil, ErrOTPInvalid
+	}
+
+	consumed, err := s.OTPRepo.DeleteCode(phone)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrOTPInvalid
+	}
+
+	user, err := s.findOrCreatePhoneUser(phone)
+	if err != nil {
+		return nil, err
+	}
+	return s.TwoFactor.Login(entities.Actor{ID: user.UserID, Type: entities.ActorTypeUser}, client)
+}
+
+func (s *authService) findOrCreatePhoneUser(phone string) (*entities.UserDataFormat, error) {
+	now := time.Now().UTC().Add(7 * time.Hour)
+
+	user, err := s.UserRepo.GetUserByPhone(phone)
+	if err == nil {
+		if err := s.UserRepo.UpdateUser(user.UserID, &entities.UserDataFormat{LastLogin: now}); err != nil {
+			fmt.Println(err)
+		}
+		return user, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	user = &entities.UserDataFormat{
+		UserID:    uuid.New().String(),
+		Username:  "user" + phone[len(phone)-4:],
+		Phone:     phone,
+		CreatedAt: now,
+		LastLogin: now,
+	}
+	if err := s.UserRepo.InsertNewUser(user); err != nil {
+		// Another request created the account first
+		if existing, getErr := s.UserRepo.GetUserByPhone(phone); getErr == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	return user, nil
+}
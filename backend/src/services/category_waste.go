@@ -0,0 +1,258 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/infrastructure/providers"
+	"recycle-waste-management-backend/src/repositories"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCategoryNotFound  = repositories.ErrCategoryNotFound
+	ErrCategoryNameTaken = repositories.ErrCategoryNameTaken
+)
+
+type ICategoryWasteService interface {
+	// GetCategories lists every category, inactive ones included, in display order
+	GetCategories() (*[]entities.CategoryWasteModel, error)
+	CreateCategory(meta entities.RequestMeta, req entities.CategoryWasteRequest, icon []byte) (*entities.CategoryWasteModel, error)
+	// UpdateCategory relabels a category; a new name is carried over to its items, stocks, materials and price history
+	UpdateCategory(meta entities.RequestMeta, categoryID string, req entities.CategoryWasteRequest, icon []byte) (*entities.CategoryWasteModel, error)
+	ReorderCategories(meta entities.RequestMeta, categoryIDs []string) (*[]entities.CategoryWasteModel, error)
+	SetCategoryActive(meta entities.RequestMeta, categoryID string, active bool) (*entities.CategoryWasteModel, error)
+	// MergeCategory moves everything in a category to another one and removes it
+	MergeCategory(meta entities.RequestMeta, categoryID, intoCategoryID string) (*entities.CategoryMergeResult, error)
+}
+
+type categoryWasteService struct {
+	CategoryWasteRepo repositories.ICategoryWasteRepository
+	RecyclableItems   repositories.IRecyclableItemsRepository
+	StockRepo         repositories.IStockRepository
+	MaterialRepo      repositories.IMaterialRepository
+	PriceHistory      repositories.IPriceHistoryRepository
+	AwsS3             providers.IAwsS3Upload
+	Audit             IAuditService
+}
+
+func NewCategoryWasteService(categoryWasteRepo repositories.ICategoryWasteRepository, recyclableItems repositories.IRecyclableItemsRepository, stockRepo repositories.IStockRepository, materialRepo repositories.IMaterialRepository, priceHistory repositories.IPriceHistoryRepository, audit IAuditService) ICategoryWasteService {
+	return &categoryWasteService{
+		CategoryWasteRepo: categoryWasteRepo,
+		RecyclableItems:   recyclableItems,
+		StockRepo:         stockRepo,
+		MaterialRepo:      materialRepo,
+		PriceHistory:      priceHistory,
+		AwsS3:             providers.NewAwsS3(),
+		Audit:             audit,
+	}
+}
+
+func (s *categoryWasteService) GetCategories() (*[]entities.CategoryWasteModel, error) {
+	return s.CategoryWasteRepo.FindAllIncludingInactive()
+}
+
+func (s *categoryWasteService) CreateCategory(meta entities.RequestMeta, req entities.CategoryWasteRequest, icon []byte) (*entities.CategoryWasteModel, error) {
+	categories, err := s.CategoryWasteRepo.FindAllIncludingInactive()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Add(7 * time.Hour)
+	id := uuid.NewString()
+	category := &entities.CategoryWasteModel{
+		ID:         id,
+		CategoryID: id,
+		SortOrder:  len(*categories),
+		Active:     true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.apply(category, req, icon); err != nil {
+		return nil, err
+	}
+	if err := s.CategoryWasteRepo.Create(category); err != nil {
+		return nil, err
+	}
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditCategoryCreated,
+		TargetType: "category",
+		TargetID:   category.CategoryID,
+		After:      category,
+	})
+	return category, nil
+}
+
+func (s *categoryWasteService) UpdateCategory(meta entities.RequestMeta, categoryID string, req entities.CategoryWasteRequest, icon []byte) (*entities.CategoryWasteModel, error) {
+	category, err := s.CategoryWasteRepo.GetByID(categoryID)
+	if err != nil {
+		return nil, err
+	}
+	before := *category
+
+	if err := s.apply(category, req, icon); err != nil {
+		return nil, err
+	}
+	category.UpdatedAt = time.Now().UTC().Add(7 * time.Hour)
+	if err := s.CategoryWasteRepo.Update(category); err != nil {
+		return nil, err
+	}
+	if category.Name != before.Name {
+		if _, err := s.renameReferences(before.Name, category.Name); err != nil {
+			return nil, err
+		}
+	}
+	s.recordUpdate(meta, &before, category)
+	return category, nil
+}
+
+func (s *categoryWasteService) ReorderCategories(meta entities.RequestMeta, categoryIDs []string) (*[]entities.CategoryWasteModel, error) {
+	categories, err := s.CategoryWasteRepo.FindAllIncludingInactive()
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(*categories))
+	for _, c := range *categories {
+		known[c.CategoryID] = true
+	}
+	seen := make(map[string]bool, len(categoryIDs))
+	for _, id := range categoryIDs {
+		if !known[id] {
+			return nil, fmt.Errorf("unknown category %q", id)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("category %q is listed twice", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(known) {
+		return nil, fmt.Errorf("category_ids must list every category")
+	}
+
+	if err := s.CategoryWasteRepo.SetOrder(categoryIDs); err != nil {
+		return nil, err
+	}
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditCategoryUpdated,
+		TargetType: "category",
+		Before:     categories,
+		After:      categoryIDs,
+	})
+	return s.CategoryWasteRepo.FindAllIncludingInactive()
+}
+
+func (s *categoryWasteService) SetCategoryActive(meta entities.RequestMeta, categoryID string, active bool) (*entities.CategoryWasteModel, error) {
+	category, err := s.CategoryWasteRepo.GetByID(categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if category.Active == active {
+		return category, nil
+	}
+	before := *category
+
+	category.Active = active
+	category.UpdatedAt = time.Now().UTC().Add(7 * time.Hour)
+	if err := s.CategoryWasteRepo.Update(category); err != nil {
+		return nil, err
+	}
+	s.recordUpdate(meta, &before, category)
+	return category, nil
+}
+
+func (s *categoryWasteService) MergeCategory(meta entities.RequestMeta, categoryID, intoCategoryID string) (*entities.CategoryMergeResult, error) {
+	if intoCategoryID == "" {
+		return nil, fmt.Errorf("into_category_id is required")
+	}
+	if categoryID == intoCategoryID {
+		return nil, fmt.Errorf("a category cannot be merged into itself")
+	}
+	source, err := s.CategoryWasteRepo.GetByID(categoryID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.CategoryWasteRepo.GetByID(intoCategoryID)
+	if err != nil {
+		return nil, err
+	}
+	if !target.Active {
+		return nil, fmt.Errorf("cannot merge into inactive category %q", target.Name)
+	}
+
+	result, err := s.renameReferences(source.Name, target.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CategoryWasteRepo.Delete(source.CategoryID); err != nil {
+		return nil, err
+	}
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditCategoryMerged,
+		TargetType: "category",
+		TargetID:   target.CategoryID,
+		Before:     source,
+		After:      result,
+	})
+	return result, nil
+}
+
+// apply validates the request and copies it onto the category
+func (s *categoryWasteService) apply(category *entities.CategoryWasteModel, req entities.CategoryWasteRequest, icon []byte) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	nameTH := strings.TrimSpace(req.NameTH)
+	if nameTH == "" {
+		nameTH = name
+	}
+
+	category.Name = name
+	category.NameTH = nameTH
+	category.NameEN = strings.TrimSpace(req.NameEN)
+
+	if len(icon) > 0 {
+		keyname, contentType := s.AwsS3.CreateKeyNameImage("category-"+category.CategoryID, "webp")
+		linkURL, err := s.AwsS3.UploadS3FromString(icon, keyname, contentType)
+		if err != nil {
+			return err
+		}
+		category.IconURL = linkURL
+	}
+	return nil
+}
+
+// renameReferences moves items, stock snapshots, materials and price history from one category name to another
+func (s *categoryWasteService) renameReferences(from, to string) (*entities.CategoryMergeResult, error) {
+	var result entities.CategoryMergeResult
+	var err error
+	if result.Items, err = s.RecyclableItems.RenameCategory(from, to); err != nil {
+		return nil, err
+	}
+	if result.Stocks, err = s.StockRepo.RenameCategory(from, to); err != nil {
+		return nil, err
+	}
+	if result.Materials, err = s.MaterialRepo.RenameCategory(from, to); err != nil {
+		return nil, err
+	}
+	if result.PriceHistory, err = s.PriceHistory.RenameCategory(from, to); err != nil {
+		return nil, err
+	}
+	log.Printf("[Category] Moved %d items, %d stocks, %d materials and %d price history entries from %q to %q",
+		result.Items, result.Stocks, result.Materials, result.PriceHistory, from, to)
+	return &result, nil
+}
+
+func (s *categoryWasteService) recordUpdate(meta entities.RequestMeta, before, after *entities.CategoryWasteModel) {
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditCategoryUpdated,
+		TargetType: "category",
+		TargetID:   after.CategoryID,
+		Before:     before,
+		After:      after,
+	})
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/domain/models"
+	"recycle-waste-management-backend/src/repositories"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Sender types of a chat room member
+const (
+	ChatMemberCustomer = "customer"
+	ChatMemberShop     = "shop"
+)
+
+var ErrNotChatMember = errors.New("you are not a member of this chat")
+
+type IChatService interface {
+	// JoinChatRoom checks that the actor takes part in the request's chat and returns their sender type
+	JoinChatRoom(actor entities.Actor, customerRequestID string) (string, error)
+	GetUnreadCounts(actor entities.Actor) (*entities.ChatUnreadCountResponse, error)
+	UploadChatImage(actor entities.Actor, customerRequestID string, file *multipart.FileHeader) (string, error)
+	ReportMessage(actor entities.Actor, messageID string, reason string) error
+	GetReportedMessages(status string) (*[]models.ChatMessageModel, error)
+	ReviewReport(reviewerID string, messageID string, action string) error
+}
+
+type chatService struct {
+	ChatMessageRepo     repositories.IChatMessageRepository
+	CustomerRequestRepo repositories.ICustomerRequestRepository
+	ShopRepo            repositories.IShopRepository
+	ImageService        IImageService
+}
+
+func NewChatService(chatMessageRepo repositories.IChatMessageRepository, customerRequestRepo repositories.ICustomerRequestRepository, shopRepo repositories.IShopRepository, imageService IImageService) IChatService {
+	return &chatService{
+		ChatMessageRepo:     chatMessageRepo,
+		CustomerRequestRepo: customerRequestRepo,
+		ShopRepo:            shopRepo,
+		ImageService:        imageService,
+	}
+}
+
+// JoinChatRoom admits the customer who created the request and the shop it is
+// assigned to: its employees and its owner
+func (s *chatService) JoinChatRoom(actor entities.Actor, customerRequestID string) (string, error) {
+	request, err := s.CustomerRequestRepo.GetCustomerRequestByID(customerRequestID)
+	if err != nil {
+		return "", fmt.Errorf("chat room not found")
+	}
+	if !actor.IsEmployee() && request.UserID == actor.ID {
+		return ChatMemberCustomer, nil
+	}
+	if request.ShopID != "" {
+		if actor.IsShopBound() {
+			if actor.ShopID == request.ShopID {
+				return ChatMemberShop, nil
+			}
+		} else if shop, err := s.ShopRepo.GetByShopID(request.ShopID); err == nil && shop.UserID == actor.ID {
+			return ChatMemberShop, nil
+		}
+	}
+	return "", ErrNotChatMember
+}
+
+// GetUnreadCounts returns unread messages per room for every room the actor takes part in:
+// the requests they created, the requests assigned to their shops (an employee's own
+// shop, or every shop a user owns) and any room they have sent a message to.
+func (s *chatService) GetUnreadCounts(actor entities.Actor) (*entities.ChatUnreadCountResponse, error) {
+	userID := actor.ID
+	roomSet := make(map[string]bool)
+
+	var shopIDs []string
+	if actor.IsShopBound() {
+		if actor.ShopID != "" {
+			shopIDs = append(shopIDs, actor.ShopID)
+		}
+	} else {
+		requests, err := s.CustomerRequestRepo.GetCustomerRequests(userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range *requests {
+			roomSet[r.CustomerRequestID] = true
+		}
+
+		shops, err := s.ShopRepo.GetAllByUserID(userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, shop := range *shops {
+			shopIDs = append(shopIDs, shop.ShopID)
+		}
+	}
+	shopRooms, err := s.CustomerRequestRepo.GetCustomerRequestIDsByShopIDs(shopIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, roomID := range shopRooms {
+		roomSet[roomID] = true
+	}
+
+	senderRooms, err := s.ChatMessageRepo.GetRoomIDsBySender(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, roomID := range senderRooms {
+		roomSet[roomID] = true
+	}
+
+	roomIDs := make([]string, 0, len(roomSet))
+	for roomID := range roomSet {
+		roomIDs = append(roomIDs, roomID)
+	}
+
+	counts, err := s.ChatMessageRepo.CountUnreadByRoom(userID, roomIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &entities.ChatUnreadCountResponse{Rooms: []entities.ChatRoomUnread{}}
+	for roomID, count := range counts {
+		response.Total += count
+		response.Rooms = append(response.Rooms, entities.ChatRoomUnread{
+			CustomerRequestID: roomID,
+			Unread:            count,
+		})
+	}
+
+	sort.Slice(response.Rooms, func(i, j int) bool {
+		return response.Rooms[i].Unread > response.Rooms[j].Unread
+	})
+
+	return response, nil
+}
+
+// UploadChatImage processes a photo for the given room and returns its public URL,
+// which the client then sends as an "image" chat message. Only room members may upload.
+func (s *chatService) UploadChatImage(actor entities.Actor, customerRequestID string, file *multipart.FileHeader) (string, error) {
+	if _, err := s.JoinChatRoom(actor, customerRequestID); err != nil {
+		return "", err
+	}
+
+	return s.ImageService.ProcessAndUploadChatImage(file, customerRequestID)
+}
+
+// ReportMessage flags a stored message for admin review; only members of the
+// message's room may report it
+func (s *chatService) ReportMessage(actor entities.Actor, messageID string, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("reason is required")
+	}
+	if utf8.RuneCountInString(reason) > 500 {
+		return errors.New("reason must not exceed 500 characters")
+	}
+
+	message, err := s.ChatMessageRepo.GetByMessageID(messageID)
+	if err != nil {
+		return errors.New("chat message not found")
+	}
+	if _, err := s.JoinChatRoom(actor, message.CustomerRequestID); err != nil {
+		return err
+	}
+	if message.SenderID == actor.ID {
+		return errors.New("cannot report your own message")
+	}
+
+	return s.ChatMessageRepo.AddReport(messageID, models.ChatMessageReport{
+		ReporterID: actor.ID,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	})
+}
+
+// GetReportedMessages lists messages in the moderation queue; the route requires chat:moderate
+func (s *chatService) GetReportedMessages(status string) (*[]models.ChatMessageModel, error) {
+	if status == "" {
+		status = models.ChatReviewPending
+	}
+	switch status {
+	case models.ChatReviewPending, models.ChatReviewDismissed, models.ChatReviewRemoved:
+	default:
+		return nil, fmt.Errorf("invalid review status: %s", status)
+	}
+
+	return s.ChatMessageRepo.GetByReviewStatus(status)
+}
+
+func (s *chatService) ReviewReport(reviewerID string, messageID string, action string) error {
+	var status string
+	switch action {
+	case "dismiss":
+		status = models.ChatReviewDismissed
+	case "remove":
+		status = models.ChatReviewRemoved
+	default:
+		return fmt.Errorf("invalid action: %s", action)
+	}
+
+	return s.ChatMessageRepo.SetReviewStatus(messageID, status, reviewerID)
+}
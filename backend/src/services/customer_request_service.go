@@ -16,11 +16,11 @@ import (
 type ICustomerRequestService interface {
 	AddCustomerRequest(userID string, body entities.CustomerRequest) (error, int)
 	GetCustomerRequestByRequestID(userID string) ([]entities.CustomerRequestResponse, error)
-	GetCustomerRequests(userID string, page, limit int, maxDistanceKm float64) (*entities.PaginatedCustomerRequestResponse, error)
-	AcceptCustomerRequest(customerRequestID string) error
-	CancelCustomerRequest(customerRequestID string, cancelReason string) error
-	CompleteCustomerRequest(customerRequestID string, shopID string) error
-	CreateWalkInRequest(body entities.WalkInCustomerRequest) (string, error)
+	GetCustomerRequests(actor entities.Actor, page, limit int, maxDistanceKm float64) (*entities.PaginatedCustomerRequestResponse, error)
+	AcceptCustomerRequest(actor entities.Actor, customerRequestID string) error
+	CancelCustomerRequest(actor entities.Actor, customerRequestID string, cancelReason string) error
+	CompleteCustomerRequest(actor entities.Actor, customerRequestID string) error
+	CreateWalkInRequest(actor entities.Actor, body entities.WalkInCustomerRequest) (string, error)
 }
 type customerRequestService struct {
 	customerRequestRepository repositories.ICustomerRequestRepository
@@ -98,11 +98,19 @@ func haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
 	return earthRadiusKm * c
 }
 
-func (s *customerRequestService) GetCustomerRequests(userID string, page, limit int, maxDistanceKm float64) (*entities.PaginatedCustomerRequestResponse, error) {
+func (s *customerRequestService) GetCustomerRequests(actor entities.Actor, page, limit int, maxDistanceKm float64) (*entities.PaginatedCustomerRequestResponse, error) {
+	if err := requireEmployeePermission(actor, entities.PermissionRequestView); err != nil {
+		return nil, err
+	}
+
 	// Get shop location
-	shopData, err := s.shopRepository.GetByUserID(userID)
+	shopID, err := ResolveActingShop(actor, s.shopRepository)
+	if err != nil {
+		return nil, err
+	}
+	shopData, err := s.shopRepository.GetByShopID(shopID)
 	if err != nil {
-		fmt.Printf("Error getting shop data for user %s: %v\n", userID, err)
+		fmt.Printf("Error getting shop data for %s %s: %v\n", actor.Type, actor.ID, err)
 		return nil, err
 	}
 
@@ -201,19 +209,112 @@ func (s *customerRequestService) GetCustomerRequests(userID string, page, limit
 	}, nil
 }
 
-func (s *customerRequestService) AcceptCustomerRequest(customerRequestID string) error {
-	return s.customerRequestRepository.UpdateCustomerRequestStatus(customerRequestID, models.CR_ACCEPTED)
+func (s *customerRequestService) AcceptCustomerRequest(actor entities.Actor, customerRequestID string) error {
+	if err := requireEmployeePermission(actor, entities.PermissionRequestManage); err != nil {
+		return err
+	}
+	shopID, err := ResolveActingShop(actor, s.shopRepository)
+	if err != nil {
+		return err
+	}
+	// The request is claimed for the accepting shop; one taken by another shop is left alone
+	return s.customerRequestRepository.AcceptCustomerRequest(customerRequestID, shopID)
 }
 
-func (s *customerRequestService) CancelCustomerRequest(customerRequestID string, cancelReason string) error {
+func (s *customerRequestService) CancelCustomerRequest(actor entities.Actor, customerRequestID string, cancelReason string) error {
+	if actor.IsEmployee() {
+		if err := s.checkEmployeeRequestAccess(actor, customerRequestID); err != nil {
+			return err
+		}
+	} else if err := s.checkUserCancelAccess(actor, customerRequestID); err != nil {
+		return err
+	}
 	return s.customerRequestRepository.CancelCustomerRequest(customerRequestID, cancelReason)
 }
 
-func (s *customerRequestService) CompleteCustomerRequest(customerRequestID string, shopID string) error {
+func (s *customerRequestService) CompleteCustomerRequest(actor entities.Actor, customerRequestID string) error {
+	var shopID string
+	if actor.IsEmployee() {
+		if err := s.checkEmployeeRequestAccess(actor, customerRequestID); err != nil {
+			return err
+		}
+		resolved, err := ResolveActingShop(actor, s.shopRepository)
+		if err != nil {
+			return err
+		}
+		shopID = resolved
+	} else {
+		request, err := s.customerRequestRepository.GetCustomerRequestByID(customerRequestID)
+		if err != nil {
+			return fmt.Errorf("customer request not found")
+		}
+		if !s.managesRequestShop(actor, request) {
+			return fmt.Errorf("you cannot complete this customer request")
+		}
+		shopID = request.ShopID
+	}
 	return s.customerRequestRepository.CompleteCustomerRequest(customerRequestID, shopID)
 }
 
-func (s *customerRequestService) CreateWalkInRequest(body entities.WalkInCustomerRequest) (string, error) {
+// checkUserCancelAccess lets customers cancel their own requests and shop
+// owners cancel the requests assigned to one of their shops
+func (s *customerRequestService) checkUserCancelAccess(actor entities.Actor, customerRequestID string) error {
+	request, err := s.customerRequestRepository.GetCustomerRequestByID(customerRequestID)
+	if err != nil {
+		return fmt.Errorf("customer request not found")
+	}
+	if request.UserID == actor.ID || s.managesRequestShop(actor, request) {
+		return nil
+	}
+	return fmt.Errorf("you cannot cancel this customer request")
+}
+
+// managesRequestShop reports whether a non-employee actor may act for the shop
+// a request is assigned to: its owner, or an admin. Unassigned requests have
+// to be accepted first.
+func (s *customerRequestService) managesRequestShop(actor entities.Actor, request *models.CustomerRequestModel) bool {
+	if request.ShopID == "" {
+		return false
+	}
+	if actor.HasPermission(entities.PermissionShopManageAny) {
+		return true
+	}
+	if !actor.HasPermission(entities.PermissionRequestManage) {
+		return false
+	}
+	shop, err := s.shopRepository.GetByShopID(request.ShopID)
+	return err == nil && shop.UserID == actor.ID
+}
+
+// checkEmployeeRequestAccess lets employees manage only the requests assigned to
+// their own shop; unassigned ones have to be accepted first
+func (s *customerRequestService) checkEmployeeRequestAccess(actor entities.Actor, customerRequestID string) error {
+	if err := requireEmployeePermission(actor, entities.PermissionRequestManage); err != nil {
+		return err
+	}
+	request, err := s.customerRequestRepository.GetCustomerRequestByID(customerRequestID)
+	if err != nil {
+		return fmt.Errorf("customer request not found")
+	}
+	if request.ShopID == "" {
+		return fmt.Errorf("customer request has not been accepted by a shop")
+	}
+	if request.ShopID != actor.ShopID {
+		return fmt.Errorf("customer request belongs to another shop")
+	}
+	return nil
+}
+
+func (s *customerRequestService) CreateWalkInRequest(actor entities.Actor, body entities.WalkInCustomerRequest) (string, error) {
+	if err := requireEmployeePermission(actor, entities.PermissionRequestManage); err != nil {
+		return "", err
+	}
+	shopID, err := ResolveActingShop(actor, s.shopRepository)
+	if err != nil {
+		return "", err
+	}
+	body.ShopID = shopID
+
 	// Create a new request with "WALK_IN" status or similar
 	// Since we don't have a real user, we'll use a placeholder UserID or "WALK_IN"
 	// And we store customer name/phone in description or a new field?
@@ -15,24 +15,37 @@ import (
 
 type employeeService struct {
 	EmployeeRepository repositories.IEmployeeRepository
+	ShopRepository     repositories.IShopRepository
+	LoginAttempts      repositories.ILoginAttemptRepository
+	SecurityEvents     repositories.ISecurityEventRepository
+	Audit              IAuditService
 }
 
 type IEmployeeService interface {
-	CreateEmployee(req *entities.CreateEmployeeRequest) (*entities.EmployeeResponse, error)
+	CreateEmployee(meta entities.RequestMeta, req *entities.CreateEmployeeRequest) (*entities.EmployeeResponse, error)
 	GetEmployeeByID(employeeID string) (*entities.EmployeeResponse, error)
 	GetEmployeesByShopID(shopID string, page, pageSize int) (*entities.EmployeeListResponse, error)
-	UpdateEmployee(employeeID string, req *entities.UpdateEmployeeRequest) (*entities.EmployeeResponse, error)
-	DeleteEmployee(employeeID string) error
-	ValidateEmployeePassword(username, password string) (*models.Employee, error)
+	UpdateEmployee(meta entities.RequestMeta, employeeID string, req *entities.UpdateEmployeeRequest) (*entities.EmployeeResponse, error)
+	DeleteEmployee(meta entities.RequestMeta, employeeID string) error
+	Login(shopCode string, username string, password string, ip string) (*models.Employee, error)
+	GetSecurityEvents(shopID string, page, limit int) (*[]models.SecurityEvent, int64, error)
 }
 
-func NewEmployeeService(repo repositories.IEmployeeRepository) IEmployeeService {
+func NewEmployeeService(repo repositories.IEmployeeRepository, shopRepo repositories.IShopRepository, loginAttempts repositories.ILoginAttemptRepository, securityEvents repositories.ISecurityEventRepository, audit IAuditService) IEmployeeService {
 	return &employeeService{
 		EmployeeRepository: repo,
+		ShopRepository:     shopRepo,
+		LoginAttempts:      loginAttempts,
+		SecurityEvents:     securityEvents,
+		Audit:              audit,
 	}
 }
 
-func (s *employeeService) CreateEmployee(req *entities.CreateEmployeeRequest) (*entities.EmployeeResponse, error) {
+func (s *employeeService) CreateEmployee(meta entities.RequestMeta, req *entities.CreateEmployeeRequest) (*entities.EmployeeResponse, error) {
+	if err := validateEmployeePermissions(req.Permissions); err != nil {
+		return nil, err
+	}
+
 	// Check if username already exists
 	existingEmployee, err := s.EmployeeRepository.GetEmployeeByUsername(req.Username)
 	if err != nil {
@@ -59,28 +72,37 @@ func (s *employeeService) CreateEmployee(req *entities.CreateEmployeeRequest) (*
 
 	// Create employee model
 	employee := &models.Employee{
-		EmployeeID: employeeID,
-		ShopID:     req.ShopID,
-		FirstName:  req.FirstName,
-		LastName:   req.LastName,
-		Username:   req.Username,
-		Password:   string(hashedPassword),
+		EmployeeID:  employeeID,
+		ShopID:      req.ShopID,
+		FirstName:   req.FirstName,
+		LastName:    req.LastName,
+		Username:    req.Username,
+		Password:    string(hashedPassword),
+		Permissions: req.Permissions,
 	}
 
 	// Save to database
 	if err := s.EmployeeRepository.CreateEmployee(employee); err != nil {
 		return nil, fmt.Errorf("error creating employee: %v", err)
 	}
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditEmployeeCreated,
+		ShopID:     employee.ShopID,
+		TargetType: "employee",
+		TargetID:   employee.EmployeeID,
+		After:      employee,
+	})
 
 	// Return response (without password)
 	response := &entities.EmployeeResponse{
-		EmployeeID: employee.EmployeeID,
-		ShopID:     employee.ShopID,
-		FirstName:  employee.FirstName,
-		LastName:   employee.LastName,
-		Username:   employee.Username,
-		CreatedAt:  employee.CreatedAt,
-		UpdatedAt:  employee.UpdatedAt,
+		EmployeeID:  employee.EmployeeID,
+		ShopID:      employee.ShopID,
+		FirstName:   employee.FirstName,
+		LastName:    employee.LastName,
+		Username:    employee.Username,
+		Permissions: employee.Actor().Permissions,
+		CreatedAt:   employee.CreatedAt,
+		UpdatedAt:   employee.UpdatedAt,
 	}
 
 	return response, nil
@@ -93,13 +115,14 @@ func (s *employeeService) GetEmployeeByID(employeeID string) (*entities.Employee
 	}
 
 	response := &entities.EmployeeResponse{
-		EmployeeID: employee.EmployeeID,
-		ShopID:     employee.ShopID,
-		FirstName:  employee.FirstName,
-		LastName:   employee.LastName,
-		Username:   employee.Username,
-		CreatedAt:  employee.CreatedAt,
-		UpdatedAt:  employee.UpdatedAt,
+		EmployeeID:  employee.EmployeeID,
+		ShopID:      employee.ShopID,
+		FirstName:   employee.FirstName,
+		LastName:    employee.LastName,
+		Username:    employee.Username,
+		Permissions: employee.Actor().Permissions,
+		CreatedAt:   employee.CreatedAt,
+		UpdatedAt:   employee.UpdatedAt,
 	}
 
 	return response, nil
@@ -115,13 +138,14 @@ func (s *employeeService) GetEmployeesByShopID(shopID string, page, pageSize int
 	var employeeResponses []entities.EmployeeResponse
 	for _, emp := range employees {
 		employeeResponses = append(employeeResponses, entities.EmployeeResponse{
-			EmployeeID: emp.EmployeeID,
-			ShopID:     emp.ShopID,
-			FirstName:  emp.FirstName,
-			LastName:   emp.LastName,
-			Username:   emp.Username,
-			CreatedAt:  emp.CreatedAt,
-			UpdatedAt:  emp.UpdatedAt,
+			EmployeeID:  emp.EmployeeID,
+			ShopID:      emp.ShopID,
+			FirstName:   emp.FirstName,
+			LastName:    emp.LastName,
+			Username:    emp.Username,
+			Permissions: emp.Actor().Permissions,
+			CreatedAt:   emp.CreatedAt,
+			UpdatedAt:   emp.UpdatedAt,
 		})
 	}
 
@@ -138,7 +162,11 @@ func (s *employeeService) GetEmployeesByShopID(shopID string, page, pageSize int
 	return response, nil
 }
 
-func (s *employeeService) UpdateEmployee(employeeID string, req *entities.UpdateEmployeeRequest) (*entities.EmployeeResponse, error) {
+func (s *employeeService) UpdateEmployee(meta entities.RequestMeta, employeeID string, req *entities.UpdateEmployeeRequest) (*entities.EmployeeResponse, error) {
+	if err := validateEmployeePermissions(req.Permissions); err != nil {
+		return nil, err
+	}
+
 	// Get existing employee
 	existingEmployee, err := s.EmployeeRepository.GetEmployeeByID(employeeID)
 	if err != nil {
@@ -158,13 +186,14 @@ func (s *employeeService) UpdateEmployee(employeeID string, req *entities.Update
 
 	// Update fields
 	updateData := &models.Employee{
-		EmployeeID: employeeID,
-		ShopID:     existingEmployee.ShopID,
-		FirstName:  existingEmployee.FirstName,
-		LastName:   existingEmployee.LastName,
-		Username:   existingEmployee.Username,
-		Password:   existingEmployee.Password,
-		CreatedAt:  existingEmployee.CreatedAt,
+		EmployeeID:  employeeID,
+		ShopID:      existingEmployee.ShopID,
+		FirstName:   existingEmployee.FirstName,
+		LastName:    existingEmployee.LastName,
+		Username:    existingEmployee.Username,
+		Password:    existingEmployee.Password,
+		Permissions: existingEmployee.Permissions,
+		CreatedAt:   existingEmployee.CreatedAt,
 	}
 
 	if req.FirstName != "" {
@@ -176,6 +205,9 @@ func (s *employeeService) UpdateEmployee(employeeID string, req *entities.Update
 	if req.Username != "" {
 		updateData.Username = req.Username
 	}
+	if req.Permissions != nil {
+		updateData.Permissions = req.Permissions
+	}
 
 	// Hash new password if provided
 	if req.Password != "" {
@@ -196,38 +228,52 @@ func (s *employeeService) UpdateEmployee(employeeID string, req *entities.Update
 	if err != nil {
 		return nil, err
 	}
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditEmployeeUpdated,
+		ShopID:     updatedEmployee.ShopID,
+		TargetType: "employee",
+		TargetID:   employeeID,
+		Before:     existingEmployee,
+		After:      updatedEmployee,
+	})
 
 	response := &entities.EmployeeResponse{
-		EmployeeID: updatedEmployee.EmployeeID,
-		ShopID:     updatedEmployee.ShopID,
-		FirstName:  updatedEmployee.FirstName,
-		LastName:   updatedEmployee.LastName,
-		Username:   updatedEmployee.Username,
-		CreatedAt:  updatedEmployee.CreatedAt,
-		UpdatedAt:  updatedEmployee.UpdatedAt,
+		EmployeeID:  updatedEmployee.EmployeeID,
+		ShopID:      updatedEmployee.ShopID,
+		FirstName:   updatedEmployee.FirstName,
+		LastName:    updatedEmployee.LastName,
+		Username:    updatedEmployee.Username,
+		Permissions: updatedEmployee.Actor().Permissions,
+		CreatedAt:   updatedEmployee.CreatedAt,
+		UpdatedAt:   updatedEmployee.UpdatedAt,
 	}
 
 	return response, nil
 }
 
-func (s *employeeService) DeleteEmployee(employeeID string) error {
-	return s.EmployeeRepository.DeleteEmployee(employeeID)
-}
-
-func (s *employeeService) ValidateEmployeePassword(username, password string) (*models.Employee, error) {
-	employee, err := s.EmployeeRepository.GetEmployeeByUsername(username)
+func (s *employeeService) DeleteEmployee(meta entities.RequestMeta, employeeID string) error {
+	employee, err := s.EmployeeRepository.GetEmployeeByID(employeeID)
 	if err != nil {
-		return nil, fmt.Errorf("error finding employee: %v", err)
+		return err
 	}
-	if employee == nil {
-		return nil, fmt.Errorf("employee not found")
+	if err := s.EmployeeRepository.DeleteEmployee(employeeID); err != nil {
+		return err
 	}
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditEmployeeDeleted,
+		ShopID:     employee.ShopID,
+		TargetType: "employee",
+		TargetID:   employeeID,
+		Before:     employee,
+	})
+	return nil
+}
 
-	// Compare hashed password
-	err = bcrypt.CompareHashAndPassword([]byte(employee.Password), []byte(password))
-	if err != nil {
-		return nil, fmt.Errorf("invalid password")
+func validateEmployeePermissions(permissions []string) error {
+	for _, p := range permissions {
+		if !entities.IsEmployeePermission(p) {
+			return fmt.Errorf("invalid permission: %s", p)
+		}
 	}
-
-	return employee, nil
+	return nil
 }
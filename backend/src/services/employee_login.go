@@ -0,0 +1,157 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"recycle-waste-management-backend/src/domain/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Failed employee logins are counted per username and per IP address within
+// a window. Past the free attempts every further failure locks the key for
+// an exponentially growing time.
+const (
+	employeeLoginWindow       = time.Hour
+	employeeLoginFreeByUser   = 5
+	employeeLoginFreeByIP     = 20
+	employeeLoginBaseLockout  = 30 * time.Second
+	employeeLoginMaxLockout   = 30 * time.Minute
+	employeeLoginMaxIPLockout = time.Hour
+)
+
+// ErrEmployeeLoginFailed is the only error a caller sees for wrong credentials,
+// whether the shop code, the username or the password was wrong
+var ErrEmployeeLoginFailed = errors.New("invalid shop code, username or password")
+
+// LoginLockedError is returned while logins are temporarily blocked
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %d seconds", int(e.RetryAfter.Seconds()))
+}
+
+// dummyPasswordHash keeps the response time the same when the employee does not exist
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+
+// Login authenticates an employee of the shop with the given code
+func (s *employeeService) Login(shopCode string, username string, password string, ip string) (*models.Employee, error) {
+	userKey := "employee:user:" + strings.ToLower(shopCode) + ":" + strings.ToLower(username)
+	ipKey := "employee:ip:" + ip
+
+	for _, key := range []string{userKey, ipKey} {
+		remaining, err := s.LoginAttempts.LockRemaining(key)
+		if err != nil {
+			return nil, err
+		}
+		if remaining > 0 {
+			return nil, &LoginLockedError{RetryAfter: remaining}
+		}
+	}
+
+	employee, err := s.checkEmployeeCredentials(shopCode, username, password)
+	if err != nil {
+		if errors.Is(err, ErrEmployeeLoginFailed) {
+			s.recordLoginFailure(userKey, ipKey, employee, username, ip)
+		}
+		return nil, err
+	}
+
+	if err := s.LoginAttempts.Reset(userKey); err != nil {
+		log.Printf("[EmployeeLogin] Could not reset failures for %s: %v", userKey, err)
+	}
+	return employee, nil
+}
+
+// checkEmployeeCredentials always runs one bcrypt comparison. On failure it
+// still returns the employee, if one matched the username, for alerting.
+func (s *employeeService) checkEmployeeCredentials(shopCode string, username string, password string) (*models.Employee, error) {
+	employee, err := s.EmployeeRepository.GetEmployeeByUsername(username)
+	if err != nil {
+		return nil, fmt.Errorf("error finding employee: %v", err)
+	}
+
+	hash := dummyPasswordHash
+	if employee != nil {
+		hash = []byte(employee.Password)
+	}
+	passwordErr := bcrypt.CompareHashAndPassword(hash, []byte(password))
+	if employee == nil {
+		return nil, ErrEmployeeLoginFailed
+	}
+
+	shop, err := s.ShopRepository.GetByShopCode(shopCode)
+	if err != nil || shop == nil || shop.ShopID != employee.ShopID || shop.ArchivedAt != nil || passwordErr != nil {
+		return employee, ErrEmployeeLoginFailed
+	}
+	return employee, nil
+}
+
+func (s *employeeService) recordLoginFailure(userKey string, ipKey string, employee *models.Employee, username string, ip string) {
+	userFailures, err := s.LoginAttempts.RecordFailure(userKey, employeeLoginWindow)
+	if err != nil {
+		log.Printf("[EmployeeLogin] Could not record failure: %v", err)
+		return
+	}
+	if lockout := loginLockout(userFailures, employeeLoginFreeByUser, employeeLoginMaxLockout); lockout > 0 {
+		if err := s.LoginAttempts.Lock(userKey, lockout); err != nil {
+			log.Printf("[EmployeeLogin] Could not lock %s: %v", userKey, err)
+		}
+		if employee != nil {
+			s.alertShopOwner(employee, username, ip, userFailures, lockout)
+		}
+	}
+
+	ipFailures, err := s.LoginAttempts.RecordFailure(ipKey, employeeLoginWindow)
+	if err != nil {
+		log.Printf("[EmployeeLogin] Could not record failure: %v", err)
+		return
+	}
+	if lockout := loginLockout(ipFailures, employeeLoginFreeByIP, employeeLoginMaxIPLockout); lockout > 0 {
+		if err := s.LoginAttempts.Lock(ipKey, lockout); err != nil {
+			log.Printf("[EmployeeLogin] Could not lock %s: %v", ipKey, err)
+		}
+	}
+}
+
+// loginLockout doubles the lockout with every failure past the free ones
+func loginLockout(failures int64, free int64, max time.Duration) time.Duration {
+	if failures < free {
+		return 0
+	}
+	lockout := employeeLoginBaseLockout
+	for i := free; i < failures && lockout < max; i++ {
+		lockout *= 2
+	}
+	if lockout > max {
+		lockout = max
+	}
+	return lockout
+}
+
+func (s *employeeService) alertShopOwner(employee *models.Employee, username string, ip string, failures int64, lockout time.Duration) {
+	event := &models.SecurityEvent{
+		EventID:        uuid.New().String(),
+		ShopID:         employee.ShopID,
+		Type:           models.SecurityEventEmployeeLoginLocked,
+		EmployeeID:     employee.EmployeeID,
+		Username:       username,
+		IPAddress:      ip,
+		FailedAttempts: failures,
+		LockedUntil:    time.Now().Add(lockout),
+		CreatedAt:      time.Now(),
+	}
+	if err := s.SecurityEvents.Create(event); err != nil {
+		log.Printf("[EmployeeLogin] Could not record security event: %v", err)
+	}
+}
+
+func (s *employeeService) GetSecurityEvents(shopID string, page, limit int) (*[]models.SecurityEvent, int64, error) {
+	return s.SecurityEvents.GetByShopID(shopID, page, limit)
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"mime/multipart"
 
+	"recycle-waste-management-backend/src/domain/entities"
 	"recycle-waste-management-backend/src/infrastructure/providers"
 )
 
@@ -15,7 +16,9 @@ type imageService struct {
 
 type IImageService interface {
 	ProcessAndUploadProfileImage(file *multipart.FileHeader, userID string) (string, error)
+	ProcessAndUploadChatImage(file *multipart.FileHeader, customerRequestID string) (string, error)
 	DeleteProfileImage(imageURL string) error
+	DeleteChatImage(imageURL string) error
 	ValidateImageFile(file *multipart.FileHeader) error
 }
 
@@ -27,6 +30,15 @@ func NewImageService() IImageService {
 }
 
 func (s *imageService) ProcessAndUploadProfileImage(file *multipart.FileHeader, userID string) (string, error) {
+	return s.processAndUpload(file, "profile-images", userID)
+}
+
+// ProcessAndUploadChatImage stores a chat photo under the room it was sent to
+func (s *imageService) ProcessAndUploadChatImage(file *multipart.FileHeader, customerRequestID string) (string, error) {
+	return s.processAndUpload(file, entities.ChatImageFolder, customerRequestID)
+}
+
+func (s *imageService) processAndUpload(file *multipart.FileHeader, folder string, ownerID string) (string, error) {
 	// Validate file
 	if err := s.ValidateImageFile(file); err != nil {
 		return "", err
@@ -52,11 +64,12 @@ func (s *imageService) ProcessAndUploadProfileImage(file *multipart.FileHeader,
 	}
 
 	// Upload to S3
-	imageURL, err := s.S3Provider.UploadImage(
+	imageURL, err := s.S3Provider.UploadImageToFolder(
 		processedImage.Data,
 		processedImage.Filename,
 		processedImage.ContentType,
-		userID,
+		folder,
+		ownerID,
 	)
 	if err != nil {
 		return "", fmt.Errorf("failed to upload image to S3: %v", err)
@@ -78,6 +91,11 @@ func (s *imageService) DeleteProfileImage(imageURL string) error {
 	return nil
 }
 
+// DeleteChatImage removes a photo sent in chat; chat photos are public S3 objects like profile images
+func (s *imageService) DeleteChatImage(imageURL string) error {
+	return s.DeleteProfileImage(imageURL)
+}
+
 func (s *imageService) ValidateImageFile(file *multipart.FileHeader) error {
 	// Check if file exists
 	if file == nil {
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/infrastructure/providers"
+	"recycle-waste-management-backend/src/repositories"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const maxMaterialAliases = 20
+
+var (
+	ErrMaterialNotFound  = repositories.ErrMaterialNotFound
+	ErrMaterialCodeTaken = repositories.ErrMaterialCodeTaken
+	materialCodePattern  = regexp.MustCompile(`^[A-Z0-9_-]{2,32}$`)
+)
+
+type IMaterialService interface {
+	GetMaterials(category string) (*[]entities.MaterialModel, error)
+	GetMaterial(materialID string) (*entities.MaterialModel, error)
+	// CreateMaterial adds a material and maps the unmapped items already sold under its names
+	CreateMaterial(meta entities.RequestMeta, req entities.MaterialRequest, image []byte) (*entities.MaterialModel, error)
+	UpdateMaterial(meta entities.RequestMeta, materialID string, req entities.MaterialRequest, image []byte) (*entities.MaterialModel, error)
+	// DeleteMaterial removes a material; its items stay, unmapped
+	DeleteMaterial(meta entities.RequestMeta, materialID string) error
+}
+
+type materialService struct {
+	MaterialRepo      repositories.IMaterialRepository
+	RecyclableItems   repositories.IRecyclableItemsRepository
+	CategoryWasteRepo repositories.ICategoryWasteRepository
+	AwsS3             providers.IAwsS3Upload
+	Audit             IAuditService
+}
+
+func NewMaterialService(materialRepo repositories.IMaterialRepository, recyclableItems repositories.IRecyclableItemsRepository, categoryWasteRepo repositories.ICategoryWasteRepository, audit IAuditService) IMaterialService {
+	return &materialService{
+		MaterialRepo:      materialRepo,
+		RecyclableItems:   recyclableItems,
+		CategoryWasteRepo: categoryWasteRepo,
+		AwsS3:             providers.NewAwsS3(),
+		Audit:             audit,
+	}
+}
+
+func (s *materialService) GetMaterials(category string) (*[]entities.MaterialModel, error) {
+	return s.MaterialRepo.GetAll(category)
+}
+
+func (s *materialService) GetMaterial(materialID string) (*entities.MaterialModel, error) {
+	return s.MaterialRepo.GetByID(materialID)
+}
+
+func (s *materialService) CreateMaterial(meta entities.RequestMeta, req entities.MaterialRequest, image []byte) (*entities.MaterialModel, error) {
+	now := time.Now().UTC().Add(7 * time.Hour)
+	material := &entities.MaterialModel{
+		MaterialID: uuid.NewString(),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.apply(material, req, image); err != nil {
+		return nil, err
+	}
+	if err := s.MaterialRepo.Create(material); err != nil {
+		return nil, err
+	}
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditMaterialCreated,
+		TargetType: "material",
+		TargetID:   material.MaterialID,
+		After:      material,
+	})
+	s.mapItems(material)
+	return material, nil
+}
+
+func (s *materialService) UpdateMaterial(meta entities.RequestMeta, materialID string, req entities.MaterialRequest, image []byte) (*entities.MaterialModel, error) {
+	material, err := s.MaterialRepo.GetByID(materialID)
+	if err != nil {
+		return nil, err
+	}
+	before := *material
+
+	if err := s.apply(material, req, image); err != nil {
+		return nil, err
+	}
+	material.UpdatedAt = time.Now().UTC().Add(7 * time.Hour)
+	if err := s.MaterialRepo.Update(material); err != nil {
+		return nil, err
+	}
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditMaterialUpdated,
+		TargetType: "material",
+		TargetID:   materialID,
+		Before:     before,
+		After:      material,
+	})
+	s.mapItems(material)
+	return material, nil
+}
+
+func (s *materialService) DeleteMaterial(meta entities.RequestMeta, materialID string) error {
+	material, err := s.MaterialRepo.GetByID(materialID)
+	if err != nil {
+		return err
+	}
+	if err := s.RecyclableItems.UnmapMaterial(materialID); err != nil {
+		return err
+	}
+	if err := s.MaterialRepo.Delete(materialID); err != nil {
+		return err
+	}
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditMaterialDeleted,
+		TargetType: "material",
+		TargetID:   materialID,
+		Before:     material,
+	})
+	return nil
+}
+
+// apply validates the request and copies it onto the material
+func (s *materialService) apply(material *entities.MaterialModel, req entities.MaterialRequest, image []byte) error {
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if !materialCodePattern.MatchString(code) {
+		return fmt.Errorf("code must be 2-32 English letters, numbers, hyphens or underscores")
+	}
+	nameTH := strings.TrimSpace(req.NameTH)
+	if nameTH == "" {
+		return fmt.Errorf("name_th is required")
+	}
+	if err := s.checkCategory(req.Category); err != nil {
+		return err
+	}
+	aliases, err := normalizeAliases(req.Aliases)
+	if err != nil {
+		return err
+	}
+
+	material.Code = code
+	material.NameTH = nameTH
+	material.NameEN = strings.TrimSpace(req.NameEN)
+	material.Category = req.Category
+	material.Aliases = aliases
+
+	if len(image) > 0 {
+		keyname, contentType := s.AwsS3.CreateKeyNameImage("material-"+code, "webp")
+		linkURL, err := s.AwsS3.UploadS3FromString(image, keyname, contentType)
+		if err != nil {
+			return err
+		}
+		material.ImageURL = linkURL
+	}
+	return nil
+}
+
+func (s *materialService) checkCategory(category string) error {
+	if category == "" {
+		return fmt.Errorf("category is required")
+	}
+	categories, err := s.CategoryWasteRepo.FindAll()
+	if err != nil {
+		return err
+	}
+	for _, c := range *categories {
+		if c.Name == category {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown category %q", category)
+}
+
+// normalizeAliases trims aliases and drops empty and duplicate ones
+func normalizeAliases(aliases []string) ([]string, error) {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		key := strings.ToLower(alias)
+		if alias == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, alias)
+	}
+	if len(result) > maxMaterialAliases {
+		return nil, fmt.Errorf("a material can have at most %d aliases", maxMaterialAliases)
+	}
+	return result, nil
+}
+
+// mapItems links unmapped items sold under the material's names; the catalog change stands if it fails
+func (s *materialService) mapItems(material *entities.MaterialModel) {
+	mapped, err := s.RecyclableItems.MapUnmappedByNames(material.MaterialID, material.Names())
+	if err != nil {
+		log.Printf("[Material] Could not map items to %s: %v", material.Code, err)
+		return
+	}
+	if mapped > 0 {
+		log.Printf("[Material] Mapped %d existing items to %s", mapped, material.Code)
+	}
+}
+
+// isMaterialNotFound tells a missing material apart from a lookup failure
+func isMaterialNotFound(err error) bool {
+	return errors.Is(err, ErrMaterialNotFound)
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"fmt"
+	"recycle-waste-management-backend/src/domain/entities"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// bangkok is fixed rather than loaded so it works on images without tzdata
+var bangkok = time.FixedZone("Asia/Bangkok", 7*60*60)
+
+const (
+	closureDateLayout      = "2006-01-02"
+	maxClosureMessageRunes = 200
+	maxClosures            = 50
+	// nextOpeningSearchDays bounds the search for the next opening, long closures included
+	nextOpeningSearchDays = 400
+)
+
+// parseClockMinutes parses "HH:MM" into minutes after midnight, allowing "24:00"
+func parseClockMinutes(clock string) (int, error) {
+	if len(clock) != 5 || clock[2] != ':' {
+		return 0, fmt.Errorf("invalid time %q, use HH:MM", clock)
+	}
+	hour, hourErr := strconv.Atoi(clock[:2])
+	minute, minuteErr := strconv.Atoi(clock[3:])
+	if hourErr != nil || minuteErr != nil || minute < 0 || minute > 59 || hour < 0 || hour > 24 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("invalid time %q, use HH:MM", clock)
+	}
+	return hour*60 + minute, nil
+}
+
+// validateOpeningHours checks the days and that each day's ranges are ordered and don't overlap
+func validateOpeningHours(schedule entities.WeeklySchedule) error {
+	for day, ranges := range schedule {
+		if !isScheduleDay(day) {
+			return fmt.Errorf("invalid day %q in opening hours", day)
+		}
+		sorted, err := sortedRanges(ranges)
+		if err != nil {
+			return fmt.Errorf("%s: %v", day, err)
+		}
+		for i := 1; i < len(sorted); i++ {
+			if sorted[i][0] < sorted[i-1][1] {
+				return fmt.Errorf("%s: opening ranges overlap", day)
+			}
+		}
+	}
+	return nil
+}
+
+func validateClosures(closures []entities.ShopClosure) error {
+	if len(closures) > maxClosures {
+		return fmt.Errorf("a shop can have at most %d closures", maxClosures)
+	}
+	for _, closure := range closures {
+		if closure.Type != entities.ClosureHoliday && closure.Type != entities.ClosureTemporary {
+			return fmt.Errorf("closure type must be %s or %s", entities.ClosureHoliday, entities.ClosureTemporary)
+		}
+		start, err := time.Parse(closureDateLayout, closure.StartDate)
+		if err != nil {
+			return fmt.Errorf("invalid closure start date %q, use YYYY-MM-DD", closure.StartDate)
+		}
+		end, err := time.Parse(closureDateLayout, closure.EndDate)
+		if err != nil {
+			return fmt.Errorf("invalid closure end date %q, use YYYY-MM-DD", closure.EndDate)
+		}
+		if end.Before(start) {
+			return fmt.Errorf("closure end date is before its start date")
+		}
+		if utf8.RuneCountInString(closure.Message) > maxClosureMessageRunes {
+			return fmt.Errorf("closure message must not exceed %d characters", maxClosureMessageRunes)
+		}
+	}
+	return nil
+}
+
+func isScheduleDay(day string) bool {
+	for _, d := range entities.ScheduleDays {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedRanges converts ranges to [open, close] minutes ordered by opening time
+func sortedRanges(ranges []entities.OpeningRange) ([][2]int, error) {
+	result := make([][2]int, 0, len(ranges))
+	for _, r := range ranges {
+		open, err := parseClockMinutes(r.Open)
+		if err != nil {
+			return nil, err
+		}
+		closing, err := parseClockMinutes(r.Close)
+		if err != nil {
+			return nil, err
+		}
+		if open >= closing {
+			return nil, fmt.Errorf("opening time %s must be before closing time %s", r.Open, r.Close)
+		}
+		result = append(result, [2]int{open, closing})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i][0] < result[j][0] })
+	return result, nil
+}
+
+// closureOn returns the closure covering a Bangkok date, if any
+func closureOn(closures []entities.ShopClosure, date time.Time) *entities.ShopClosure {
+	day := date.Format(closureDateLayout)
+	for i := range closures {
+		if closures[i].StartDate <= day && day <= closures[i].EndDate {
+			return &closures[i]
+		}
+	}
+	return nil
+}
+
+// shopOpenStatus works out whether the shop is open at now and, if not, when it
+// next opens. Closures are skipped as a whole, so the scan takes at most a week
+// per closure.
+func shopOpenStatus(shop *entities.ShopModel, now time.Time) entities.ShopOpenStatus {
+	now = now.In(bangkok)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, bangkok)
+	minuteOfDay := now.Hour()*60 + now.Minute()
+
+	var status entities.ShopOpenStatus
+	if closure := closureOn(shop.Closures, today); closure != nil {
+		status.ClosedMessage = closure.Message
+	}
+	if !hasOpeningHours(shop.OpeningHours) {
+		return status
+	}
+
+	lastDay := today.AddDate(0, 0, nextOpeningSearchDays)
+	for date := today; !date.After(lastDay); date = date.AddDate(0, 0, 1) {
+		if closure := closureOn(shop.Closures, date); closure != nil {
+			// Resume the day after the closure ends
+			if end, err := time.ParseInLocation(closureDateLayout, closure.EndDate, bangkok); err == nil && end.After(date) {
+				date = end
+			}
+			continue
+		}
+		// Stored schedules are validated, so ranges that fail to parse are skipped
+		ranges, err := sortedRanges(shop.OpeningHours[entities.ScheduleDay(date.Weekday())])
+		if err != nil {
+			continue
+		}
+		for _, r := range ranges {
+			if date.Equal(today) && r[0] <= minuteOfDay && minuteOfDay < r[1] {
+				status.IsOpenNow = true
+				return status
+			}
+			if date.After(today) || r[0] > minuteOfDay {
+				next := date.Add(time.Duration(r[0]) * time.Minute)
+				status.NextOpening = &next
+				return status
+			}
+		}
+	}
+	return status
+}
+
+// hasOpeningHours reports whether the schedule opens on any day at all
+func hasOpeningHours(schedule entities.WeeklySchedule) bool {
+	for _, ranges := range schedule {
+		if len(ranges) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// openNowQuery is the schedule day, HH:MM time and YYYY-MM-DD date a shop is
+// checked against to be open at now
+func openNowQuery(now time.Time) (day, clock, date string) {
+	now = now.In(bangkok)
+	return entities.ScheduleDay(now.Weekday()), now.Format("15:04"), now.Format(closureDateLayout)
+}
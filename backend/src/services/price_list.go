@@ -0,0 +1,433 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"recycle-waste-management-backend/src/domain/entities"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	// maxPriceListRows bounds an import, header excluded
+	maxPriceListRows = 1000
+	priceListSheet   = "Price list"
+)
+
+// utf8BOM lets spreadsheet programs open exported CSV files with Thai names intact
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// ExportPriceList writes the shop's items, ordered by category and name, as CSV or XLSX
+func (s *RecycleWasteService) ExportPriceList(shopID, format string) ([]byte, error) {
+	items, err := s.RecyclableItemsRepo.FindAllByShopID(shopID)
+	if err != nil {
+		return nil, err
+	}
+	sorted := append([]entities.RecyclableItemsModel{}, *items...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Category != sorted[j].Category {
+			return sorted[i].Category < sorted[j].Category
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	materialIDs := []string{}
+	for _, item := range sorted {
+		if item.MaterialID != "" {
+			materialIDs = append(materialIDs, item.MaterialID)
+		}
+	}
+	materials, err := s.GetMaterialsByIDs(materialIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{entities.PriceListColumns}
+	for _, item := range sorted {
+		rows = append(rows, []string{
+			item.WasteID,
+			item.Name,
+			item.Category,
+			materials[item.MaterialID].Code,
+			strconv.FormatFloat(item.Price, 'f', -1, 64),
+		})
+	}
+	return writePriceList(format, rows)
+}
+
+// ImportPriceList validates every row of a price list against the shop's items.
+// Rows with a waste_id, or named like an existing item, update that item; other
+// rows create items. Unless dryRun is set and only when no row has an error, all
+// changes are applied together and the changed prices recorded.
+func (s *RecycleWasteService) ImportPriceList(meta entities.RequestMeta, shopID, format string, file []byte, dryRun bool) (*entities.PriceListImportResult, error) {
+	rows, err := readPriceList(format, file)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("the price list is empty")
+	}
+	columns, err := priceListColumns(rows[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(rows)-1 > maxPriceListRows {
+		return nil, fmt.Errorf("a price list can have at most %d rows", maxPriceListRows)
+	}
+
+	importer, err := s.newPriceListImporter(shopID)
+	if err != nil {
+		return nil, err
+	}
+	for i, row := range rows[1:] {
+		importer.addRow(i+2, columns, row)
+	}
+
+	result := importer.result
+	result.ShopID = shopID
+	result.DryRun = dryRun
+	if dryRun || len(result.Errors) > 0 {
+		return &result, nil
+	}
+
+	written, atomic, err := s.RecyclableItemsRepo.ApplyPriceList(importer.creates, importer.updates)
+	if err != nil && atomic {
+		return nil, err
+	}
+	result.Atomic = atomic
+	result.Applied = err == nil
+
+	// Writes are applied creates first, then updates
+	for i := range importer.creates {
+		if i >= written {
+			break
+		}
+		change := &result.Changes[importer.createRows[i]]
+		change.WasteID = importer.creates[i].WasteID
+		result.WrittenRows = append(result.WrittenRows, change.Row)
+		recordPriceHistory(s.PriceHistory, &importer.creates[i], false)
+	}
+	for i := range importer.updates {
+		if len(importer.creates)+i >= written {
+			break
+		}
+		result.WrittenRows = append(result.WrittenRows, result.Changes[importer.updateRows[i]].Row)
+		recordPriceHistory(s.PriceHistory, &importer.updates[i], false)
+	}
+	if err != nil {
+		// Without a transaction the rows before the failing one stay imported
+		log.Printf("[PriceList] Import into shop %s stopped after %d of %d writes: %v", shopID, written, len(importer.creates)+len(importer.updates), err)
+		result.Errors = append(result.Errors, entities.PriceListRowError{
+			Row:     importer.writeRow(written),
+			Message: fmt.Sprintf("could not be saved, only the rows in written_rows were imported: %v", err),
+		})
+	}
+
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditPriceListImport,
+		ShopID:     shopID,
+		TargetType: "shop",
+		TargetID:   shopID,
+		After: map[string]interface{}{
+			"created":      result.Created,
+			"updated":      result.Updated,
+			"unchanged":    result.Unchanged,
+			"atomic":       result.Atomic,
+			"written_rows": result.WrittenRows,
+		},
+	})
+	return &result, nil
+}
+
+// priceListImporter checks rows one by one, collecting the changes to apply
+type priceListImporter struct {
+	service    *RecycleWasteService
+	shopID     string
+	now        time.Time
+	byWasteID  map[string]entities.RecyclableItemsModel
+	byName     map[string]entities.RecyclableItemsModel
+	categories map[string]bool // Category name to active
+	materials  map[string]string
+	seenItems  map[string]int // Item key to the row it first appeared on
+	creates    []entities.RecyclableItemsModel
+	createRows []int // Index in result.Changes of each create
+	updates    []entities.RecyclableItemsModel
+	updateRows []int // Index in result.Changes of each update
+	result     entities.PriceListImportResult
+}
+
+func (s *RecycleWasteService) newPriceListImporter(shopID string) (*priceListImporter, error) {
+	items, err := s.RecyclableItemsRepo.FindAllByShopID(shopID)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.CategoryWAsteRepo.FindAllIncludingInactive()
+	if err != nil {
+		return nil, err
+	}
+	materials, err := s.MaterialRepo.GetAll("")
+	if err != nil {
+		return nil, err
+	}
+
+	importer := &priceListImporter{
+		service:    s,
+		shopID:     shopID,
+		now:        time.Now().UTC().Add(7 * time.Hour),
+		byWasteID:  map[string]entities.RecyclableItemsModel{},
+		byName:     map[string]entities.RecyclableItemsModel{},
+		categories: map[string]bool{},
+		materials:  map[string]string{},
+		seenItems:  map[string]int{},
+		result: entities.PriceListImportResult{
+			Changes: []entities.PriceListChange{},
+			Errors:  []entities.PriceListRowError{},
+		},
+	}
+	for _, item := range *items {
+		importer.byWasteID[item.WasteID] = item
+		importer.byName[strings.ToLower(item.Name)] = item
+	}
+	for _, category := range *categories {
+		importer.categories[category.Name] = category.Active
+	}
+	for _, material := range *materials {
+		importer.materials[material.Code] = material.MaterialID
+	}
+	return importer, nil
+}
+
+func (p *priceListImporter) addRow(rowNumber int, columns map[string]int, row []string) {
+	cell := func(column string) string {
+		index, ok := columns[column]
+		if !ok || index >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[index])
+	}
+	if strings.TrimSpace(strings.Join(row, "")) == "" {
+		return
+	}
+	fail := func(column, format string, args ...interface{}) {
+		p.result.Errors = append(p.result.Errors, entities.PriceListRowError{Row: rowNumber, Column: column, Message: fmt.Sprintf(format, args...)})
+	}
+	errorCount := len(p.result.Errors)
+
+	wasteID, name, category := cell("waste_id"), cell("name"), cell("category")
+	var existing *entities.RecyclableItemsModel
+	if wasteID != "" {
+		item, ok := p.byWasteID[wasteID]
+		if !ok {
+			fail("waste_id", "item %s is not sold by this shop", wasteID)
+		} else {
+			existing = &item
+		}
+	} else if item, ok := p.byName[strings.ToLower(name)]; ok && name != "" {
+		existing = &item
+	}
+
+	if name == "" {
+		fail("name", "name is required")
+	}
+	itemKey := "name:" + strings.ToLower(name)
+	if existing != nil {
+		itemKey = "waste:" + existing.WasteID
+	}
+	if first, ok := p.seenItems[itemKey]; ok {
+		fail("", "same item as row %d", first)
+	} else if name != "" || existing != nil {
+		p.seenItems[itemKey] = rowNumber
+	}
+
+	active, known := p.categories[category]
+	switch {
+	case category == "":
+		fail("category", "category is required")
+	case !known:
+		fail("category", "unknown category %q", category)
+	case !active && (existing == nil || existing.Category != category):
+		fail("category", "category %q is no longer in use", category)
+	}
+
+	price, err := strconv.ParseFloat(strings.ReplaceAll(cell("price"), ",", ""), 64)
+	if err != nil || price <= 0 {
+		fail("price", "price must be a number above 0")
+	}
+
+	materialID := ""
+	if code := strings.ToUpper(cell("material_code")); code != "" {
+		id, ok := p.materials[code]
+		if !ok {
+			fail("material_code", "unknown material %s", code)
+		}
+		materialID = id
+	}
+
+	if len(p.result.Errors) > errorCount {
+		return
+	}
+
+	change := entities.PriceListChange{Row: rowNumber, Name: name, Category: category, Price: price}
+	if existing == nil {
+		item := entities.RecyclableItemsModel{
+			WasteID:    p.newWasteID(),
+			ShopID:     p.shopID,
+			MaterialID: materialID,
+			Name:       name,
+			Category:   category,
+			Price:      price,
+			LastUpdate: p.now,
+			URL:        p.service.ImageURLDefault,
+		}
+		if err := p.service.resolveMaterial(&item); err != nil {
+			fail("material_code", "%v", err)
+			return
+		}
+		change.Action = entities.PriceListCreate
+		change.MaterialID = item.MaterialID
+		p.creates = append(p.creates, item)
+		p.createRows = append(p.createRows, len(p.result.Changes))
+		p.result.Created++
+		p.result.Changes = append(p.result.Changes, change)
+		return
+	}
+
+	item := *existing
+	if materialID == "" {
+		materialID = item.MaterialID
+	}
+	change.WasteID = item.WasteID
+	change.MaterialID = materialID
+	change.OldPrice = item.Price
+	if item.Name == name && item.Category == category && item.Price == price && item.MaterialID == materialID {
+		change.Action = entities.PriceListUnchanged
+		p.result.Unchanged++
+	} else {
+		item.Name, item.Category, item.Price, item.MaterialID = name, category, price, materialID
+		item.LastUpdate = p.now
+		change.Action = entities.PriceListUpdate
+		p.updates = append(p.updates, item)
+		p.updateRows = append(p.updateRows, len(p.result.Changes))
+		p.result.Updated++
+	}
+	p.result.Changes = append(p.result.Changes, change)
+}
+
+// writeRow is the spreadsheet row of the n-th write, creates first
+func (p *priceListImporter) writeRow(n int) int {
+	if n < len(p.createRows) {
+		return p.result.Changes[p.createRows[n]].Row
+	}
+	if n -= len(p.createRows); n < len(p.updateRows) {
+		return p.result.Changes[p.updateRows[n]].Row
+	}
+	return 0
+}
+
+// newWasteID avoids the IDs of the shop's items and of rows already read,
+// as IDs generated in quick succession can repeat
+func (p *priceListImporter) newWasteID() string {
+	for {
+		id := generateRandomWasteID()
+		if _, taken := p.byWasteID[id]; taken {
+			continue
+		}
+		if _, taken := p.seenItems["waste:"+id]; taken {
+			continue
+		}
+		p.seenItems["waste:"+id] = 0
+		return id
+	}
+}
+
+// priceListColumns maps header names to column indexes; name, category and price are required
+func priceListColumns(header []string) (map[string]int, error) {
+	columns := map[string]int{}
+	for i, title := range header {
+		title = strings.ToLower(strings.TrimSpace(title))
+		if _, ok := columns[title]; title != "" && !ok {
+			columns[title] = i
+		}
+	}
+	for _, required := range []string{"name", "category", "price"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("the price list has no %q column", required)
+		}
+	}
+	return columns, nil
+}
+
+func readPriceList(format string, file []byte) ([][]string, error) {
+	switch format {
+	case entities.PriceListCSV:
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(file, utf8BOM)))
+		reader.FieldsPerRecord = -1
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("error reading CSV: %v", err)
+		}
+		return rows, nil
+	case entities.PriceListXLSX:
+		workbook, err := excelize.OpenReader(bytes.NewReader(file))
+		if err != nil {
+			return nil, fmt.Errorf("error reading XLSX: %v", err)
+		}
+		defer workbook.Close()
+		// The first sheet holds the price list, whatever it is called
+		rows, err := workbook.GetRows(workbook.GetSheetName(0))
+		if err != nil {
+			return nil, fmt.Errorf("error reading XLSX: %v", err)
+		}
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("format must be %s or %s", entities.PriceListCSV, entities.PriceListXLSX)
+	}
+}
+
+func writePriceList(format string, rows [][]string) ([]byte, error) {
+	switch format {
+	case entities.PriceListCSV:
+		var buf bytes.Buffer
+		buf.Write(utf8BOM)
+		writer := csv.NewWriter(&buf)
+		if err := writer.WriteAll(rows); err != nil {
+			return nil, fmt.Errorf("error writing CSV: %v", err)
+		}
+		return buf.Bytes(), nil
+	case entities.PriceListXLSX:
+		workbook := excelize.NewFile()
+		defer workbook.Close()
+		if err := workbook.SetSheetName(workbook.GetSheetName(0), priceListSheet); err != nil {
+			return nil, fmt.Errorf("error writing XLSX: %v", err)
+		}
+		for i, row := range rows {
+			values := make([]interface{}, len(row))
+			for j, value := range row {
+				values[j] = value
+			}
+			// Prices are written as numbers so they can be edited as such
+			if i > 0 {
+				if price, err := strconv.ParseFloat(row[len(row)-1], 64); err == nil {
+					values[len(row)-1] = price
+				}
+			}
+			cellName, _ := excelize.CoordinatesToCellName(1, i+1)
+			if err := workbook.SetSheetRow(priceListSheet, cellName, &values); err != nil {
+				return nil, fmt.Errorf("error writing XLSX: %v", err)
+			}
+		}
+		buf, err := workbook.WriteToBuffer()
+		if err != nil {
+			return nil, fmt.Errorf("error writing XLSX: %v", err)
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("format must be %s or %s", entities.PriceListCSV, entities.PriceListXLSX)
+	}
+}
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/domain/models"
+	"recycle-waste-management-backend/src/infrastructure/providers"
+	"recycle-waste-management-backend/src/repositories"
+	"time"
+
+	"github.com/goccy/go-json"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const privacyDocumentURLTTL = time.Hour
+
+var (
+	ErrPrivacyUserNotFound = errors.New("user not found")
+	// ErrErasureShopOwner is returned while the user still owns shops; a shop
+	// has its own customers and records and must be closed first
+	ErrErasureShopOwner = errors.New("delete your shops before erasing the account")
+)
+
+type IPrivacyService interface {
+	// ExportUserData collects everything stored about a user
+	ExportUserData(userID string) (*UserDataExport, error)
+	// ExportUserArchive is ExportUserData as a ZIP with one JSON file per section
+	ExportUserArchive(userID string) ([]byte, error)
+	// EraseUser deletes a user and their data. Receipts are kept for accounting;
+	// the requests they were issued for are anonymized instead of deleted.
+	EraseUser(meta entities.RequestMeta, userID string) error
+}
+
+// UserDataExport is the personal data archive handed out under PDPA
+type UserDataExport struct {
+	ExportedAt        time.Time                      `json:"exported_at"`
+	Profile           *entities.UserDataFormat       `json:"profile"`
+	Settings          *entities.UserSettings         `json:"settings"`
+	TwoFactorEnabled  bool                           `json:"two_factor_enabled"`
+	Sessions          *[]models.Session              `json:"sessions"`
+	Shops             *[]entities.ShopModel          `json:"shops"`
+	ShopVerifications *[]models.ShopVerification     `json:"shop_verifications"`
+	CustomerRequests  *[]models.CustomerRequestModel `json:"customer_requests"`
+	Receipts          []entities.Receipt             `json:"receipts"`
+	Reviews           []models.ReviewModel           `json:"reviews"`
+	ChatMessages      *[]models.ChatMessageModel     `json:"chat_messages"`
+}
+
+type privacyService struct {
+	UsersRepo            repositories.IUsersRepository
+	SettingsRepo         repositories.ISettingsRepository
+	CustomerRequestRepo  repositories.ICustomerRequestRepository
+	ReceiptRepo          repositories.IReceiptRepository
+	ReviewRepo           repositories.IReviewRepository
+	ChatMessageRepo      repositories.IChatMessageRepository
+	ShopRepo             repositories.IShopRepository
+	ShopVerificationRepo repositories.IShopVerificationRepository
+	SessionRepo          repositories.ISessionRepository
+	TwoFactorRepo        repositories.ITwoFactorRepository
+	SessionService       ISessionService
+	ImageService         IImageService
+	S3Provider           providers.IS3Provider
+	Audit                IAuditService
+}
+
+func NewPrivacyService(
+	usersRepo repositories.IUsersRepository,
+	settingsRepo repositories.ISettingsRepository,
+	customerRequestRepo repositories.ICustomerRequestRepository,
+	receiptRepo repositories.IReceiptRepository,
+	reviewRepo repositories.IReviewRepository,
+	chatMessageRepo repositories.IChatMessageRepository,
+	shopRepo repositories.IShopRepository,
+	shopVerificationRepo repositories.IShopVerificationRepository,
+	sessionRepo repositories.ISessionRepository,
+	twoFactorRepo repositories.ITwoFactorRepository,
+	sessionService ISessionService,
+	imageService IImageService,
+	audit IAuditService,
+) IPrivacyService {
+	return &privacyService{
+		UsersRepo:            usersRepo,
+		SettingsRepo:         settingsRepo,
+		CustomerRequestRepo:  customerRequestRepo,
+		ReceiptRepo:          receiptRepo,
+		ReviewRepo:           reviewRepo,
+		ChatMessageRepo:      chatMessageRepo,
+		ShopRepo:             shopRepo,
+		ShopVerificationRepo: shopVerificationRepo,
+		SessionRepo:          sessionRepo,
+		TwoFactorRepo:        twoFactorRepo,
+		SessionService:       sessionService,
+		ImageService:         imageService,
+		S3Provider:           providers.NewS3Provider(),
+		Audit:                audit,
+	}
+}
+
+func (s *privacyService) ExportUserData(userID string) (*UserDataExport, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	export := &UserDataExport{
+		ExportedAt: time.Now().UTC(),
+		Profile:    user,
+	}
+
+	if export.Settings, err = s.SettingsRepo.GetSettings(userID); err != nil {
+		return nil, err
+	}
+	twoFactor, err := s.TwoFactorRepo.GetBySubject(userID)
+	if err != nil && !errors.Is(err, repositories.ErrTwoFactorNotFound) {
+		return nil, err
+	}
+	export.TwoFactorEnabled = twoFactor != nil && twoFactor.Enabled
+	if export.Sessions, err = s.SessionRepo.GetAllBySubject(userID); err != nil {
+		return nil, err
+	}
+
+	if export.Shops, err = s.ShopRepo.GetAllByUserID(userID); err != nil {
+		return nil, err
+	}
+	if export.ShopVerifications, err = s.ShopVerificationRepo.GetByUserID(userID); err != nil {
+		return nil, err
+	}
+	// Documents are private; hand out links that expire instead of the S3 keys
+	for i := range *export.ShopVerifications {
+		documents := (*export.ShopVerifications)[i].Documents
+		for j := range documents {
+			if documents[j].URL, err = s.S3Provider.GetPresignedURL(documents[j].Key, privacyDocumentURLTTL); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if export.CustomerRequests, err = s.CustomerRequestRepo.GetCustomerRequests(userID); err != nil {
+		return nil, err
+	}
+	export.Receipts = []entities.Receipt{}
+	roomIDs := []string{}
+	for _, request := range *export.CustomerRequests {
+		roomIDs = append(roomIDs, request.CustomerRequestID)
+		receipt, err := s.ReceiptRepo.FindByCustomerRequestID(request.CustomerRequestID)
+		if err != nil {
+			return nil, err
+		}
+		if receipt != nil {
+			export.Receipts = append(export.Receipts, *receipt)
+		}
+	}
+
+	if export.Reviews, err = s.ReviewRepo.GetReviewsByUserID(context.Background(), userID); err != nil {
+		return nil, fmt.Errorf("error getting reviews: %v", err)
+	}
+	if export.ChatMessages, err = s.userChatMessages(userID, roomIDs); err != nil {
+		return nil, err
+	}
+	// Reports name the users who filed them
+	for i := range *export.ChatMessages {
+		(*export.ChatMessages)[i].Reports = nil
+	}
+	return export, nil
+}
+
+// userChatMessages returns the conversations of the user's requests and every
+// message the user sent in other rooms
+func (s *privacyService) userChatMessages(userID string, roomIDs []string) (*[]models.ChatMessageModel, error) {
+	messages, err := s.ChatMessageRepo.GetByRooms(roomIDs)
+	if err != nil {
+		return nil, err
+	}
+	sent, err := s.ChatMessageRepo.GetBySender(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, message := range *messages {
+		seen[message.MessageID] = true
+	}
+	for _, message := range *sent {
+		if !seen[message.MessageID] {
+			*messages = append(*messages, message)
+		}
+	}
+	return messages, nil
+}
+
+func (s *privacyService) ExportUserArchive(userID string) ([]byte, error) {
+	export, err := s.ExportUserData(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"settings.json", export.Settings},
+		{"security.json", map[string]interface{}{"two_factor_enabled": export.TwoFactorEnabled, "sessions": export.Sessions}},
+		{"shops.json", map[string]interface{}{"shops": export.Shops, "shop_verifications": export.ShopVerifications}},
+		{"customer_requests.json", export.CustomerRequests},
+		{"receipts.json", export.Receipts},
+		{"reviews.json", export.Reviews},
+		{"chat_messages.json", export.ChatMessages},
+	}
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for _, section := range sections {
+		data, err := json.MarshalIndent(section.data, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("error encoding %s: %v", section.name, err)
+		}
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: section.name, Method: zip.Deflate, Modified: export.ExportedAt})
+		if err != nil {
+			return nil, fmt.Errorf("error creating export archive: %v", err)
+		}
+		if _, err := file.Write(data); err != nil {
+			return nil, fmt.Errorf("error creating export archive: %v", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("error creating export archive: %v", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// EraseUser removes the user document last, so an erasure that fails halfway
+// can simply be run again
+func (s *privacyService) EraseUser(meta entities.RequestMeta, userID string) error {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	shops, err := s.ShopRepo.GetAllByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if len(*shops) > 0 {
+		return ErrErasureShopOwner
+	}
+
+	// Log out everywhere first so nothing is written for the user while erasing
+	if err := s.SessionService.LogoutAll(userID); err != nil {
+		return err
+	}
+
+	requests, err := s.CustomerRequestRepo.GetCustomerRequests(userID)
+	if err != nil {
+		return err
+	}
+	roomIDs := []string{}
+	for _, request := range *requests {
+		roomIDs = append(roomIDs, request.CustomerRequestID)
+	}
+
+	// Conversations of the user's requests go entirely; messages the user sent
+	// elsewhere are emptied so the other side keeps its thread
+	messages, err := s.userChatMessages(userID, roomIDs)
+	if err != nil {
+		return err
+	}
+	for _, message := range *messages {
+		if message.ImageURL != "" {
+			if err := s.ImageService.DeleteChatImage(message.ImageURL); err != nil {
+				log.Printf("[Privacy] Could not delete chat image %s: %v", message.ImageURL, err)
+			}
+		}
+	}
+	if err := s.ChatMessageRepo.DeleteByRooms(roomIDs); err != nil {
+		return err
+	}
+	if err := s.ChatMessageRepo.AnonymizeBySender(userID, entities.ErasedUserID); err != nil {
+		return err
+	}
+
+	// Ratings stay in the shop averages without the comment or the author
+	if err := s.ReviewRepo.AnonymizeByUserID(context.Background(), userID, entities.ErasedUserID); err != nil {
+		return fmt.Errorf("error anonymizing reviews: %v", err)
+	}
+
+	for _, request := range *requests {
+		receipt, err := s.ReceiptRepo.FindByCustomerRequestID(request.CustomerRequestID)
+		if err != nil {
+			return err
+		}
+		if receipt != nil {
+			err = s.CustomerRequestRepo.AnonymizeCustomerRequest(request.CustomerRequestID, entities.ErasedUserID)
+		} else {
+			err = s.CustomerRequestRepo.DeleteCustomerRequest(request.CustomerRequestID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	applications, err := s.ShopVerificationRepo.GetByUserID(userID)
+	if err != nil {
+		return err
+	}
+	for _, application := range *applications {
+		for _, document := range application.Documents {
+			if err := s.S3Provider.DeleteObject(document.Key); err != nil {
+				log.Printf("[Privacy] Could not delete verification document %s: %v", document.Key, err)
+			}
+		}
+	}
+	if err := s.ShopVerificationRepo.DeleteByUserID(userID); err != nil {
+		return err
+	}
+
+	if err := s.SettingsRepo.DeleteSettings(userID); err != nil {
+		return err
+	}
+	if err := s.TwoFactorRepo.Delete(userID); err != nil && !errors.Is(err, repositories.ErrTwoFactorNotFound) {
+		return err
+	}
+	if err := s.SessionRepo.DeleteAllBySubject(userID); err != nil {
+		return err
+	}
+
+	if user.ImageURL != "" {
+		if err := s.ImageService.DeleteProfileImage(user.ImageURL); err != nil {
+			log.Printf("[Privacy] Could not delete profile image of %s: %v", userID, err)
+		}
+	}
+	if err := s.UsersRepo.DeleteUser(userID); err != nil {
+		return err
+	}
+
+	// The audit log is append-only and keeps only the opaque user ID
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditUserErased,
+		TargetType: "user",
+		TargetID:   userID,
+	})
+	return nil
+}
+
+func (s *privacyService) getUser(userID string) (*entities.UserDataFormat, error) {
+	user, err := s.UsersRepo.GetUser(userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrPrivacyUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
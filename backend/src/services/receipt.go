@@ -4,16 +4,22 @@ import (
 	"fmt"
 	"recycle-waste-management-backend/src/domain/entities"
 	"recycle-waste-management-backend/src/repositories"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type IReceiptService interface {
-	CreateReceipt(userID string, req CreateReceiptRequest) (*entities.Receipt, error)
+	CreateReceipt(meta entities.RequestMeta, req CreateReceiptRequest) (*entities.Receipt, error)
+	VoidReceipt(meta entities.RequestMeta, receiptID string, reason string) (*entities.Receipt, error)
 	GetReceiptByCustomerRequestID(requestID string) (*ReceiptWithItemsResponse, error)
 	GetReceiptByID(receiptID string) (*ReceiptWithItemsResponse, error)
+	// IsReceiptCustomer reports whether the user made the customer request the receipt was issued for
+	IsReceiptCustomer(receipt *entities.Receipt, userID string) bool
 	GetReceiptsByShopID(shopID string) ([]entities.ReceiptWithDetails, error)
+	// GetOwnerReceipts consolidates the receipts of every shop the user owns
+	GetOwnerReceipts(ownerID string) (*entities.OwnerReceiptSummary, error)
 }
 
 type ReceiptWithItemsResponse struct {
@@ -46,6 +52,7 @@ type ReceiptService struct {
 	CustomerRequestRepo repositories.ICustomerRequestRepository
 	ShopRepo            repositories.IShopRepository  // Inject
 	UserRepo            repositories.IUsersRepository // Inject
+	Audit               IAuditService
 }
 
 func NewReceiptService(
@@ -56,6 +63,7 @@ func NewReceiptService(
 	customerRequestRepo repositories.ICustomerRequestRepository,
 	shopRepo repositories.IShopRepository, // Add param
 	userRepo repositories.IUsersRepository, // Add param
+	audit IAuditService,
 ) IReceiptService {
 	return &ReceiptService{
 		ReceiptRepo:         receiptRepo,
@@ -65,10 +73,22 @@ func NewReceiptService(
 		CustomerRequestRepo: customerRequestRepo,
 		ShopRepo:            shopRepo, // Assign
 		UserRepo:            userRepo, // Assign
+		Audit:               audit,
 	}
 }
 
-func (s *ReceiptService) CreateReceipt(userID string, req CreateReceiptRequest) (*entities.Receipt, error) {
+func (s *ReceiptService) CreateReceipt(meta entities.RequestMeta, req CreateReceiptRequest) (*entities.Receipt, error) {
+	shopID, err := s.authorizeReceiptShop(meta.Actor, req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+	req.ShopID = shopID
+	if req.CustomerRequestID != "" {
+		if err := s.checkReceiptRequest(req.CustomerRequestID, shopID); err != nil {
+			return nil, err
+		}
+	}
+
 	// 1. Calculate totals
 	var totalAmount float64
 	for _, item := range req.Items {
@@ -90,13 +110,6 @@ func (s *ReceiptService) CreateReceipt(userID string, req CreateReceiptRequest)
 
 	// Generate Receipt ID
 	receiptID := uuid.New().String()
-	userData, err := s.UserRepo.GetUser(userID)
-	if err != nil {
-		return nil, err
-	}
-	if userData.Role != string(entities.UserRoleModerator) && userData.Role != string(entities.UserRoleAdmin) {
-		return nil, fmt.Errorf("user is not authorized to create receipt")
-	}
 
 	receipt := &entities.Receipt{
 		ID:                receiptID,
@@ -150,17 +163,113 @@ func (s *ReceiptService) CreateReceipt(userID string, req CreateReceiptRequest)
 	// 4. Update Customer Request Status (if applicable)
 	if req.CustomerRequestID != "" {
 		if err := s.CustomerRequestRepo.CompleteCustomerRequest(req.CustomerRequestID, req.ShopID); err != nil {
-			// Log error but don't fail the receipt creation?
-			// Or fail? Let's log for now (in real world) but here we just return error or ignore.
-			// Let's print error for now.
-			// fmt.Printf("Error completing customer request: %v\n", err)
-			// Better to not fail the whole transaction if receipt is already created.
+			return nil, fmt.Errorf("receipt %s was created but its customer request could not be completed: %v", receipt.ID, err)
 		}
 	}
 
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditReceiptCreated,
+		ShopID:     receipt.ShopID,
+		TargetType: "receipt",
+		TargetID:   receipt.ID,
+		After:      receipt,
+	})
 	return receipt, nil
 }
 
+// VoidReceipt cancels a completed receipt and takes its items back out of stock
+func (s *ReceiptService) VoidReceipt(meta entities.RequestMeta, receiptID string, reason string) (*entities.Receipt, error) {
+	if reason == "" {
+		return nil, fmt.Errorf("void reason is required")
+	}
+	receipt, err := s.ReceiptRepo.FindByID(receiptID)
+	if err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, fmt.Errorf("receipt not found")
+	}
+	if _, err := s.authorizeReceiptShop(meta.Actor, receipt.ShopID); err != nil {
+		return nil, err
+	}
+
+	voidedAt := time.Now()
+	voided, err := s.ReceiptRepo.Void(receiptID, reason, voidedAt)
+	if err != nil {
+		return nil, err
+	}
+	if !voided {
+		return nil, fmt.Errorf("only completed receipts can be voided")
+	}
+
+	items, err := s.ReceiptItemRepo.FindByReceiptID(receiptID)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range *items {
+		if err := s.StockService.AddStock(receipt.ShopID, item.WasteID, -item.Weight); err != nil {
+			return nil, err
+		}
+	}
+
+	before := *receipt
+	receipt.Status = "voided"
+	receipt.VoidedAt = &voidedAt
+	receipt.VoidReason = reason
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditReceiptVoided,
+		ShopID:     receipt.ShopID,
+		TargetType: "receipt",
+		TargetID:   receipt.ID,
+		Before:     before,
+		After:      receipt,
+	})
+	return receipt, nil
+}
+
+// authorizeReceiptShop decides which shop a receipt is issued for. Employees and
+// shop owners can only issue receipts for their own shops; admins for any shop.
+// checkReceiptRequest makes sure a receipt is only linked to a customer request
+// its shop has accepted
+func (s *ReceiptService) checkReceiptRequest(customerRequestID string, shopID string) error {
+	request, err := s.CustomerRequestRepo.GetCustomerRequestByID(customerRequestID)
+	if err != nil {
+		return fmt.Errorf("customer request not found")
+	}
+	if request.ShopID != shopID {
+		return fmt.Errorf("customer request belongs to another shop")
+	}
+	return nil
+}
+
+func (s *ReceiptService) authorizeReceiptShop(actor entities.Actor, requestedShopID string) (string, error) {
+	if !actor.HasPermission(entities.PermissionReceiptCreate) {
+		return "", fmt.Errorf("access denied - missing permission %s", entities.PermissionReceiptCreate)
+	}
+	if actor.IsEmployee() {
+		if requestedShopID != "" && requestedShopID != actor.ShopID {
+			return "", fmt.Errorf("employee can only create receipts for their own shop")
+		}
+		return actor.ShopID, nil
+	}
+
+	if requestedShopID != "" && actor.HasPermission(entities.PermissionShopManageAny) {
+		return requestedShopID, nil
+	}
+	// A shop_id in the request picks the active shop of an owner with several shops
+	if requestedShopID != "" {
+		if actor.ActiveShopID != "" && actor.ActiveShopID != requestedShopID {
+			return "", fmt.Errorf("shop_id does not match the active shop")
+		}
+		actor.ActiveShopID = requestedShopID
+	}
+	shopID, err := ResolveActingShop(actor, s.ShopRepo)
+	if err != nil {
+		return "", err
+	}
+	return shopID, nil
+}
+
 func (s *ReceiptService) GetReceiptByCustomerRequestID(requestID string) (*ReceiptWithItemsResponse, error) {
 	// 1. Find receipt by customer_request_id
 	receipt, err := s.ReceiptRepo.FindByCustomerRequestID(requestID)
@@ -219,6 +328,14 @@ func (s *ReceiptService) GetReceiptByID(receiptID string) (*ReceiptWithItemsResp
 	}, nil
 }
 
+func (s *ReceiptService) IsReceiptCustomer(receipt *entities.Receipt, userID string) bool {
+	if receipt.CustomerRequestID == "" {
+		return false
+	}
+	request, err := s.CustomerRequestRepo.GetCustomerRequestByID(receipt.CustomerRequestID)
+	return err == nil && request.UserID == userID
+}
+
 func (s *ReceiptService) GetReceiptsByShopID(shopID string) ([]entities.ReceiptWithDetails, error) {
 	receipts, err := s.ReceiptRepo.FindByShopID(shopID)
 	if err != nil {
@@ -256,3 +373,38 @@ func (s *ReceiptService) GetReceiptsByShopID(shopID string) ([]entities.ReceiptW
 
 	return result, nil
 }
+
+func (s *ReceiptService) GetOwnerReceipts(ownerID string) (*entities.OwnerReceiptSummary, error) {
+	shops, err := s.ShopRepo.GetAllByUserID(ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &entities.OwnerReceiptSummary{
+		Shops:    []entities.ShopReceiptTotal{},
+		Receipts: []entities.OwnerReceipt{},
+	}
+	for _, shop := range *shops {
+		receipts, err := s.GetReceiptsByShopID(shop.ShopID)
+		if err != nil {
+			return nil, err
+		}
+
+		total := entities.ShopReceiptTotal{ShopID: shop.ShopID, ShopName: shop.Name}
+		for _, receipt := range receipts {
+			summary.Receipts = append(summary.Receipts, entities.OwnerReceipt{ReceiptWithDetails: receipt, ShopName: shop.Name})
+			if receipt.Status != "completed" {
+				continue
+			}
+			total.ReceiptCount++
+			total.TotalAmount += receipt.TotalAmount
+			total.NetTotal += receipt.NetTotal
+		}
+		summary.Shops = append(summary.Shops, total)
+	}
+
+	sort.SliceStable(summary.Receipts, func(i, j int) bool {
+		return summary.Receipts[i].CreatedAt.After(summary.Receipts[j].CreatedAt)
+	})
+	return summary, nil
+}
@@ -1,9 +1,12 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"math/rand"
 	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/domain/models"
 	"recycle-waste-management-backend/src/infrastructure/providers"
 	"recycle-waste-management-backend/src/repositories"
 	"strings"
@@ -14,26 +17,46 @@ import (
 
 type IRecycleWasteService interface {
 	GetRecyclableItems() (*[]entities.RecyclableItemsModel, error)
-	GetRecyclableItemsPaginated(page, limit int) (*[]entities.RecyclableItemsModel, int64, error)
-	GetRecyclableItemsByShopIDPaginated(shopID string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error)
-	GetRecyclableItemsByShopIDAndCategoryPaginated(shopID, category string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error)
+	// The paginated getters take an optional search over item names and categories
+	GetRecyclableItemsPaginated(search string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error)
+	GetRecyclableItemsByShopIDPaginated(shopID, search string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error)
+	GetRecyclableItemsByShopIDAndCategoryPaginated(shopID, category, search string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error)
+	// SuggestMaterialNames autocompletes a material name from the catalog and the names items are sold under
+	SuggestMaterialNames(query string, limit int) ([]entities.SearchSuggestion, error)
 	GetRecyclableItemByWasteID(wasteID string) (*entities.RecyclableItemsModel, error)
 	AddRecycleWaste(data entities.RecyclableItemsModel, image []byte) error
 	DeleteWasteItem(wasteID string) error
 	GetCategoryWaste() (*[]entities.CategoryWasteModel, error)
-	EditWasteItem(wasteID string, data entities.RecyclableItemsModel, image []byte) error
+	EditWasteItem(meta entities.RequestMeta, wasteID string, data entities.RecyclableItemsModel, image []byte) error
+	// GetPriceHistory returns the recorded changes of an item between two days, both inclusive
+	GetPriceHistory(wasteID string, from, to time.Time) (*[]models.PriceHistoryEntry, error)
+	// GetMarketPriceSeries returns the daily spread of a material's price across shops
+	// Pass a materialID to follow a catalog material, otherwise name and optional category
+	GetMarketPriceSeries(materialID, name, category string, from, to time.Time) ([]entities.MarketPricePoint, error)
+	// GetMaterialsByIDs returns catalog materials keyed by ID
+	GetMaterialsByIDs(materialIDs []string) (map[string]entities.MaterialModel, error)
+	// ExportPriceList writes a shop's items as a CSV or XLSX price list
+	ExportPriceList(shopID, format string) ([]byte, error)
+	// ImportPriceList previews or applies a CSV or XLSX price list for a shop
+	ImportPriceList(meta entities.RequestMeta, shopID, format string, file []byte, dryRun bool) (*entities.PriceListImportResult, error)
 }
 
+// maxPriceSeriesDays bounds the range of a price series request
+const maxPriceSeriesDays = 366
+
 type RecycleWasteService struct {
 	RecyclableItemsRepo repositories.IRecyclableItemsRepository
 	CategoryWAsteRepo   repositories.ICategoryWasteRepository
 	StockService        IStockService // Inject StockService
+	Audit               IAuditService
+	PriceHistory        repositories.IPriceHistoryRepository
+	MaterialRepo        repositories.IMaterialRepository
 	AwsS3               providers.IAwsS3Upload
 	ImageURLDefault     string
 }
 
-func NewRecycleWasteService(recyclableItemsRepo repositories.IRecyclableItemsRepository, CategoryWAsteRepo repositories.ICategoryWasteRepository, stockService IStockService) IRecycleWasteService {
-	return &RecycleWasteService{RecyclableItemsRepo: recyclableItemsRepo, CategoryWAsteRepo: CategoryWAsteRepo, StockService: stockService, AwsS3: providers.NewAwsS3(), ImageURLDefault: "https://bucketnaja2.s3.ap-southeast-1.amazonaws.com/images/wastes/DEFAULT.jpg"}
+func NewRecycleWasteService(recyclableItemsRepo repositories.IRecyclableItemsRepository, CategoryWAsteRepo repositories.ICategoryWasteRepository, stockService IStockService, audit IAuditService, priceHistory repositories.IPriceHistoryRepository, materialRepo repositories.IMaterialRepository) IRecycleWasteService {
+	return &RecycleWasteService{RecyclableItemsRepo: recyclableItemsRepo, CategoryWAsteRepo: CategoryWAsteRepo, StockService: stockService, Audit: audit, PriceHistory: priceHistory, MaterialRepo: materialRepo, AwsS3: providers.NewAwsS3(), ImageURLDefault: "https://bucketnaja2.s3.ap-southeast-1.amazonaws.com/images/wastes/DEFAULT.jpg"}
 }
 
 func (s *RecycleWasteService) GetRecyclableItems() (*[]entities.RecyclableItemsModel, error) {
@@ -47,8 +70,8 @@ func (s *RecycleWasteService) GetRecyclableItems() (*[]entities.RecyclableItemsM
 	return data, nil
 }
 
-func (s *RecycleWasteService) GetRecyclableItemsPaginated(page, limit int) (*[]entities.RecyclableItemsModel, int64, error) {
-	data, totalCount, err := s.RecyclableItemsRepo.FindAllPaginated(page, limit)
+func (s *RecycleWasteService) GetRecyclableItemsPaginated(search string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error) {
+	data, totalCount, err := s.RecyclableItemsRepo.FindAllPaginated(search, page, limit)
 	if err != nil && err != mongo.ErrNoDocuments {
 		return nil, 0, err
 	}
@@ -58,8 +81,8 @@ func (s *RecycleWasteService) GetRecyclableItemsPaginated(page, limit int) (*[]e
 	return data, totalCount, nil
 }
 
-func (s *RecycleWasteService) GetRecyclableItemsByShopIDPaginated(shopID string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error) {
-	data, totalCount, err := s.RecyclableItemsRepo.FindByShopIDPaginated(shopID, page, limit)
+func (s *RecycleWasteService) GetRecyclableItemsByShopIDPaginated(shopID, search string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error) {
+	data, totalCount, err := s.RecyclableItemsRepo.FindByShopIDPaginated(shopID, search, page, limit)
 	if err != nil && err != mongo.ErrNoDocuments {
 		return nil, 0, err
 	}
@@ -69,8 +92,8 @@ func (s *RecycleWasteService) GetRecyclableItemsByShopIDPaginated(shopID string,
 	return data, totalCount, nil
 }
 
-func (s *RecycleWasteService) GetRecyclableItemsByShopIDAndCategoryPaginated(shopID, category string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error) {
-	data, totalCount, err := s.RecyclableItemsRepo.FindByShopIDAndCategoryPaginated(shopID, category, page, limit)
+func (s *RecycleWasteService) GetRecyclableItemsByShopIDAndCategoryPaginated(shopID, category, search string, page, limit int) (*[]entities.RecyclableItemsModel, int64, error) {
+	data, totalCount, err := s.RecyclableItemsRepo.FindByShopIDAndCategoryPaginated(shopID, category, search, page, limit)
 	if err != nil && err != mongo.ErrNoDocuments {
 		return nil, 0, err
 	}
@@ -92,6 +115,9 @@ func (s *RecycleWasteService) AddRecycleWaste(data entities.RecyclableItemsModel
 	if data.Name == "" || data.Category == "" || data.Price == 0 {
 		return fmt.Errorf("invalid data")
 	}
+	if err := s.checkCategory(data.Category, ""); err != nil {
+		return err
+	}
 	// Validate shop_id if provided
 	if data.ShopID != "" {
 		// Additional validation can be added here
@@ -107,11 +133,15 @@ func (s *RecycleWasteService) AddRecycleWaste(data entities.RecyclableItemsModel
 	} else {
 		data.URL = s.ImageURLDefault
 	}
+	if err := s.resolveMaterial(&data); err != nil {
+		return err
+	}
 	data.LastUpdate = time.Now().UTC().Add(7 * time.Hour)
 	data.WasteID = generateRandomWasteID()
 	if err := s.RecyclableItemsRepo.Create(&data); err != nil {
 		return err
 	}
+	recordPriceHistory(s.PriceHistory, &data, false)
 
 	return nil
 }
@@ -127,6 +157,7 @@ func (s *RecycleWasteService) DeleteWasteItem(wasteID string) error {
 	if err := s.RecyclableItemsRepo.Delete(wasteID); err != nil {
 		return err
 	}
+	recordPriceHistory(s.PriceHistory, data, true)
 
 	// Delete associated stock
 	if err := s.StockService.DeleteStockByWasteID(wasteID); err != nil {
@@ -156,10 +187,47 @@ func (s *RecycleWasteService) GetCategoryWaste() (*[]entities.CategoryWasteModel
 	return data, nil
 }
 
-func (s *RecycleWasteService) EditWasteItem(wasteID string, data entities.RecyclableItemsModel, image []byte) error {
+// checkCategory requires an active category, except that an item may keep the one it already has
+func (s *RecycleWasteService) checkCategory(category, current string) error {
+	if category == current {
+		return nil
+	}
+	found, err := s.CategoryWAsteRepo.GetByName(category)
+	if err != nil {
+		if errors.Is(err, repositories.ErrCategoryNotFound) {
+			return fmt.Errorf("unknown category %q", category)
+		}
+		return err
+	}
+	if !found.Active {
+		return fmt.Errorf("category %q is no longer in use", category)
+	}
+	return nil
+}
+
+func (s *RecycleWasteService) EditWasteItem(meta entities.RequestMeta, wasteID string, data entities.RecyclableItemsModel, image []byte) error {
 	if data.Name == "" || data.Category == "" || data.Price == 0 {
 		return fmt.Errorf("invalid data")
 	}
+	before, err := s.RecyclableItemsRepo.FindByWasteID(wasteID)
+	if err != nil {
+		return err
+	}
+	// Keep the current image and hours unless they are replaced
+	data.URL = before.URL
+	if data.Hours == "" {
+		data.Hours = before.Hours
+	}
+	if err := s.checkCategory(data.Category, before.Category); err != nil {
+		return err
+	}
+	// A mapped item stays mapped unless another material is chosen
+	if data.MaterialID == "" {
+		data.MaterialID = before.MaterialID
+	}
+	if err := s.resolveMaterial(&data); err != nil {
+		return err
+	}
 	// Validate shop_id if provided
 	if data.ShopID != "" {
 		// Additional validation can be added here
@@ -177,9 +245,183 @@ func (s *RecycleWasteService) EditWasteItem(wasteID string, data entities.Recycl
 	if err := s.RecyclableItemsRepo.Update(wasteID, &data); err != nil {
 		return err
 	}
+
+	after, err := s.RecyclableItemsRepo.FindByWasteID(wasteID)
+	if err != nil {
+		return err
+	}
+	if after.Price != before.Price || after.Name != before.Name || after.Category != before.Category || after.MaterialID != before.MaterialID {
+		recordPriceHistory(s.PriceHistory, after, false)
+	}
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditWasteUpdated,
+		ShopID:     after.ShopID,
+		TargetType: "waste",
+		TargetID:   wasteID,
+		Before:     before,
+		After:      after,
+	})
+	return nil
+}
+
+func (s *RecycleWasteService) GetPriceHistory(wasteID string, from, to time.Time) (*[]models.PriceHistoryEntry, error) {
+	if err := validatePriceSeriesRange(from, to); err != nil {
+		return nil, err
+	}
+	return s.PriceHistory.GetByWasteID(wasteID, from, to.AddDate(0, 0, 1))
+}
+
+// GetMarketPriceSeries replays the history of every item ever sold under the
+// material, carrying each item's price forward, and summarizes it per day
+func (s *RecycleWasteService) GetMarketPriceSeries(materialID, name, category string, from, to time.Time) ([]entities.MarketPricePoint, error) {
+	if materialID == "" && name == "" {
+		return nil, fmt.Errorf("material_id or name is required")
+	}
+	if err := validatePriceSeriesRange(from, to); err != nil {
+		return nil, err
+	}
+	end := to.AddDate(0, 0, 1)
+
+	var wasteIDs []string
+	var err error
+	if materialID != "" {
+		wasteIDs, err = s.PriceHistory.GetWasteIDsByMaterialID(materialID, end)
+	} else {
+		wasteIDs, err = s.PriceHistory.GetWasteIDsByMaterial(name, category, end)
+	}
+	if err != nil {
+		return nil, err
+	}
+	points := []entities.MarketPricePoint{}
+	if len(wasteIDs) == 0 {
+		return points, nil
+	}
+	entries, err := s.PriceHistory.GetByWasteIDs(wasteIDs, end)
+	if err != nil {
+		return nil, err
+	}
+
+	// Latest entry per item as of the day being summarized
+	current := map[string]models.PriceHistoryEntry{}
+	next := 0
+	for day := from; day.Before(end); day = day.AddDate(0, 0, 1) {
+		dayEnd := day.AddDate(0, 0, 1)
+		for next < len(*entries) && (*entries)[next].ChangedAt.Before(dayEnd) {
+			entry := (*entries)[next]
+			current[entry.WasteID] = entry
+			next++
+		}
+
+		point := entities.MarketPricePoint{Date: day.Format("2006-01-02")}
+		total := 0.0
+		for _, entry := range current {
+			// An item renamed, recategorized or remapped away no longer counts
+			if entry.Removed {
+				continue
+			}
+			if materialID != "" && entry.MaterialID != materialID {
+				continue
+			}
+			if materialID == "" && (!strings.EqualFold(entry.Name, name) || (category != "" && entry.Category != category)) {
+				continue
+			}
+			if point.Shops == 0 || entry.Price < point.Min {
+				point.Min = entry.Price
+			}
+			if entry.Price > point.Max {
+				point.Max = entry.Price
+			}
+			total += entry.Price
+			point.Shops++
+		}
+		if point.Shops == 0 {
+			continue
+		}
+		point.Avg = math.Round(total/float64(point.Shops)*100) / 100
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+func (s *RecycleWasteService) GetMaterialsByIDs(materialIDs []string) (map[string]entities.MaterialModel, error) {
+	materials := map[string]entities.MaterialModel{}
+	if len(materialIDs) == 0 {
+		return materials, nil
+	}
+	found, err := s.MaterialRepo.GetByIDs(materialIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, material := range *found {
+		materials[material.MaterialID] = material
+	}
+	return materials, nil
+}
+
+// resolveMaterial checks the chosen catalog material, or picks the one the
+// item's name is known by. Items matching no material stay unmapped.
+func (s *RecycleWasteService) resolveMaterial(item *entities.RecyclableItemsModel) error {
+	if item.MaterialID != "" {
+		if _, err := s.MaterialRepo.GetByID(item.MaterialID); err != nil {
+			if isMaterialNotFound(err) {
+				return fmt.Errorf("material %s not found", item.MaterialID)
+			}
+			return err
+		}
+		return nil
+	}
+	material, err := s.MaterialRepo.FindByName(strings.TrimSpace(item.Name))
+	if err != nil {
+		if isMaterialNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	item.MaterialID = material.MaterialID
 	return nil
 }
 
+func validatePriceSeriesRange(from, to time.Time) error {
+	if to.Before(from) {
+		return fmt.Errorf("'to' must not be before 'from'")
+	}
+	if to.Sub(from) > maxPriceSeriesDays*24*time.Hour {
+		return fmt.Errorf("date range must not exceed %d days", maxPriceSeriesDays)
+	}
+	return nil
+}
+
+// recordPriceHistory appends a snapshot of the item; a failure is logged, the item change stands
+func recordPriceHistory(repo repositories.IPriceHistoryRepository, item *entities.RecyclableItemsModel, removed bool) {
+	entry := priceHistoryEntry(item, removed, time.Now().UTC().Add(7*time.Hour))
+	if err := repo.Create(&entry); err != nil {
+		fmt.Printf("Warning: Could not record price history of %s: %v\n", item.WasteID, err)
+	}
+}
+
+// priceHistoryEntries snapshots several items at the same moment
+func priceHistoryEntries(items *[]entities.RecyclableItemsModel, removed bool) []models.PriceHistoryEntry {
+	now := time.Now().UTC().Add(7 * time.Hour)
+	entries := make([]models.PriceHistoryEntry, 0, len(*items))
+	for i := range *items {
+		entries = append(entries, priceHistoryEntry(&(*items)[i], removed, now))
+	}
+	return entries
+}
+
+func priceHistoryEntry(item *entities.RecyclableItemsModel, removed bool, changedAt time.Time) models.PriceHistoryEntry {
+	return models.PriceHistoryEntry{
+		WasteID:    item.WasteID,
+		ShopID:     item.ShopID,
+		MaterialID: item.MaterialID,
+		Name:       item.Name,
+		Category:   item.Category,
+		Price:      item.Price,
+		Removed:    removed,
+		ChangedAt:  changedAt,
+	}
+}
+
 func generateRandomWasteID() string {
 	characters := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	rand.Seed(time.Now().UnixNano())
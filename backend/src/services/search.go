@@ -0,0 +1,90 @@
+package services
+
+import (
+	"recycle-waste-management-backend/src/domain/entities"
+	"sort"
+	"strings"
+)
+
+// maxSuggestions bounds an autocomplete request
+const maxSuggestions = 20
+
+func (s *RecycleWasteService) SuggestMaterialNames(query string, limit int) ([]entities.SearchSuggestion, error) {
+	if limit <= 0 || limit > maxSuggestions {
+		limit = maxSuggestions
+	}
+	query = strings.ToLower(strings.Join(strings.Fields(query), " "))
+	if query == "" {
+		return []entities.SearchSuggestion{}, nil
+	}
+
+	itemNames, err := s.RecyclableItemsRepo.SuggestNames(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	materials, err := s.MaterialRepo.GetAll("")
+	if err != nil {
+		return nil, err
+	}
+
+	codes := map[string]string{}
+	suggestions := []entities.SearchSuggestion{}
+	byName := map[string]int{}
+	for _, material := range *materials {
+		codes[material.MaterialID] = material.Code
+		// Each material is suggested once, under its best matching name
+		best := entities.SearchSuggestion{}
+		for _, name := range material.Names() {
+			if score := nameScore(name, query); score > best.Score {
+				best = entities.SearchSuggestion{Name: name, MaterialID: material.MaterialID, Code: material.Code, Score: score}
+			}
+		}
+		if best.Score > 0 {
+			byName[strings.ToLower(best.Name)] = len(suggestions)
+			suggestions = append(suggestions, best)
+		}
+	}
+	for _, item := range itemNames {
+		if i, ok := byName[strings.ToLower(item.Name)]; ok {
+			suggestions[i].Shops = item.Shops
+			continue
+		}
+		item.Code = codes[item.MaterialID]
+		suggestions = append(suggestions, item)
+	}
+
+	// Catalog names go before item names of the same relevance
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if (a.Code != "") != (b.Code != "") {
+			return a.Code != ""
+		}
+		if a.Shops != b.Shops {
+			return a.Shops > b.Shops
+		}
+		return a.Name < b.Name
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
+// nameScore ranks a name against a lowercased query the way item search does:
+// 3 for the whole name, 2 for a prefix, 1 for a substring, else 0
+func nameScore(name, query string) int {
+	name = strings.ToLower(name)
+	switch {
+	case name == query:
+		return 3
+	case strings.HasPrefix(name, query):
+		return 2
+	case strings.Contains(name, query):
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/domain/models"
+	"recycle-waste-management-backend/src/middlewares"
+	"recycle-waste-management-backend/src/repositories"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+type ISessionService interface {
+	CreateSession(actor entities.Actor, client entities.ClientInfo) (*entities.AuthTokens, error)
+	Refresh(refreshToken string, client entities.ClientInfo) (*entities.AuthTokens, error)
+	Logout(token *middlewares.TokenDetails) error
+	LogoutAll(subjectID string) error
+	GetSessions(token *middlewares.TokenDetails) (*[]entities.SessionResponse, error)
+	RevokeSession(subjectID string, sessionID string) error
+}
+
+type sessionService struct {
+	SessionRepo      repositories.ISessionRepository
+	RevokedTokenRepo repositories.IRevokedTokenRepository
+	EmployeeRepo     repositories.IEmployeeRepository
+}
+
+func NewSessionService(sessionRepo repositories.ISessionRepository, revokedTokenRepo repositories.IRevokedTokenRepository, employeeRepo repositories.IEmployeeRepository) ISessionService {
+	return &sessionService{
+		SessionRepo:      sessionRepo,
+		RevokedTokenRepo: revokedTokenRepo,
+		EmployeeRepo:     employeeRepo,
+	}
+}
+
+// refreshTokenTTL is how long a session survives without being refreshed, JWT_REFRESH_TOKEN_TTL (default 30 days)
+func refreshTokenTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("JWT_REFRESH_TOKEN_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return 30 * 24 * time.Hour
+}
+
+func (s *sessionService) CreateSession(actor entities.Actor, client entities.ClientInfo) (*entities.AuthTokens, error) {
+	refreshToken, refreshHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID := uuid.New().String()
+	access, err := middlewares.GenerateJWTToken(actor, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		SessionID:    sessionID,
+		SubjectID:    actor.ID,
+		SubjectType:  access.Type,
+		RefreshHash:  refreshHash,
+		AccessJTI:    access.JTI,
+		AccessExpiry: time.Unix(*access.ExpiresIn, 0),
+		Device:       client.Device,
+		IPAddress:    client.IPAddress,
+		CreatedAt:    now,
+		LastUsedAt:   now,
+		ExpiresAt:    now.Add(refreshTokenTTL()),
+	}
+	if err := s.SessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	return buildAuthTokens(access, refreshToken), nil
+}
+
+// Refresh exchanges a refresh token for a new access/refresh pair. The old
+// refresh token stops working; presenting it again is treated as theft and
+// ends the whole session.
+func (s *sessionService) Refresh(refreshToken string, client entities.ClientInfo) (*entities.AuthTokens, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+	hash := hashToken(refreshToken)
+
+	session, err := s.SessionRepo.GetByRefreshHash(hash)
+	if err != nil {
+		if reused, err := s.SessionRepo.GetByPreviousHash(hash); err == nil {
+			log.Printf("Refresh token reuse detected for session %s, revoking it", reused.SessionID)
+			_ = s.revoke(reused)
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	actor, err := s.actorForSession(session)
+	if err != nil {
+		_ = s.revoke(session)
+		return nil, ErrInvalidRefreshToken
+	}
+
+	newToken, newHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	access, err := middlewares.GenerateJWTToken(actor, session.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.SessionRepo.Rotate(session.SessionID, hash, newHash, access.JTI, time.Unix(*access.ExpiresIn, 0), client.IPAddress); err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// The access token issued before this refresh is superseded
+	if err := s.RevokedTokenRepo.Revoke(session.AccessJTI, session.AccessExpiry); err != nil {
+		log.Println("Error revoking superseded access token: ", err)
+	}
+
+	return buildAuthTokens(access, newToken), nil
+}
+
+// Logout ends the session the token belongs to and revokes the token itself
+func (s *sessionService) Logout(token *middlewares.TokenDetails) error {
+	if err := s.RevokedTokenRepo.Revoke(token.JTI, time.Unix(*token.ExpiresIn, 0)); err != nil {
+		return err
+	}
+	if token.SessionID == "" {
+		return nil
+	}
+
+	session, err := s.SessionRepo.GetByID(token.SessionID)
+	if err != nil {
+		return nil
+	}
+	return s.revoke(session)
+}
+
+// LogoutAll ends every session of the subject, including the current one
+func (s *sessionService) LogoutAll(subjectID string) error {
+	sessions, err := s.SessionRepo.GetActiveBySubject(subjectID)
+	if err != nil {
+		return err
+	}
+	for _, session := range *sessions {
+		if err := s.RevokedTokenRepo.Revoke(session.AccessJTI, session.AccessExpiry); err != nil {
+			return err
+		}
+	}
+	return s.SessionRepo.RevokeAllBySubject(subjectID)
+}
+
+func (s *sessionService) GetSessions(token *middlewares.TokenDetails) (*[]entities.SessionResponse, error) {
+	sessions, err := s.SessionRepo.GetActiveBySubject(token.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entities.SessionResponse, 0, len(*sessions))
+	for _, session := range *sessions {
+		result = append(result, entities.SessionResponse{
+			SessionID:  session.SessionID,
+			Device:     session.Device,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.SessionID == token.SessionID,
+		})
+	}
+	return &result, nil
+}
+
+func (s *sessionService) RevokeSession(subjectID string, sessionID string) error {
+	session, err := s.SessionRepo.GetByID(sessionID)
+	if err != nil || session.SubjectID != subjectID {
+		return errors.New("session not found")
+	}
+	return s.revoke(session)
+}
+
+// actorForSession rebuilds the token subject on refresh. Employees are reloaded
+// so changes to their shop or permissions apply from the next refresh on.
+func (s *sessionService) actorForSession(session *models.Session) (entities.Actor, error) {
+	if session.SubjectType != entities.ActorTypeEmployee {
+		return entities.Actor{ID: session.SubjectID, Type: entities.ActorTypeUser}, nil
+	}
+
+	employee, err := s.EmployeeRepo.GetEmployeeByID(session.SubjectID)
+	if err != nil {
+		return entities.Actor{}, err
+	}
+	return employee.Actor(), nil
+}
+
+func (s *sessionService) revoke(session *models.Session) error {
+	if err := s.RevokedTokenRepo.Revoke(session.AccessJTI, session.AccessExpiry); err != nil {
+		return err
+	}
+	return s.SessionRepo.Revoke(session.SessionID)
+}
+
+func buildAuthTokens(access *middlewares.TokenDetails, refreshToken string) *entities.AuthTokens {
+	return &entities.AuthTokens{
+		AccessToken:  *access.Token,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(middlewares.AccessTokenTTL().Seconds()),
+		SessionID:    access.SessionID,
+	}
+}
+
+// newRefreshToken returns an opaque random token and the hash that is stored in its place
+func newRefreshToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/domain/models"
+	"recycle-waste-management-backend/src/infrastructure/providers"
+	"recycle-waste-management-backend/src/repositories"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	maxVerificationDocuments    = 5
+	maxVerificationDocumentSize = 10 * 1024 * 1024 // 10MB
+	verificationDocumentURLTTL  = 15 * time.Minute
+	defaultVerificationDocType  = "business_document"
+)
+
+// Business documents may be scans (images) or PDFs; the content is sniffed, not trusted from the client
+var allowedVerificationContentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+// ErrShopVerificationNotFound is returned for unknown or already reviewed applications
+var ErrShopVerificationNotFound = errors.New("shop verification application not found")
+
+type IShopVerificationService interface {
+	Apply(userID string, req entities.ShopVerificationRequest, files []*multipart.FileHeader, documentTypes []string) (*models.ShopVerification, error)
+	GetMyApplication(userID string) (*models.ShopVerification, error)
+	GetApplications(status string, page, limit int) (*[]models.ShopVerification, int64, error)
+	GetApplication(applicationID string) (*models.ShopVerification, error)
+	Review(meta entities.RequestMeta, applicationID string, req entities.ReviewShopVerificationRequest) error
+}
+
+type shopVerificationService struct {
+	VerificationRepo repositories.IShopVerificationRepository
+	ShopRepo         repositories.IShopRepository
+	UsersRepo        repositories.IUsersRepository
+	S3Provider       providers.IS3Provider
+	Audit            IAuditService
+}
+
+func NewShopVerificationService(verificationRepo repositories.IShopVerificationRepository, shopRepo repositories.IShopRepository, usersRepo repositories.IUsersRepository, audit IAuditService) IShopVerificationService {
+	return &shopVerificationService{
+		VerificationRepo: verificationRepo,
+		ShopRepo:         shopRepo,
+		UsersRepo:        usersRepo,
+		S3Provider:       providers.NewS3Provider(),
+		Audit:            audit,
+	}
+}
+
+// Apply submits the caller's shop for verification. The shop has to exist
+// already, and only one application may be pending at a time.
+func (s *shopVerificationService) Apply(userID string, req entities.ShopVerificationRequest, files []*multipart.FileHeader, documentTypes []string) (*models.ShopVerification, error) {
+	req.BusinessName = strings.TrimSpace(req.BusinessName)
+	req.RegistrationNumber = strings.TrimSpace(req.RegistrationNumber)
+	req.ContactName = strings.TrimSpace(req.ContactName)
+	req.ContactPhone = strings.TrimSpace(req.ContactPhone)
+	req.Address = strings.TrimSpace(req.Address)
+	if req.BusinessName == "" || req.RegistrationNumber == "" || req.ContactName == "" || req.ContactPhone == "" || req.Address == "" {
+		return nil, fmt.Errorf("business_name, registration_number, contact_name, contact_phone and address are required")
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("at least one business document is required")
+	}
+	if len(files) > maxVerificationDocuments {
+		return nil, fmt.Errorf("at most %d documents can be uploaded", maxVerificationDocuments)
+	}
+
+	shop, err := s.applicationShop(userID, req.ShopID)
+	if err != nil {
+		return nil, err
+	}
+	if shop.Verified {
+		return nil, fmt.Errorf("shop is already verified")
+	}
+
+	latest, err := s.VerificationRepo.GetLatestByUserID(userID)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	if latest != nil && latest.Status == models.ShopVerificationPending {
+		return nil, fmt.Errorf("a verification application is already pending review")
+	}
+
+	documents := make([]models.VerificationDocument, 0, len(files))
+	for i, file := range files {
+		docType := defaultVerificationDocType
+		if i < len(documentTypes) && strings.TrimSpace(documentTypes[i]) != "" {
+			docType = strings.TrimSpace(documentTypes[i])
+		}
+		document, err := s.uploadDocument(userID, file, docType)
+		if err != nil {
+			s.deleteDocuments(documents)
+			return nil, err
+		}
+		documents = append(documents, *document)
+	}
+
+	now := time.Now()
+	application := &models.ShopVerification{
+		ApplicationID:      uuid.New().String(),
+		UserID:             userID,
+		ShopID:             shop.ShopID,
+		Status:             models.ShopVerificationPending,
+		BusinessName:       req.BusinessName,
+		RegistrationNumber: req.RegistrationNumber,
+		ContactName:        req.ContactName,
+		ContactPhone:       req.ContactPhone,
+		Address:            req.Address,
+		Note:               strings.TrimSpace(req.Note),
+		Documents:          documents,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	if err := s.VerificationRepo.Create(application); err != nil {
+		s.deleteDocuments(documents)
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("a verification application is already pending review")
+		}
+		return nil, err
+	}
+	return application, nil
+}
+
+func (s *shopVerificationService) uploadDocument(userID string, file *multipart.FileHeader, docType string) (*models.VerificationDocument, error) {
+	if file.Size > maxVerificationDocumentSize {
+		return nil, fmt.Errorf("document %s exceeds maximum size of %d bytes", file.Filename, maxVerificationDocumentSize)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded file: %v", err)
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, maxVerificationDocumentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file data: %v", err)
+	}
+	if len(data) > maxVerificationDocumentSize {
+		return nil, fmt.Errorf("document %s exceeds maximum size of %d bytes", file.Filename, maxVerificationDocumentSize)
+	}
+
+	contentType := strings.Split(http.DetectContentType(data), ";")[0]
+	ext, ok := allowedVerificationContentTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported document format for %s. Supported formats: JPG, PNG, PDF", file.Filename)
+	}
+
+	key, err := s.S3Provider.UploadPrivateFile(data, "document"+ext, contentType, entities.ShopVerificationFolder, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.VerificationDocument{
+		DocumentID:  uuid.New().String(),
+		Type:        docType,
+		Filename:    file.Filename,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Key:         key,
+		UploadedAt:  time.Now(),
+	}, nil
+}
+
+// applicationShop picks the applicant's shop to verify: the requested one, or
+// their only shop
+func (s *shopVerificationService) applicationShop(userID string, shopID string) (*entities.ShopModel, error) {
+	shops, err := s.ShopRepo.GetAllByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(*shops) == 0 {
+		return nil, fmt.Errorf("create your shop before applying for verification")
+	}
+	if shopID == "" {
+		if len(*shops) > 1 {
+			return nil, fmt.Errorf("shop_id is required when you own several shops")
+		}
+		return &(*shops)[0], nil
+	}
+	for i := range *shops {
+		if (*shops)[i].ShopID == shopID {
+			return &(*shops)[i], nil
+		}
+	}
+	return nil, fmt.Errorf("you don't own this shop")
+}
+
+func (s *shopVerificationService) deleteDocuments(documents []models.VerificationDocument) {
+	for _, document := range documents {
+		if err := s.S3Provider.DeleteObject(document.Key); err != nil {
+			log.Printf("[ShopVerification] Could not delete document %s: %v", document.Key, err)
+		}
+	}
+}
+
+func (s *shopVerificationService) GetMyApplication(userID string) (*models.ShopVerification, error) {
+	application, err := s.VerificationRepo.GetLatestByUserID(userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrShopVerificationNotFound
+		}
+		return nil, err
+	}
+	return application, nil
+}
+
+func (s *shopVerificationService) GetApplications(status string, page, limit int) (*[]models.ShopVerification, int64, error) {
+	if status == "" {
+		status = models.ShopVerificationPending
+	}
+	switch status {
+	case models.ShopVerificationPending, models.ShopVerificationApproved, models.ShopVerificationRejected:
+	default:
+		return nil, 0, fmt.Errorf("invalid status: %s", status)
+	}
+	return s.VerificationRepo.GetByStatus(status, page, limit)
+}
+
+// GetApplication returns an application for review, with presigned links to its documents
+func (s *shopVerificationService) GetApplication(applicationID string) (*models.ShopVerification, error) {
+	application, err := s.VerificationRepo.GetByID(applicationID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrShopVerificationNotFound
+		}
+		return nil, err
+	}
+
+	for i := range application.Documents {
+		url, err := s.S3Provider.GetPresignedURL(application.Documents[i].Key, verificationDocumentURLTTL)
+		if err != nil {
+			return nil, err
+		}
+		application.Documents[i].URL = url
+	}
+	return application, nil
+}
+
+// Review approves or rejects a pending application. Approval marks the shop
+// verified and grants the applicant the shop owner role.
+func (s *shopVerificationService) Review(meta entities.RequestMeta, applicationID string, req entities.ReviewShopVerificationRequest) error {
+	reviewerID := meta.Actor.ID
+	var status string
+	switch req.Action {
+	case "approve":
+		status = models.ShopVerificationApproved
+	case "reject":
+		status = models.ShopVerificationRejected
+		if strings.TrimSpace(req.Reason) == "" {
+			return fmt.Errorf("reason is required when rejecting")
+		}
+	default:
+		return fmt.Errorf("invalid action: %s", req.Action)
+	}
+
+	application, err := s.VerificationRepo.GetByID(applicationID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrShopVerificationNotFound
+		}
+		return err
+	}
+
+	if err := s.VerificationRepo.Review(applicationID, status, reviewerID, strings.TrimSpace(req.Reason)); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrShopVerificationNotFound
+		}
+		return err
+	}
+	if status != models.ShopVerificationApproved {
+		return nil
+	}
+
+	if err := s.ShopRepo.SetVerified(application.ShopID, true); err != nil {
+		return fmt.Errorf("error marking shop verified: %v", err)
+	}
+
+	user, err := s.UsersRepo.GetUser(application.UserID)
+	if err != nil {
+		return err
+	}
+	// Admins keep their role; owners verified before already have it
+	if user.Role == string(entities.UserRoleAdmin) || user.Role == string(entities.UserRoleModerator) {
+		return nil
+	}
+	if err := s.UsersRepo.UpdateUser(application.UserID, &entities.UserDataFormat{Role: string(entities.UserRoleModerator)}); err != nil {
+		return err
+	}
+
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditUserRoleChanged,
+		ShopID:     application.ShopID,
+		TargetType: "user",
+		TargetID:   application.UserID,
+		Before:     map[string]string{"role": user.Role},
+		After:      map[string]string{"role": string(entities.UserRoleModerator)},
+	})
+	return nil
+}
@@ -3,7 +3,9 @@ package services
 import (
 	"context"
 	"fmt"
+	"math"
 	"math/rand"
+	"net/url"
 	"recycle-waste-management-backend/src/domain/entities"
 	"recycle-waste-management-backend/src/infrastructure/providers"
 	"recycle-waste-management-backend/src/repositories"
@@ -14,29 +16,60 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// maxNearbyDistanceKm bounds the discovery search radius
+const maxNearbyDistanceKm = 100
+
 type IShopService interface {
 	CreateShop(userID string, data entities.CreateShopRequest, image []byte) error
 	GetShopByShopID(shopID string) (*entities.ShopResponse, error)
-	GetShopByUserID(userID string) (*entities.ShopModel, error)
-	GetAllShops(page, limit int) (*[]entities.ShopModel, int64, error)
-	UpdateShop(shopID string, data entities.UpdateShopRequest, image []byte) error
-	DeleteShop(shopID string) error
+	GetShopsByUserID(userID string) (*[]entities.ShopModel, error)
+	// GetActingShop returns the shop the actor acts for, see ResolveActingShop
+	GetActingShop(actor entities.Actor) (*entities.ShopModel, error)
+	// GetAllShops lists shops with their open status; openNow keeps only shops open right now
+	GetAllShops(page, limit int, openNow bool) (*[]entities.ShopListItem, int64, error)
+	UpdateShop(meta entities.RequestMeta, shopID string, data entities.UpdateShopRequest, image []byte) error
+	// PreviewShopDeletion reports what DeleteShop would do without changing anything
+	PreviewShopDeletion(shopID string) (*entities.ShopDeletionSummary, error)
+	// DeleteShop archives a shop with receipts and purges one without
+	DeleteShop(meta entities.RequestMeta, shopID string) (*entities.ShopDeletionSummary, error)
 	CheckShopCode(shopCode string) (bool, error)
+	// FindNearbyShops lists the shops matching the query, nearest first
+	FindNearbyShops(query entities.NearbyShopQuery, page, limit int) (*[]entities.NearbyShop, int64, error)
 }
 
 type ShopService struct {
 	ShopRepository   repositories.IShopRepository
 	ReviewRepository repositories.IReviewRepository
+	RecyclableItems  repositories.IRecyclableItemsRepository
+	ReceiptRepo      repositories.IReceiptRepository
+	StockRepo        repositories.IStockRepository
+	EmployeeRepo     repositories.IEmployeeRepository
+	APIKeyRepo       repositories.IAPIKeyRepository
+	SessionRepo      repositories.ISessionRepository
+	PriceHistory     repositories.IPriceHistoryRepository
+	Sessions         ISessionService
 	AwsS3            providers.IAwsS3Upload
 	ImageURLDefault  string
+	WasteImageURL    string // Default recyclable item image, never deleted
+	Audit            IAuditService
 }
 
-func NewShopService(shopRepo repositories.IShopRepository, reviewRepo repositories.IReviewRepository) IShopService {
+func NewShopService(shopRepo repositories.IShopRepository, reviewRepo repositories.IReviewRepository, recyclableItems repositories.IRecyclableItemsRepository, receiptRepo repositories.IReceiptRepository, stockRepo repositories.IStockRepository, employeeRepo repositories.IEmployeeRepository, apiKeyRepo repositories.IAPIKeyRepository, sessionRepo repositories.ISessionRepository, priceHistory repositories.IPriceHistoryRepository, sessions ISessionService, audit IAuditService) IShopService {
 	return &ShopService{
 		ShopRepository:   shopRepo,
 		ReviewRepository: reviewRepo,
+		RecyclableItems:  recyclableItems,
+		ReceiptRepo:      receiptRepo,
+		StockRepo:        stockRepo,
+		EmployeeRepo:     employeeRepo,
+		APIKeyRepo:       apiKeyRepo,
+		SessionRepo:      sessionRepo,
+		PriceHistory:     priceHistory,
+		Sessions:         sessions,
 		AwsS3:            providers.NewAwsS3(),
 		ImageURLDefault:  "https://bucketnaja2.s3.ap-southeast-1.amazonaws.com/images/shops/DEFAULT.jpg",
+		WasteImageURL:    "https://bucketnaja2.s3.ap-southeast-1.amazonaws.com/images/wastes/DEFAULT.jpg",
+		Audit:            audit,
 	}
 }
 
@@ -56,6 +89,13 @@ func (s *ShopService) CreateShop(userID string, data entities.CreateShopRequest,
 		return fmt.Errorf("shop code must contain only English letters, numbers, hyphens, and underscores")
 	}
 
+	if err := validateOpeningHours(data.OpeningHours); err != nil {
+		return err
+	}
+	if err := validateClosures(data.Closures); err != nil {
+		return err
+	}
+
 	// Check if shop_code already exists
 	existingShop, err := s.ShopRepository.GetByShopCode(data.ShopCode)
 	if err != nil && err != mongo.ErrNoDocuments {
@@ -65,35 +105,21 @@ func (s *ShopService) CreateShop(userID string, data entities.CreateShopRequest,
 		return fmt.Errorf("shop code already exists")
 	}
 
-	// Check if user already has a shop
-	_, err = s.ShopRepository.GetByUserID(userID)
-	if err != nil {
-		// If there's an error and it's not "no document found", return the error
-		if err != mongo.ErrNoDocuments {
-			return err
-		}
-		// If it's "no document found" error, it means user doesn't have a shop yet,
-		// so we continue with creating the shop
-	} else {
-		// If we found an existing shop (no error returned), return an error
-		return fmt.Errorf("user already has a shop")
-	}
-
 	shopModel := &entities.ShopModel{
-		ShopID:      generateRandomShopID(),
-		UserID:      userID,
-		ShopCode:    data.ShopCode,
-		Name:        data.Name,
-		Description: data.Description,
-		Address:     data.Address,
-		Phone:       data.Phone,
-		Email:       data.Email,
-		OpeningTime: data.OpeningTime,
-		ClosingTime: data.ClosingTime,
-		Latitude:    data.Latitude,
-		Longitude:   data.Longitude,
-		CreatedAt:   time.Now().UTC().Add(7 * time.Hour),
-		UpdatedAt:   time.Now().UTC().Add(7 * time.Hour),
+		ShopID:       generateRandomShopID(),
+		UserID:       userID,
+		ShopCode:     data.ShopCode,
+		Name:         data.Name,
+		Description:  data.Description,
+		Address:      data.Address,
+		Phone:        data.Phone,
+		Email:        data.Email,
+		OpeningHours: data.OpeningHours,
+		Closures:     data.Closures,
+		Latitude:     data.Latitude,
+		Longitude:    data.Longitude,
+		CreatedAt:    time.Now().UTC().Add(7 * time.Hour),
+		UpdatedAt:    time.Now().UTC().Add(7 * time.Hour),
 	}
 
 	if len(image) > 0 {
@@ -122,6 +148,9 @@ func (s *ShopService) GetShopByShopID(shopID string) (*entities.ShopResponse, er
 		}
 		return nil, err
 	}
+	if shop.ArchivedAt != nil {
+		return nil, fmt.Errorf("shop not found")
+	}
 
 	// Calculate rating
 	avgRating, totalReviews, err := s.ReviewRepository.GetShopRatingStats(context.Background(), shopID)
@@ -131,40 +160,70 @@ func (s *ShopService) GetShopByShopID(shopID string) (*entities.ShopResponse, er
 	}
 
 	return &entities.ShopResponse{
-		ShopModel:     *shop,
-		AverageRating: avgRating,
-		TotalReviews:  totalReviews,
+		ShopModel:      *shop,
+		AverageRating:  avgRating,
+		TotalReviews:   totalReviews,
+		ShopOpenStatus: shopOpenStatus(shop, time.Now()),
 	}, nil
 }
 
-func (s *ShopService) GetShopByUserID(userID string) (*entities.ShopModel, error) {
-	shop, err := s.ShopRepository.GetByUserID(userID)
-	fmt.Println("shop", shop)
+func (s *ShopService) GetShopsByUserID(userID string) (*[]entities.ShopModel, error) {
+	return s.ShopRepository.GetAllByUserID(userID)
+}
+
+func (s *ShopService) GetActingShop(actor entities.Actor) (*entities.ShopModel, error) {
+	shopID, err := ResolveActingShop(actor, s.ShopRepository)
+	if err != nil {
+		return nil, err
+	}
+	shop, err := s.ShopRepository.GetByShopID(shopID)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			// Fallback: Try to find shop_id from user document (for legacy data)
-			// This handles shops created before user_id field was added
-			// In this case, we'll return the error as we don't have access to UsersRepository here
-			return nil, fmt.Errorf("no shop found for this user")
+			return nil, fmt.Errorf("shop not found")
 		}
 		return nil, err
 	}
-
 	return shop, nil
 }
 
-func (s *ShopService) GetAllShops(page, limit int) (*[]entities.ShopModel, int64, error) {
+func (s *ShopService) GetAllShops(page, limit int, openNow bool) (*[]entities.ShopListItem, int64, error) {
+	now := time.Now()
+	if openNow {
+		return s.getShopsOpenAt(now, page, limit)
+	}
+
 	shops, totalCount, err := s.ShopRepository.GetAll(page, limit)
 	if err != nil && err != mongo.ErrNoDocuments {
 		return nil, 0, err
 	}
+	items := []entities.ShopListItem{}
 	if shops == nil {
-		return &[]entities.ShopModel{}, 0, nil
+		return &items, 0, nil
+	}
+	for i := range *shops {
+		shop := &(*shops)[i]
+		items = append(items, entities.ShopListItem{ShopModel: *shop, ShopOpenStatus: shopOpenStatus(shop, now)})
 	}
-	return shops, totalCount, nil
+	return &items, totalCount, nil
 }
 
-func (s *ShopService) UpdateShop(shopID string, data entities.UpdateShopRequest, image []byte) error {
+// getShopsOpenAt lets the database filter and page the shops open at now
+func (s *ShopService) getShopsOpenAt(now time.Time, page, limit int) (*[]entities.ShopListItem, int64, error) {
+	day, clock, date := openNowQuery(now)
+	shops, totalCount, err := s.ShopRepository.GetOpenAt(day, clock, date, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]entities.ShopListItem, 0, len(*shops))
+	for i := range *shops {
+		shop := &(*shops)[i]
+		items = append(items, entities.ShopListItem{ShopModel: *shop, ShopOpenStatus: shopOpenStatus(shop, now)})
+	}
+	return &items, totalCount, nil
+}
+
+func (s *ShopService) UpdateShop(meta entities.RequestMeta, shopID string, data entities.UpdateShopRequest, image []byte) error {
 	// Get existing shop
 	existingShop, err := s.ShopRepository.GetByShopID(shopID)
 	if err != nil {
@@ -173,6 +232,10 @@ func (s *ShopService) UpdateShop(shopID string, data entities.UpdateShopRequest,
 		}
 		return err
 	}
+	if existingShop.ArchivedAt != nil {
+		return fmt.Errorf("shop is archived")
+	}
+	before := *existingShop
 
 	// Check if shop_code is being updated and if it's unique
 	if data.ShopCode != nil {
@@ -214,11 +277,17 @@ func (s *ShopService) UpdateShop(shopID string, data entities.UpdateShopRequest,
 	if data.Email != nil {
 		existingShop.Email = *data.Email
 	}
-	if data.OpeningTime != nil {
-		existingShop.OpeningTime = *data.OpeningTime
+	if data.OpeningHours != nil {
+		if err := validateOpeningHours(*data.OpeningHours); err != nil {
+			return err
+		}
+		existingShop.OpeningHours = *data.OpeningHours
 	}
-	if data.ClosingTime != nil {
-		existingShop.ClosingTime = *data.ClosingTime
+	if data.Closures != nil {
+		if err := validateClosures(*data.Closures); err != nil {
+			return err
+		}
+		existingShop.Closures = *data.Closures
 	}
 	if data.Latitude != nil {
 		existingShop.Latitude = *data.Latitude
@@ -241,34 +310,232 @@ func (s *ShopService) UpdateShop(shopID string, data entities.UpdateShopRequest,
 	if err := s.ShopRepository.Update(shopID, existingShop); err != nil {
 		return err
 	}
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditShopUpdated,
+		ShopID:     shopID,
+		TargetType: "shop",
+		TargetID:   shopID,
+		Before:     before,
+		After:      existingShop,
+	})
 
 	return nil
 }
 
-func (s *ShopService) DeleteShop(shopID string) error {
+func (s *ShopService) PreviewShopDeletion(shopID string) (*entities.ShopDeletionSummary, error) {
 	shop, err := s.ShopRepository.GetByShopID(shopID)
 	if err != nil && err != mongo.ErrNoDocuments {
-		return err
+		return nil, err
 	}
 	if shop == nil {
-		return fmt.Errorf("shop not found")
+		return nil, fmt.Errorf("shop not found")
+	}
+	if shop.ArchivedAt != nil {
+		return nil, fmt.Errorf("shop is already archived")
 	}
 
-	if err := s.ShopRepository.Delete(shopID); err != nil {
+	receipts, err := s.ReceiptRepo.CountByShopID(shopID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.RecyclableItems.FindAllByShopID(shopID)
+	if err != nil {
+		return nil, err
+	}
+	stocks, err := s.StockRepo.GetStocksByShopID(shopID)
+	if err != nil {
+		return nil, err
+	}
+	employees, err := s.EmployeeRepo.GetAllEmployeesByShopID(shopID)
+	if err != nil {
+		return nil, err
+	}
+	apiKeys, err := s.APIKeyRepo.GetByShopID(shopID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &entities.ShopDeletionSummary{
+		ShopID:          shop.ShopID,
+		ShopName:        shop.Name,
+		OwnerID:         shop.UserID,
+		Action:          entities.ShopDeletionPurge,
+		DryRun:          true,
+		Receipts:        receipts,
+		RecyclableItems: len(*items),
+		Stocks:          len(stocks),
+		Employees:       len(employees),
+		APIKeys:         len(*apiKeys),
+	}
+	if receipts > 0 {
+		summary.Action = entities.ShopDeletionArchive
+		return summary, nil
+	}
+	imageKeys, err := s.purgeableImageKeys(shop, *items)
+	if err != nil {
+		return nil, err
+	}
+	summary.Images = len(imageKeys)
+	return summary, nil
+}
+
+func (s *ShopService) DeleteShop(meta entities.RequestMeta, shopID string) (*entities.ShopDeletionSummary, error) {
+	summary, err := s.PreviewShopDeletion(shopID)
+	if err != nil {
+		return nil, err
+	}
+	summary.DryRun = false
+	if summary.Action == entities.ShopDeletionArchive {
+		return summary, s.archiveShop(meta, shopID)
+	}
+	return summary, s.purgeShop(meta, shopID)
+}
+
+// archiveShop hides a shop that has financial history. Its records stay, but
+// nobody can act for it any more: employees are logged out and keys revoked.
+func (s *ShopService) archiveShop(meta entities.RequestMeta, shopID string) error {
+	if err := s.ShopRepository.Archive(shopID, time.Now().UTC().Add(7*time.Hour)); err != nil {
+		return err
+	}
+	items, err := s.RecyclableItems.FindAllByShopID(shopID)
+	if err != nil {
+		return err
+	}
+	// The items leave the market, so their prices stop counting from now on
+	if err := s.PriceHistory.CreateMany(priceHistoryEntries(items, true)); err != nil {
+		return err
+	}
+	if err := s.RecyclableItems.ArchiveByShopID(shopID); err != nil {
+		return err
+	}
+	if err := s.APIKeyRepo.RevokeAllByShopID(shopID); err != nil {
+		return err
+	}
+	employees, err := s.EmployeeRepo.GetAllEmployeesByShopID(shopID)
+	if err != nil {
+		return err
+	}
+	for _, employee := range employees {
+		if err := s.Sessions.LogoutAll(employee.EmployeeID); err != nil {
+			return fmt.Errorf("error logging out employee %s: %v", employee.EmployeeID, err)
+		}
+	}
+
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditShopArchived,
+		ShopID:     shopID,
+		TargetType: "shop",
+		TargetID:   shopID,
+	})
+	return nil
+}
+
+// purgeShop removes a shop without financial history and everything that belongs to it
+func (s *ShopService) purgeShop(meta entities.RequestMeta, shopID string) error {
+	shop, err := s.ShopRepository.GetByShopID(shopID)
+	if err != nil {
+		return err
+	}
+	items, err := s.RecyclableItems.FindAllByShopID(shopID)
+	if err != nil {
+		return err
+	}
+	// Work out the images before their documents are gone
+	imageKeys, err := s.purgeableImageKeys(shop, *items)
+	if err != nil {
 		return err
 	}
 
-	// Delete image from S3 if it's not the default image
-	if shop.ImageURL != s.ImageURLDefault {
-		keyName := fmt.Sprintf("images/shops/%v.webp", strings.ToUpper(shop.Name))
-		if err := s.AwsS3.DeleteS3(keyName); err != nil {
+	employees, err := s.EmployeeRepo.GetAllEmployeesByShopID(shopID)
+	if err != nil {
+		return err
+	}
+	for _, employee := range employees {
+		if err := s.Sessions.LogoutAll(employee.EmployeeID); err != nil {
+			return fmt.Errorf("error logging out employee %s: %v", employee.EmployeeID, err)
+		}
+		if err := s.SessionRepo.DeleteAllBySubject(employee.EmployeeID); err != nil {
 			return err
 		}
 	}
-
+	if err := s.EmployeeRepo.DeleteEmployeesByShopID(shopID); err != nil {
+		return err
+	}
+	if err := s.APIKeyRepo.DeleteByShopID(shopID); err != nil {
+		return err
+	}
+	if err := s.StockRepo.DeleteByShopID(shopID); err != nil {
+		return err
+	}
+	if err := s.RecyclableItems.DeleteByShopID(shopID); err != nil {
+		return err
+	}
+	if err := s.PriceHistory.DeleteByShopID(shopID); err != nil {
+		return err
+	}
+	if err := s.ReviewRepository.DeleteByShopID(context.Background(), shopID); err != nil {
+		return fmt.Errorf("error deleting reviews of shop: %v", err)
+	}
+	if err := s.ShopRepository.Delete(shopID); err != nil {
+		return err
+	}
+	s.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditShopDeleted,
+		ShopID:     shopID,
+		TargetType: "shop",
+		TargetID:   shopID,
+		Before:     shop,
+	})
+
+	// The data is gone, so a leftover image is only logged
+	for _, key := range imageKeys {
+		if err := s.AwsS3.DeleteS3(key); err != nil {
+			fmt.Printf("Warning: Could not delete image %s of shop %s: %v\n", key, shopID, err)
+		}
+	}
 	return nil
 }
 
+// purgeableImageKeys lists the S3 keys of the images of a shop and its items.
+// Images are keyed by name, so one still used elsewhere is kept.
+func (s *ShopService) purgeableImageKeys(shop *entities.ShopModel, items []entities.RecyclableItemsModel) ([]string, error) {
+	keys := []string{}
+	seen := map[string]bool{}
+	if shop.ImageURL != "" && shop.ImageURL != s.ImageURLDefault {
+		others, err := s.ShopRepository.CountOtherByImageURL(shop.ImageURL, shop.ShopID)
+		if err != nil {
+			return nil, err
+		}
+		if key := s3KeyFromURL(shop.ImageURL); others == 0 && key != "" {
+			keys = append(keys, key)
+			seen[shop.ImageURL] = true
+		}
+	}
+	for _, item := range items {
+		if item.URL == "" || item.URL == s.WasteImageURL || seen[item.URL] {
+			continue
+		}
+		seen[item.URL] = true
+		others, err := s.RecyclableItems.CountOtherByURL(item.URL, shop.ShopID)
+		if err != nil {
+			return nil, err
+		}
+		if key := s3KeyFromURL(item.URL); others == 0 && key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// s3KeyFromURL returns the object key of a public S3 URL
+func s3KeyFromURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || !strings.HasSuffix(parsed.Host, ".amazonaws.com") {
+		return ""
+	}
+	return strings.TrimPrefix(parsed.Path, "/")
+}
+
 func (s *ShopService) CheckShopCode(shopCode string) (bool, error) {
 	shop, err := s.ShopRepository.GetByShopCode(shopCode)
 	if err != nil {
@@ -283,6 +550,88 @@ func (s *ShopService) CheckShopCode(shopCode string) (bool, error) {
 	return true, nil
 }
 
+func (s *ShopService) FindNearbyShops(query entities.NearbyShopQuery, page, limit int) (*[]entities.NearbyShop, int64, error) {
+	if query.Latitude < -90 || query.Latitude > 90 || query.Longitude < -180 || query.Longitude > 180 {
+		return nil, 0, fmt.Errorf("invalid coordinates")
+	}
+	if query.MaxDistanceKm <= 0 || query.MaxDistanceKm > maxNearbyDistanceKm {
+		return nil, 0, fmt.Errorf("max distance must be between 0 and %v km", maxNearbyDistanceKm)
+	}
+
+	// With a material, waste name or category, only shops that buy it are searched
+	var shopIDs []string
+	prices := map[string][]entities.MaterialPrice{}
+	if query.MaterialID != "" || query.Material != "" || query.Category != "" {
+		items, err := s.RecyclableItems.FindByMaterial(query.MaterialID, query.Material, query.Category)
+		if err != nil {
+			return nil, 0, err
+		}
+		shopIDs = []string{}
+		for _, item := range *items {
+			if _, seen := prices[item.ShopID]; !seen {
+				shopIDs = append(shopIDs, item.ShopID)
+			}
+			prices[item.ShopID] = append(prices[item.ShopID], entities.MaterialPrice{
+				WasteID:    item.WasteID,
+				MaterialID: item.MaterialID,
+				Name:       item.Name,
+				Category:   item.Category,
+				Price:      item.Price,
+			})
+		}
+		if len(shopIDs) == 0 {
+			return &[]entities.NearbyShop{}, 0, nil
+		}
+	}
+
+	found, err := s.ShopRepository.FindNearby(query.Latitude, query.Longitude, query.MaxDistanceKm*1000, shopIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	foundIDs := make([]string, 0, len(*found))
+	for _, shop := range *found {
+		foundIDs = append(foundIDs, shop.ShopID)
+	}
+	ratings, err := s.ReviewRepository.GetRatingStatsByShopIDs(context.Background(), foundIDs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error calculating shop ratings: %v", err)
+	}
+
+	now := time.Now()
+	matches := []entities.NearbyShop{}
+	for i := range *found {
+		shop := &(*found)[i]
+		rating := ratings[shop.ShopID]
+		if rating.AverageRating < query.MinRating {
+			continue
+		}
+		status := shopOpenStatus(&shop.ShopModel, now)
+		if query.OpenNow && !status.IsOpenNow {
+			continue
+		}
+		matches = append(matches, entities.NearbyShop{
+			ShopModel:      shop.ShopModel,
+			DistanceKm:     math.Round(shop.DistanceMeters) / 1000,
+			AverageRating:  rating.AverageRating,
+			TotalReviews:   rating.TotalReviews,
+			Prices:         prices[shop.ShopID],
+			ShopOpenStatus: status,
+		})
+	}
+
+	total := int64(len(matches))
+	start := (page - 1) * limit
+	if start >= len(matches) {
+		return &[]entities.NearbyShop{}, total, nil
+	}
+	end := start + limit
+	if end > len(matches) {
+		end = len(matches)
+	}
+	results := matches[start:end]
+	return &results, total, nil
+}
+
 func generateRandomShopID() string {
 	characters := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	rand.Seed(time.Now().UnixNano())
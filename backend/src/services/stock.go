@@ -9,17 +9,21 @@ type IStockService interface {
 	AddStock(shopID, wasteID string, quantity float64) error
 	DeleteStockByWasteID(wasteID string) error
 	GetStocksByShopID(shopID string) ([]entities.StockWithDetails, error)
+	// GetOwnerStocks consolidates the stock of every shop the user owns
+	GetOwnerStocks(ownerID string) (*entities.OwnerStockSummary, error)
 }
 
 type StockService struct {
 	StockRepo           repositories.IStockRepository
 	RecyclableItemsRepo repositories.IRecyclableItemsRepository
+	ShopRepo            repositories.IShopRepository
 }
 
-func NewStockService(stockRepo repositories.IStockRepository, recyclableItemsRepo repositories.IRecyclableItemsRepository) IStockService {
+func NewStockService(stockRepo repositories.IStockRepository, recyclableItemsRepo repositories.IRecyclableItemsRepository, shopRepo repositories.IShopRepository) IStockService {
 	return &StockService{
 		StockRepo:           stockRepo,
 		RecyclableItemsRepo: recyclableItemsRepo,
+		ShopRepo:            shopRepo,
 	}
 }
 
@@ -91,3 +95,45 @@ func (s *StockService) GetStocksByShopID(shopID string) ([]entities.StockWithDet
 
 	return result, nil
 }
+
+func (s *StockService) GetOwnerStocks(ownerID string) (*entities.OwnerStockSummary, error) {
+	shops, err := s.ShopRepo.GetAllByUserID(ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &entities.OwnerStockSummary{
+		Shops:  []entities.ShopStock{},
+		Totals: []entities.StockTotal{},
+	}
+	totalIndex := map[string]int{}
+	for _, shop := range *shops {
+		stocks, err := s.GetStocksByShopID(shop.ShopID)
+		if err != nil {
+			return nil, err
+		}
+		if stocks == nil {
+			stocks = []entities.StockWithDetails{}
+		}
+		summary.Shops = append(summary.Shops, entities.ShopStock{
+			ShopID:   shop.ShopID,
+			ShopName: shop.Name,
+			Stocks:   stocks,
+		})
+
+		// Each shop has its own waste items, so branches are matched by category and name
+		for _, stock := range stocks {
+			key := stock.Category + "\x00" + stock.Name
+			i, ok := totalIndex[key]
+			if !ok {
+				i = len(summary.Totals)
+				totalIndex[key] = i
+				summary.Totals = append(summary.Totals, entities.StockTotal{Category: stock.Category, Name: stock.Name})
+			}
+			summary.Totals[i].Quantity += stock.Quantity
+			summary.Totals[i].Value += stock.Quantity * stock.CurrentPrice
+			summary.Totals[i].Profit += stock.Profit
+		}
+	}
+	return summary, nil
+}
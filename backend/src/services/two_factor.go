@@ -0,0 +1,560 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/domain/models"
+	"recycle-waste-management-backend/src/repositories"
+	"strings"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// TOTP parameters (RFC 6238 defaults, understood by every authenticator app)
+const (
+	totpPeriod          = 30
+	totpDigits          = 6
+	totpSkew            = 1 // Steps accepted either side of now, for clock drift
+	totpSecretSize      = 20
+	recoveryCodeCount   = 10
+	loginChallengeTTL   = 5 * time.Minute
+	twoFactorFreeTries  = 5
+	twoFactorLockout    = 15 * time.Minute
+	twoFactorFailWindow = 15 * time.Minute
+)
+
+var (
+	ErrInvalidMFAToken        = errors.New("login expired, please log in again")
+	ErrTwoFactorCodeInvalid   = errors.New("invalid two-factor code")
+	ErrTwoFactorRequired      = errors.New("two-factor authentication is required for this account")
+	ErrTwoFactorNotEnabled    = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorAlreadyActive = errors.New("two-factor authentication is already enabled")
+)
+
+type ITwoFactorService interface {
+	// Login opens a session for an actor that passed the first factor, or
+	// returns a challenge when a second factor is needed
+	Login(actor entities.Actor, client entities.ClientInfo) (*entities.LoginResult, error)
+	VerifyLogin(mfaToken string, code string, client entities.ClientInfo) (*entities.TwoFactorLoginResponse, error)
+	SetupForLogin(mfaToken string) (*entities.TwoFactorSetupResponse, error)
+	GetStatus(actor entities.Actor) (*entities.TwoFactorStatus, error)
+	Setup(actor entities.Actor) (*entities.TwoFactorSetupResponse, error)
+	Enable(actor entities.Actor, code string) ([]string, error)
+	Disable(actor entities.Actor, code string) error
+	RegenerateRecoveryCodes(actor entities.Actor, code string) ([]string, error)
+}
+
+type twoFactorService struct {
+	TwoFactorRepo  repositories.ITwoFactorRepository
+	ChallengeRepo  repositories.ILoginChallengeRepository
+	LoginAttempts  repositories.ILoginAttemptRepository
+	UserRepo       repositories.IUsersRepository
+	EmployeeRepo   repositories.IEmployeeRepository
+	SessionService ISessionService
+}
+
+func NewTwoFactorService(twoFactorRepo repositories.ITwoFactorRepository, challengeRepo repositories.ILoginChallengeRepository, loginAttempts repositories.ILoginAttemptRepository, userRepo repositories.IUsersRepository, employeeRepo repositories.IEmployeeRepository, sessionService ISessionService) ITwoFactorService {
+	return &twoFactorService{
+		TwoFactorRepo:  twoFactorRepo,
+		ChallengeRepo:  challengeRepo,
+		LoginAttempts:  loginAttempts,
+		UserRepo:       userRepo,
+		EmployeeRepo:   employeeRepo,
+		SessionService: sessionService,
+	}
+}
+
+func (s *twoFactorService) Login(actor entities.Actor, client entities.ClientInfo) (*entities.LoginResult, error) {
+	required, err := s.isRequired(actor)
+	if err != nil {
+		return nil, err
+	}
+	twoFactor, err := s.getTwoFactor(actor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := twoFactor != nil && twoFactor.Enabled
+	if !enabled && !required {
+		tokens, err := s.SessionService.CreateSession(actor, client)
+		if err != nil {
+			return nil, err
+		}
+		return &entities.LoginResult{Tokens: tokens}, nil
+	}
+
+	mfaToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	challenge := entities.LoginChallenge{Actor: actor, Client: client, SetupRequired: !enabled}
+	if err := s.ChallengeRepo.Save(mfaToken, challenge, loginChallengeTTL); err != nil {
+		return nil, err
+	}
+	return &entities.LoginResult{Challenge: &entities.TwoFactorChallenge{
+		MFAToken:      mfaToken,
+		SetupRequired: challenge.SetupRequired,
+		ExpiresIn:     int64(loginChallengeTTL.Seconds()),
+	}}, nil
+}
+
+// VerifyLogin completes a challenged login. For admins enrolling during login
+// the first valid code also enables two-factor and returns the recovery codes.
+func (s *twoFactorService) VerifyLogin(mfaToken string, code string, client entities.ClientInfo) (*entities.TwoFactorLoginResponse, error) {
+	challenge, err := s.ChallengeRepo.Get(mfaToken)
+	if err != nil {
+		if errors.Is(err, repositories.ErrLoginChallengeNotFound) {
+			return nil, ErrInvalidMFAToken
+		}
+		return nil, err
+	}
+
+	response := &entities.TwoFactorLoginResponse{}
+	if challenge.SetupRequired {
+		if response.RecoveryCodes, err = s.Enable(challenge.Actor, code); err != nil {
+			return nil, err
+		}
+	} else if err := s.checkCode(challenge.Actor.ID, code, true); err != nil {
+		return nil, err
+	}
+
+	// The challenge is single use, even if two requests raced with valid codes
+	consumed, err := s.ChallengeRepo.Delete(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidMFAToken
+	}
+
+	if response.Tokens, err = s.SessionService.CreateSession(challenge.Actor, client); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// SetupForLogin starts enrollment for an admin whose login is waiting on it
+func (s *twoFactorService) SetupForLogin(mfaToken string) (*entities.TwoFactorSetupResponse, error) {
+	challenge, err := s.ChallengeRepo.Get(mfaToken)
+	if err != nil {
+		if errors.Is(err, repositories.ErrLoginChallengeNotFound) {
+			return nil, ErrInvalidMFAToken
+		}
+		return nil, err
+	}
+	if !challenge.SetupRequired {
+		return nil, ErrTwoFactorAlreadyActive
+	}
+	return s.Setup(challenge.Actor)
+}
+
+func (s *twoFactorService) GetStatus(actor entities.Actor) (*entities.TwoFactorStatus, error) {
+	required, err := s.isRequired(actor)
+	if err != nil {
+		return nil, err
+	}
+	twoFactor, err := s.getTwoFactor(actor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &entities.TwoFactorStatus{Required: required}
+	if twoFactor != nil && twoFactor.Enabled {
+		status.Enabled = true
+		status.RecoveryCodesLeft = len(twoFactor.RecoveryCodes)
+	}
+	return status, nil
+}
+
+// Setup generates a new secret. It is not enforced until Enable confirms that
+// the authenticator app produces valid codes for it.
+func (s *twoFactorService) Setup(actor entities.Actor) (*entities.TwoFactorSetupResponse, error) {
+	existing, err := s.getTwoFactor(actor.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.Enabled {
+		return nil, ErrTwoFactorAlreadyActive
+	}
+
+	secretBytes := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, fmt.Errorf("error generating two-factor secret: %v", err)
+	}
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secretBytes)
+
+	encrypted, err := encryptTwoFactorSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	twoFactor := &models.TwoFactor{
+		SubjectID:   actor.ID,
+		SubjectType: actor.Type,
+		Secret:      encrypted,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.TwoFactorRepo.SavePending(twoFactor); err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountName(actor)
+	if err != nil {
+		return nil, err
+	}
+	uri := otpAuthURI(secret, account)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return nil, fmt.Errorf("error generating qr code: %v", err)
+	}
+
+	return &entities.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: uri,
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// Enable turns on a pending enrollment once a code from the app checks out
+func (s *twoFactorService) Enable(actor entities.Actor, code string) ([]string, error) {
+	twoFactor, err := s.getTwoFactor(actor.ID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor == nil {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if twoFactor.Enabled {
+		return nil, ErrTwoFactorAlreadyActive
+	}
+	if err := s.checkLock(actor.ID); err != nil {
+		return nil, err
+	}
+
+	step, err := matchTOTP(twoFactor, code)
+	if err != nil {
+		return nil, err
+	}
+	if step < 0 {
+		return nil, s.recordFailure(actor.ID)
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.TwoFactorRepo.Enable(actor.ID, hashes, step); err != nil {
+		if errors.Is(err, repositories.ErrTwoFactorNotFound) {
+			return nil, ErrTwoFactorAlreadyActive
+		}
+		return nil, err
+	}
+	s.resetFailures(actor.ID)
+	return codes, nil
+}
+
+func (s *twoFactorService) Disable(actor entities.Actor, code string) error {
+	required, err := s.isRequired(actor)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrTwoFactorRequired
+	}
+	if err := s.checkCode(actor.ID, code, true); err != nil {
+		return err
+	}
+	return s.TwoFactorRepo.Delete(actor.ID)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes. Only an authenticator
+// code is accepted, so a leaked recovery code cannot mint new ones.
+func (s *twoFactorService) RegenerateRecoveryCodes(actor entities.Actor, code string) ([]string, error) {
+	if err := s.checkCode(actor.ID, code, false); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.TwoFactorRepo.SetRecoveryCodes(actor.ID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// checkCode verifies a code against an enabled second factor. Failures count
+// towards a lockout per subject, however many challenges they are spread over.
+func (s *twoFactorService) checkCode(subjectID string, code string, allowRecovery bool) error {
+	twoFactor, err := s.getTwoFactor(subjectID)
+	if err != nil {
+		return err
+	}
+	if twoFactor == nil || !twoFactor.Enabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := s.checkLock(subjectID); err != nil {
+		return err
+	}
+
+	step, err := matchTOTP(twoFactor, code)
+	if err != nil {
+		return err
+	}
+	switch {
+	case step >= 0:
+		err = s.TwoFactorRepo.UseStep(subjectID, step)
+	case allowRecovery && !isTOTPCode(code):
+		err = s.TwoFactorRepo.UseRecoveryCode(subjectID, hashRecoveryCode(code))
+	default:
+		err = repositories.ErrTwoFactorCodeUsed
+	}
+	if errors.Is(err, repositories.ErrTwoFactorCodeUsed) {
+		return s.recordFailure(subjectID)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.resetFailures(subjectID)
+	return nil
+}
+
+func twoFactorAttemptKey(subjectID string) string {
+	return "2fa:" + subjectID
+}
+
+func (s *twoFactorService) checkLock(subjectID string) error {
+	remaining, err := s.LoginAttempts.LockRemaining(twoFactorAttemptKey(subjectID))
+	if err != nil {
+		return err
+	}
+	if remaining > 0 {
+		return &LoginLockedError{RetryAfter: remaining}
+	}
+	return nil
+}
+
+// recordFailure counts a wrong code and returns the error to report for it
+func (s *twoFactorService) recordFailure(subjectID string) error {
+	key := twoFactorAttemptKey(subjectID)
+	failures, err := s.LoginAttempts.RecordFailure(key, twoFactorFailWindow)
+	if err != nil {
+		return err
+	}
+	if failures >= twoFactorFreeTries {
+		if err := s.LoginAttempts.Lock(key, twoFactorLockout); err != nil {
+			return err
+		}
+		s.resetFailures(subjectID)
+		return &LoginLockedError{RetryAfter: twoFactorLockout}
+	}
+	return ErrTwoFactorCodeInvalid
+}
+
+func (s *twoFactorService) resetFailures(subjectID string) {
+	if err := s.LoginAttempts.Reset(twoFactorAttemptKey(subjectID)); err != nil {
+		log.Printf("[TwoFactor] Could not reset failures for %s: %v", subjectID, err)
+	}
+}
+
+func (s *twoFactorService) getTwoFactor(subjectID string) (*models.TwoFactor, error) {
+	twoFactor, err := s.TwoFactorRepo.GetBySubject(subjectID)
+	if errors.Is(err, repositories.ErrTwoFactorNotFound) {
+		return nil, nil
+	}
+	return twoFactor, err
+}
+
+// isRequired reports whether the actor may not log in without a second factor
+func (s *twoFactorService) isRequired(actor entities.Actor) (bool, error) {
+	if actor.IsEmployee() {
+		return false, nil
+	}
+	user, err := s.UserRepo.GetUser(actor.ID)
+	if err != nil {
+		return false, err
+	}
+	return user.Role == string(entities.UserRoleAdmin), nil
+}
+
+// accountName labels the entry in the authenticator app
+func (s *twoFactorService) accountName(actor entities.Actor) (string, error) {
+	if actor.IsEmployee() {
+		employee, err := s.EmployeeRepo.GetEmployeeByID(actor.ID)
+		if err != nil {
+			return "", err
+		}
+		return employee.Username, nil
+	}
+
+	user, err := s.UserRepo.GetUser(actor.ID)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range []string{user.Email, user.Phone, user.Username} {
+		if name != "" {
+			return name, nil
+		}
+	}
+	return user.UserID, nil
+}
+
+// totpIssuer is shown as the account's name in authenticator apps, TOTP_ISSUER
+func totpIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "Recycle Waste Management"
+}
+
+func otpAuthURI(secret string, account string) string {
+	issuer := totpIssuer()
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + strings.ReplaceAll(query.Encode(), "+", "%20")
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totpDigits {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// matchTOTP returns the time step the code belongs to, or -1 if it matches none
+// within the allowed clock skew
+func matchTOTP(twoFactor *models.TwoFactor, code string) (int64, error) {
+	code = strings.TrimSpace(code)
+	if !isTOTPCode(code) {
+		return -1, nil
+	}
+
+	secret, err := decryptTwoFactorSecret(twoFactor.Secret)
+	if err != nil {
+		return -1, err
+	}
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return -1, fmt.Errorf("error decoding two-factor secret: %v", err)
+	}
+
+	now := time.Now().Unix() / totpPeriod
+	for step := now - totpSkew; step <= now+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, nil
+		}
+	}
+	return -1, nil
+}
+
+// totpCode is the HOTP value (RFC 4226) of a time step
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
+
+// generateRecoveryCodes returns codes to show once and the hashes to store
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("error generating recovery codes: %v", err)
+		}
+		raw := hex.EncodeToString(buf)
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// twoFactorKey encrypts TOTP secrets at rest, from TWO_FACTOR_ENCRYPTION_KEY
+// (falls back to JWT_SECRET_KEY)
+func twoFactorKey() []byte {
+	secret := os.Getenv("TWO_FACTOR_ENCRYPTION_KEY")
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET_KEY")
+	}
+	key := sha256.Sum256([]byte(secret))
+	return key[:]
+}
+
+func encryptTwoFactorSecret(secret string) (string, error) {
+	gcm, err := twoFactorCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("error encrypting two-factor secret: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+func decryptTwoFactorSecret(encrypted string) (string, error) {
+	gcm, err := twoFactorCipher()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("error decrypting two-factor secret: malformed value")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("error decrypting two-factor secret: %v", err)
+	}
+	return string(plain), nil
+}
+
+func twoFactorCipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(twoFactorKey())
+	if err != nil {
+		return nil, fmt.Errorf("error creating two-factor cipher: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -9,6 +9,7 @@ import (
 
 type usersService struct {
 	UsersRepository repositories.IUsersRepository
+	Audit           IAuditService
 }
 
 type IUsersService interface {
@@ -18,14 +19,14 @@ type IUsersService interface {
 	UpdateUser(userID string, data *entities.NewUserBody) error
 	UpdateUserWithRole(userID string, data *entities.NewUserBody, role entities.UserRole) error
 	UpdateUserImage(userID string, imageURL string) error
-	DeleteUser(userID string) error
 	GetUser(userID string) (*entities.UserDataFormat, error)
-	UpdateUserRole(userID string, role entities.UserRole) error
+	UpdateUserRole(meta entities.RequestMeta, userID string, role entities.UserRole) error
 }
 
-func NewUsersService(repo0 repositories.IUsersRepository) IUsersService {
+func NewUsersService(repo0 repositories.IUsersRepository, audit IAuditService) IUsersService {
 	return &usersService{
 		UsersRepository: repo0,
+		Audit:           audit,
 	}
 }
 
@@ -109,13 +110,6 @@ func (sv *usersService) UpdateUserWithRole(userID string, data *entities.NewUser
 	return nil
 }
 
-func (sv *usersService) DeleteUser(userID string) error {
-	if err := sv.UsersRepository.DeleteUser(userID); err != nil {
-		return err
-	}
-	return nil
-}
-
 func (sv *usersService) UpdateUserImage(userID string, imageURL string) error {
 	updateData := &entities.UserDataFormat{
 		ImageURL: imageURL,
@@ -136,7 +130,7 @@ func (sv *usersService) GetUser(userID string) (*entities.UserDataFormat, error)
 	return userData, nil
 }
 
-func (sv *usersService) UpdateUserRole(userID string, role entities.UserRole) error {
+func (sv *usersService) UpdateUserRole(meta entities.RequestMeta, userID string, role entities.UserRole) error {
 	userData, err := sv.UsersRepository.GetUser(userID)
 	if err != nil {
 		return err
@@ -156,5 +150,13 @@ func (sv *usersService) UpdateUserRole(userID string, role entities.UserRole) er
 	if err != nil {
 		return err
 	}
+
+	sv.Audit.Record(meta, entities.AuditRecord{
+		Action:     entities.AuditUserRoleChanged,
+		TargetType: "user",
+		TargetID:   userID,
+		Before:     map[string]string{"role": userData.Role},
+		After:      map[string]string{"role": string(role)},
+	})
 	return nil
 }
@@ -0,0 +1,102 @@
+package websocket
+
+import (
+	"sync"
+)
+
+// Presence describes one connected client in a room. A user may be present
+// several times (e.g. two browser tabs), so ClientID identifies the connection.
+type Presence struct {
+	ClientID string `json:"client_id"`
+	UserID   string `json:"user_id"`
+	UserType string `json:"user_type"`
+}
+
+// IBroker fans chat traffic out to every hub instance serving a room.
+type IBroker interface {
+	// Publish sends a message to every instance subscribed to the room
+	Publish(roomID string, message []byte) error
+	// Subscribe registers the handler that delivers messages to local clients
+	Subscribe(handler func(roomID string, message []byte))
+	AddPresence(roomID string, presence Presence) error
+	RemovePresence(roomID string, clientID string) error
+	ListPresence(roomID string) ([]Presence, error)
+	// RefreshPresence keeps the presence of clients still connected to this instance from expiring
+	RefreshPresence(roomID string, clientIDs []string) error
+	Close() error
+}
+
+// memoryBroker keeps everything inside the process. It is the default for
+// single-node deployments.
+type memoryBroker struct {
+	handler  func(roomID string, message []byte)
+	presence map[string]map[string]Presence
+	mutex    sync.RWMutex
+}
+
+func NewMemoryBroker() IBroker {
+	return &memoryBroker{
+		presence: make(map[string]map[string]Presence),
+	}
+}
+
+func (b *memoryBroker) Publish(roomID string, message []byte) error {
+	b.mutex.RLock()
+	handler := b.handler
+	b.mutex.RUnlock()
+
+	if handler != nil {
+		handler(roomID, message)
+	}
+	return nil
+}
+
+func (b *memoryBroker) Subscribe(handler func(roomID string, message []byte)) {
+	b.mutex.Lock()
+	b.handler = handler
+	b.mutex.Unlock()
+}
+
+func (b *memoryBroker) AddPresence(roomID string, presence Presence) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.presence[roomID] == nil {
+		b.presence[roomID] = make(map[string]Presence)
+	}
+	b.presence[roomID][presence.ClientID] = presence
+	return nil
+}
+
+func (b *memoryBroker) RemovePresence(roomID string, clientID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if room, ok := b.presence[roomID]; ok {
+		delete(room, clientID)
+		if len(room) == 0 {
+			delete(b.presence, roomID)
+		}
+	}
+	return nil
+}
+
+func (b *memoryBroker) ListPresence(roomID string) ([]Presence, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	var result []Presence
+	for _, p := range b.presence[roomID] {
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+// RefreshPresence is a no-op; in-process presence never expires
+func (b *memoryBroker) RefreshPresence(roomID string, clientIDs []string) error {
+	return nil
+}
+
+func (b *memoryBroker) Close() error {
+	return nil
+}
@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"log"
 	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/domain/models"
 	"time"
 
 	"github.com/gofiber/contrib/websocket"
+	"github.com/google/uuid"
 )
 
 const (
@@ -18,6 +20,16 @@ const (
 
 	// Send pings to peer with this period (must be less than pongWait)
 	pingPeriod = (pongWait * 9) / 10
+
+	// Maximum frame size allowed from peer; larger frames close the connection
+	maxFrameSize = 8 * 1024
+
+	// Each connection may send a burst of rateBurst frames, then rateRefill frames per second
+	rateBurst  = 10
+	rateRefill = 2
+
+	// Close connections that keep sending after being rate limited
+	maxRateViolations = 20
 )
 
 func (c *Client) ReadPump() {
@@ -26,9 +38,13 @@ func (c *Client) ReadPump() {
 		c.Conn.Close()
 	}()
 
+	c.Conn.SetReadLimit(maxFrameSize)
 	c.Conn.SetReadDeadline(time.Now().Add(pongWait))
 	c.Conn.SetPongHandler(func(string) error { c.Conn.SetReadDeadline(time.Now().Add(pongWait)); return nil })
 
+	limiter := newTokenBucket(rateBurst, rateRefill)
+	violations := 0
+
 	for {
 		_, message, err := c.Conn.ReadMessage()
 		if err != nil {
@@ -38,44 +54,170 @@ func (c *Client) ReadPump() {
 			break
 		}
 
-		log.Printf("[Chat] ReadPump: Received raw message from UserID=%s: %s", c.UserID, string(message))
+		if !limiter.Allow() {
+			violations++
+			if violations >= maxRateViolations {
+				log.Printf("[Chat] ReadPump: Closing connection of UserID=%s after repeated rate limit violations", c.UserID)
+				break
+			}
+			c.sendError("rate limit exceeded, slow down")
+			continue
+		}
+		violations = 0
 
 		// Parse incoming message
 		var msgReq entities.ChatMessageRequest
-		var messageContent string
 
 		if err := json.Unmarshal(message, &msgReq); err != nil {
-			// If JSON parsing fails, treat as plain text
-			messageContent = string(message)
-			log.Printf("[Chat] ReadPump: Failed to parse JSON, using raw text: %s", messageContent)
-		} else {
-			messageContent = msgReq.Message
-			log.Printf("[Chat] ReadPump: Parsed JSON message: %s", messageContent)
-		}
-
-		// Create chat message
-		chatMsg := entities.ChatMessage{
-			Type:              "message",
-			CustomerRequestID: c.CustomerRequestID,
-			SenderID:          c.UserID,
-			SenderType:        c.UserType,
-			Message:           messageContent,
-			Timestamp:         time.Now().Format(time.RFC3339),
+			log.Printf("[Chat] ReadPump: Rejected non-JSON frame from UserID=%s", c.UserID)
+			c.sendError("invalid message format")
+			continue
 		}
 
-		// Marshal and broadcast
-		if msgBytes, err := json.Marshal(chatMsg); err == nil {
-			log.Printf("[Chat] Broadcasting message to room %s from UserID=%s", c.CustomerRequestID, c.UserID)
-			ChatHub.Broadcast <- &BroadcastMessage{
+		switch msgReq.Type {
+		case "", entities.ChatTypeMessage, entities.ChatTypeImage, entities.ChatTypeLocation, entities.ChatTypePriceOffer:
+			if msgReq.Type == "" {
+				msgReq.Type = entities.ChatTypeMessage
+			}
+			if err := validateChatMessage(c.CustomerRequestID, &msgReq); err != nil {
+				log.Printf("[Chat] ReadPump: Rejected %s from UserID=%s: %v", msgReq.Type, c.UserID, err)
+				c.sendError(err.Error())
+				continue
+			}
+			c.filterChatMessage(&msgReq)
+			c.handleChatMessage(msgReq)
+		case entities.ChatTypeTypingStart, entities.ChatTypeTypingStop:
+			// Typing indicators are relayed to the room but never stored
+			c.broadcast(entities.ChatMessage{
+				Type:              msgReq.Type,
 				CustomerRequestID: c.CustomerRequestID,
-				Message:           msgBytes,
+				SenderID:          c.UserID,
+				SenderType:        c.UserType,
+				Timestamp:         time.Now().Format(time.RFC3339),
+			})
+		case entities.ChatTypeDelivered, entities.ChatTypeRead:
+			if len(msgReq.MessageIDs) > maxAcknowledgedMessages {
+				c.sendError("too many message_ids")
+				continue
 			}
-			log.Printf("[Chat] Message from %s (%s) in room %s: %s",
-				c.UserID, c.UserType, c.CustomerRequestID, messageContent)
+			c.handleAcknowledgement(msgReq)
+		default:
+			log.Printf("[Chat] ReadPump: Ignoring unknown message type %q from UserID=%s", msgReq.Type, c.UserID)
 		}
 	}
 }
 
+// filterChatMessage masks banned words in every free-text field of the message
+func (c *Client) filterChatMessage(msgReq *entities.ChatMessageRequest) {
+	var masked, m bool
+	msgReq.Message, m = ChatHub.Filter.Mask(msgReq.Message)
+	masked = masked || m
+	if msgReq.Location != nil {
+		msgReq.Location.Label, m = ChatHub.Filter.Mask(msgReq.Location.Label)
+		masked = masked || m
+	}
+	if msgReq.PriceOffer != nil {
+		msgReq.PriceOffer.Note, m = ChatHub.Filter.Mask(msgReq.PriceOffer.Note)
+		masked = masked || m
+	}
+	if masked {
+		log.Printf("[Chat] Masked banned words in %s from UserID=%s", msgReq.Type, c.UserID)
+	}
+}
+
+// sendError tells only this client why its frame was dropped
+func (c *Client) sendError(reason string) {
+	msgBytes, err := json.Marshal(entities.ChatMessage{
+		Type:              entities.ChatTypeError,
+		CustomerRequestID: c.CustomerRequestID,
+		Message:           reason,
+		Timestamp:         time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return
+	}
+	c.trySend(msgBytes)
+}
+
+// handleChatMessage stores a chat message and broadcasts it to the room. The
+// sender is the socket's authenticated user; a message that can't be stored
+// is not broadcast.
+func (c *Client) handleChatMessage(msgReq entities.ChatMessageRequest) {
+	now := time.Now()
+	stored := &models.ChatMessageModel{
+		MessageID:         uuid.New().String(),
+		CustomerRequestID: c.CustomerRequestID,
+		SenderID:          c.UserID,
+		SenderType:        c.UserType,
+		Type:              msgReq.Type,
+		Message:           msgReq.Message,
+		ImageURL:          msgReq.ImageURL,
+		Location:          msgReq.Location,
+		PriceOffer:        msgReq.PriceOffer,
+		CreatedAt:         now,
+	}
+	if err := ChatHub.Messages.Create(stored); err != nil {
+		log.Printf("[Chat] Failed to store message from UserID=%s: %v", c.UserID, err)
+		c.sendError("message could not be sent, try again")
+		return
+	}
+
+	c.broadcast(entities.ChatMessage{
+		Type:              stored.Type,
+		MessageID:         stored.MessageID,
+		CustomerRequestID: c.CustomerRequestID,
+		SenderID:          c.UserID,
+		SenderType:        c.UserType,
+		Message:           stored.Message,
+		ImageURL:          stored.ImageURL,
+		Location:          stored.Location,
+		PriceOffer:        stored.PriceOffer,
+		Timestamp:         now.Format(time.RFC3339),
+	})
+	log.Printf("[Chat] %s %s from %s (%s) in room %s",
+		stored.Type, stored.MessageID, c.UserID, c.UserType, c.CustomerRequestID)
+}
+
+// handleAcknowledgement updates delivered/read state of stored messages for the
+// socket's authenticated user and tells the room about it
+func (c *Client) handleAcknowledgement(msgReq entities.ChatMessageRequest) {
+	if len(msgReq.MessageIDs) == 0 {
+		return
+	}
+
+	var err error
+	if msgReq.Type == entities.ChatTypeRead {
+		err = ChatHub.Messages.MarkRead(c.CustomerRequestID, msgReq.MessageIDs, c.UserID)
+	} else {
+		err = ChatHub.Messages.MarkDelivered(c.CustomerRequestID, msgReq.MessageIDs, c.UserID)
+	}
+	if err != nil {
+		log.Printf("[Chat] Failed to store %s acknowledgement from UserID=%s: %v", msgReq.Type, c.UserID, err)
+		c.sendError("acknowledgement could not be saved")
+		return
+	}
+
+	c.broadcast(entities.ChatMessage{
+		Type:              msgReq.Type,
+		MessageIDs:        msgReq.MessageIDs,
+		CustomerRequestID: c.CustomerRequestID,
+		SenderID:          c.UserID,
+		SenderType:        c.UserType,
+		Timestamp:         time.Now().Format(time.RFC3339),
+	})
+}
+
+func (c *Client) broadcast(chatMsg entities.ChatMessage) {
+	msgBytes, err := json.Marshal(chatMsg)
+	if err != nil {
+		return
+	}
+	ChatHub.Broadcast <- &BroadcastMessage{
+		CustomerRequestID: c.CustomerRequestID,
+		Message:           msgBytes,
+	}
+}
+
 func (c *Client) WritePump() {
 	ticker := time.NewTicker(pingPeriod)
 	defer func() {
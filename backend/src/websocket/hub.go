@@ -4,16 +4,23 @@ import (
 	"encoding/json"
 	"log"
 	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/repositories"
 	"sync"
 	"time"
 
 	"github.com/gofiber/contrib/websocket"
+	"github.com/google/uuid"
 )
 
+// Rooms with connected clients get their presence refreshed this often, so
+// quiet but open chats don't drop out of the shared presence
+const presenceHeartbeatPeriod = 5 * time.Minute
+
 type Client struct {
+	ID                string // Unique per connection, used for presence
 	Conn              *websocket.Conn
-	UserID            string
-	UserType          string // "customer" or "shop"
+	UserID            string // From the verified token; messages and receipts are stored under it
+	UserType          string // "customer" or "shop", from the user's membership of the room
 	CustomerRequestID string // Room ID
 	Send              chan []byte
 }
@@ -31,6 +38,15 @@ type Hub struct {
 	// Broadcast messages to clients in a room
 	Broadcast chan *BroadcastMessage
 
+	// Broker fans broadcasts and presence out to every instance
+	Broker IBroker
+
+	// Messages stores chat messages and their delivery/read state
+	Messages repositories.IChatMessageRepository
+
+	// Filter masks banned words before messages are stored
+	Filter *WordFilter
+
 	// Mutex for thread-safe operations
 	mutex sync.RWMutex
 }
@@ -42,19 +58,33 @@ type BroadcastMessage struct {
 
 var ChatHub *Hub
 
-func NewHub() *Hub {
-	return &Hub{
+func NewHub(broker IBroker, messages repositories.IChatMessageRepository, filter *WordFilter) *Hub {
+	h := &Hub{
 		Rooms:      make(map[string]map[*Client]bool),
 		Register:   make(chan *Client),
 		Unregister: make(chan *Client),
 		Broadcast:  make(chan *BroadcastMessage),
+		Broker:     broker,
+		Messages:   messages,
+		Filter:     filter,
 	}
+	broker.Subscribe(h.deliver)
+	return h
 }
 
 func (h *Hub) Run() {
+	heartbeat := time.NewTicker(presenceHeartbeatPeriod)
+	defer heartbeat.Stop()
+
 	for {
 		select {
+		case <-heartbeat.C:
+			h.refreshPresence()
+
 		case client := <-h.Register:
+			if client.ID == "" {
+				client.ID = uuid.New().String()
+			}
 			h.mutex.Lock()
 			if h.Rooms[client.CustomerRequestID] == nil {
 				h.Rooms[client.CustomerRequestID] = make(map[*Client]bool)
@@ -66,6 +96,14 @@ func (h *Hub) Run() {
 			log.Printf("[Chat] Client registered: UserID=%s, Type=%s, Room=%s (Total in room: %d)",
 				client.UserID, client.UserType, client.CustomerRequestID, roomSize)
 
+			if err := h.Broker.AddPresence(client.CustomerRequestID, Presence{
+				ClientID: client.ID,
+				UserID:   client.UserID,
+				UserType: client.UserType,
+			}); err != nil {
+				log.Printf("[Chat] Failed to add presence: %v", err)
+			}
+
 			// Send join notification in a goroutine to avoid blocking
 			go func(c *Client) {
 				joinMsg := entities.ChatMessage{
@@ -84,45 +122,28 @@ func (h *Hub) Run() {
 				}
 			}(client)
 
-			// Send existing users presence to the new client
-			// We need to do this carefully to avoid race conditions, but since we have the list of clients
-			// from the map (which we accessed under lock), we can iterate and send.
-			// However, we need to be careful not to send to closed channels if they disconnect in between.
-			// But here we are just sending to 'client.Send' which is our own channel.
-
-			// We need to capture the existing clients info, not the pointers themselves if we want to be super safe,
-			// but for this simple case, we can just iterate the map inside the lock or copy it.
-			// We already have the map access inside the lock above.
-			// Let's modify the logic above to capture existing clients.
-
-			// Since I cannot easily modify the code above the block I'm replacing without replacing the whole block,
-			// I will assume I can't access 'existingUsers' unless I captured them.
-			// I'll have to re-acquire lock or just do it in a separate goroutine with a new lock acquisition?
-			// No, re-acquiring lock is fine.
-
+			// Send presence of everyone already in the room (on any instance) to the new client
 			go func(target *Client) {
-				h.mutex.RLock()
-				var others []*Client
-				if room, ok := h.Rooms[target.CustomerRequestID]; ok {
-					for c := range room {
-						if c != target {
-							others = append(others, c)
-						}
-					}
+				others, err := h.Broker.ListPresence(target.CustomerRequestID)
+				if err != nil {
+					log.Printf("[Chat] Failed to list presence for room %s: %v", target.CustomerRequestID, err)
+					return
 				}
-				h.mutex.RUnlock()
 
 				for _, other := range others {
+					if other.ClientID == target.ID {
+						continue
+					}
 					presenceMsg := entities.ChatMessage{
 						Type:              "join",
-						CustomerRequestID: other.CustomerRequestID,
+						CustomerRequestID: target.CustomerRequestID,
 						SenderID:          other.UserID,
 						SenderType:        other.UserType,
 						Message:           "is online",
 						Timestamp:         time.Now().Format(time.RFC3339),
 					}
 					if msgBytes, err := json.Marshal(presenceMsg); err == nil {
-						target.Send <- msgBytes
+						target.trySend(msgBytes)
 					}
 				}
 			}(client)
@@ -145,6 +166,10 @@ func (h *Hub) Run() {
 			log.Printf("[Chat] Client unregistered: UserID=%s, Type=%s, Room=%s",
 				client.UserID, client.UserType, client.CustomerRequestID)
 
+			if err := h.Broker.RemovePresence(client.CustomerRequestID, client.ID); err != nil {
+				log.Printf("[Chat] Failed to remove presence: %v", err)
+			}
+
 			// Send leave notification in a goroutine to avoid blocking
 			go func(c *Client) {
 				leaveMsg := entities.ChatMessage{
@@ -164,41 +189,87 @@ func (h *Hub) Run() {
 			}(client)
 
 		case broadcast := <-h.Broadcast:
-			h.mutex.RLock()
-			roomClients := h.Rooms[broadcast.CustomerRequestID]
-			clientCount := len(roomClients)
-
-			// Copy clients to a slice to avoid holding the lock during broadcast
-			// and to avoid concurrent map modification issues
-			var clients []*Client
-			for client := range roomClients {
-				clients = append(clients, client)
+			if err := h.Broker.Publish(broadcast.CustomerRequestID, broadcast.Message); err != nil {
+				log.Printf("[Chat] Failed to publish to room %s: %v", broadcast.CustomerRequestID, err)
 			}
-			h.mutex.RUnlock()
-
-			log.Printf("[Chat] Broadcasting message to room %s (Clients: %d)", broadcast.CustomerRequestID, clientCount)
-
-			for _, client := range clients {
-				select {
-				case client.Send <- broadcast.Message:
-					log.Printf("[Chat] Sent to client UserID=%s", client.UserID)
-				default:
-					log.Printf("[Chat] Failed to send to client UserID=%s (Channel full)", client.UserID)
-					// Client's send channel is full, unregister
-					h.mutex.Lock()
-					if _, ok := h.Rooms[broadcast.CustomerRequestID][client]; ok {
-						delete(h.Rooms[broadcast.CustomerRequestID], client)
-						close(client.Send)
-					}
-					h.mutex.Unlock()
-				}
+		}
+	}
+}
+
+// refreshPresence renews the presence of every client connected to this instance
+func (h *Hub) refreshPresence() {
+	h.mutex.RLock()
+	rooms := make(map[string][]string, len(h.Rooms))
+	for roomID, roomClients := range h.Rooms {
+		for client := range roomClients {
+			rooms[roomID] = append(rooms[roomID], client.ID)
+		}
+	}
+	h.mutex.RUnlock()
+
+	for roomID, clientIDs := range rooms {
+		if err := h.Broker.RefreshPresence(roomID, clientIDs); err != nil {
+			log.Printf("[Chat] Failed to refresh presence for room %s: %v", roomID, err)
+		}
+	}
+}
+
+// deliver sends a published message to the clients of the room connected to this instance
+func (h *Hub) deliver(roomID string, message []byte) {
+	h.mutex.RLock()
+	roomClients := h.Rooms[roomID]
+	clientCount := len(roomClients)
+
+	// Copy clients to a slice to avoid holding the lock during broadcast
+	// and to avoid concurrent map modification issues
+	var clients []*Client
+	for client := range roomClients {
+		clients = append(clients, client)
+	}
+	h.mutex.RUnlock()
+
+	log.Printf("[Chat] Broadcasting message to room %s (Clients: %d)", roomID, clientCount)
+
+	for _, client := range clients {
+		select {
+		case client.Send <- message:
+			log.Printf("[Chat] Sent to client UserID=%s", client.UserID)
+		default:
+			log.Printf("[Chat] Failed to send to client UserID=%s (Channel full)", client.UserID)
+			// Client's send channel is full, unregister
+			h.mutex.Lock()
+			if _, ok := h.Rooms[roomID][client]; ok {
+				delete(h.Rooms[roomID], client)
+				close(client.Send)
+			}
+			h.mutex.Unlock()
+
+			if err := h.Broker.RemovePresence(roomID, client.ID); err != nil {
+				log.Printf("[Chat] Failed to remove presence: %v", err)
 			}
 		}
 	}
 }
 
-func InitChatHub() {
-	ChatHub = NewHub()
+// trySend queues a message for one client without blocking. It reports false
+// when the buffer is full or the client has already been unregistered.
+func (c *Client) trySend(message []byte) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
+	select {
+	case c.Send <- message:
+		return true
+	default:
+		return false
+	}
+}
+
+func InitChatHub(broker IBroker, messages repositories.IChatMessageRepository, filter *WordFilter) {
+	ChatHub = NewHub(broker, messages, filter)
 	go ChatHub.Run()
 	log.Println("[Chat] WebSocket Hub initialized and running")
 }
@@ -0,0 +1,141 @@
+package websocket
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// tokenBucket limits how fast a single connection may send frames.
+// It starts full so a short burst is allowed, then refills at a steady rate.
+type tokenBucket struct {
+	capacity   float64
+	tokens     float64
+	refillRate float64 // tokens per second
+	last       time.Time
+	mutex      sync.Mutex
+}
+
+func newTokenBucket(capacity float64, refillRate float64) *tokenBucket {
+	return &tokenBucket{
+		capacity:   capacity,
+		tokens:     capacity,
+		refillRate: refillRate,
+		last:       time.Now(),
+	}
+}
+
+// Allow takes one token and reports whether one was available
+func (b *tokenBucket) Allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.refillRate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// WordFilter masks banned words in chat text. English (Latin) words are
+// matched on word boundaries so "class" does not trip on "ass"; Thai is
+// written without spaces between words, so Thai entries match anywhere.
+type WordFilter struct {
+	latin *regexp.Regexp
+	thai  []string
+}
+
+// NewWordFilter builds a filter from a list of words. Empty entries are ignored.
+func NewWordFilter(words []string) *WordFilter {
+	f := &WordFilter{}
+
+	var latin []string
+	for _, w := range words {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w == "" {
+			continue
+		}
+		if isLatinWord(w) {
+			latin = append(latin, regexp.QuoteMeta(w))
+		} else {
+			f.thai = append(f.thai, w)
+		}
+	}
+	if len(latin) > 0 {
+		f.latin = regexp.MustCompile(`(?i)\b(` + strings.Join(latin, "|") + `)\b`)
+	}
+	return f
+}
+
+// NewWordFilterFromEnv loads banned words from CHAT_BANNED_WORDS (comma
+// separated) and from CHAT_BANNED_WORDS_FILE (one word per line).
+func NewWordFilterFromEnv() *WordFilter {
+	var words []string
+	if list := os.Getenv("CHAT_BANNED_WORDS"); list != "" {
+		words = append(words, strings.Split(list, ",")...)
+	}
+
+	if path := os.Getenv("CHAT_BANNED_WORDS_FILE"); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Printf("[Chat] Could not open banned words file %s: %v", path, err)
+		} else {
+			defer file.Close()
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				line := strings.TrimSpace(scanner.Text())
+				if line != "" && !strings.HasPrefix(line, "#") {
+					words = append(words, line)
+				}
+			}
+		}
+	}
+
+	filter := NewWordFilter(words)
+	log.Printf("[Chat] Loaded %d banned words", len(words))
+	return filter
+}
+
+// Mask replaces every banned word with asterisks and reports whether anything was replaced
+func (f *WordFilter) Mask(text string) (string, bool) {
+	if f == nil || text == "" {
+		return text, false
+	}
+
+	masked := false
+	if f.latin != nil {
+		text = f.latin.ReplaceAllStringFunc(text, func(match string) string {
+			masked = true
+			return strings.Repeat("*", len([]rune(match)))
+		})
+	}
+
+	for _, word := range f.thai {
+		if strings.Contains(text, word) {
+			masked = true
+			text = strings.ReplaceAll(text, word, strings.Repeat("*", len([]rune(word))))
+		}
+	}
+	return text, masked
+}
+
+func isLatinWord(word string) bool {
+	for _, r := range word {
+		if r > unicode.MaxLatin1 {
+			return false
+		}
+	}
+	return true
+}
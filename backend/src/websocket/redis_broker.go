@@ -0,0 +1,165 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"recycle-waste-management-backend/src/domain/datasources"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisRoomChannelPrefix     = "chat:room:"
+	redisPresenceKeyPrefix     = "chat:presence:"
+	redisPresenceSeenKeyPrefix = "chat:presence-seen:"
+
+	// A presence entry is stale once the instance holding the client has not
+	// refreshed it for this long, e.g. because that instance crashed. Must be
+	// well above presenceHeartbeatPeriod.
+	redisPresenceTTL = 15 * time.Minute
+)
+
+// redisBroker shares rooms between backend replicas through Redis pub/sub.
+// Presence of a room is a hash of entries plus a sorted set of when each entry
+// was last seen, so stale entries are dropped even while the room stays active.
+type redisBroker struct {
+	Context   context.Context
+	RedisWR   *redis.Client
+	RedisRead *redis.Client
+	pubsub    *redis.PubSub
+}
+
+func NewRedisBroker(conn *datasources.RedisConnection) IBroker {
+	return &redisBroker{
+		Context:   conn.Context,
+		RedisWR:   conn.RedisWR,
+		RedisRead: conn.RedisRead,
+	}
+}
+
+func (b *redisBroker) Publish(roomID string, message []byte) error {
+	if err := b.RedisWR.Publish(b.Context, redisRoomChannelPrefix+roomID, message).Err(); err != nil {
+		return fmt.Errorf("error publishing chat message: %v", err)
+	}
+	return nil
+}
+
+func (b *redisBroker) Subscribe(handler func(roomID string, message []byte)) {
+	b.pubsub = b.RedisWR.PSubscribe(b.Context, redisRoomChannelPrefix+"*")
+
+	go func() {
+		for msg := range b.pubsub.Channel() {
+			roomID := strings.TrimPrefix(msg.Channel, redisRoomChannelPrefix)
+			handler(roomID, []byte(msg.Payload))
+		}
+		log.Println("[Chat] Redis subscription closed")
+	}()
+}
+
+func (b *redisBroker) AddPresence(roomID string, presence Presence) error {
+	data, err := json.Marshal(presence)
+	if err != nil {
+		return err
+	}
+
+	key, seenKey := redisPresenceKeyPrefix+roomID, redisPresenceSeenKeyPrefix+roomID
+	pipe := b.RedisWR.TxPipeline()
+	pipe.HSet(b.Context, key, presence.ClientID, data)
+	pipe.ZAdd(b.Context, seenKey, redis.Z{Score: float64(time.Now().Unix()), Member: presence.ClientID})
+	pipe.Expire(b.Context, key, redisPresenceTTL)
+	pipe.Expire(b.Context, seenKey, redisPresenceTTL)
+	if _, err := pipe.Exec(b.Context); err != nil {
+		return fmt.Errorf("error adding chat presence: %v", err)
+	}
+	return nil
+}
+
+func (b *redisBroker) RemovePresence(roomID string, clientID string) error {
+	pipe := b.RedisWR.TxPipeline()
+	pipe.HDel(b.Context, redisPresenceKeyPrefix+roomID, clientID)
+	pipe.ZRem(b.Context, redisPresenceSeenKeyPrefix+roomID, clientID)
+	if _, err := pipe.Exec(b.Context); err != nil {
+		return fmt.Errorf("error removing chat presence: %v", err)
+	}
+	return nil
+}
+
+// RefreshPresence marks this instance's clients as seen now. Only entries that
+// still exist are touched, so a client removed meanwhile is not brought back.
+func (b *redisBroker) RefreshPresence(roomID string, clientIDs []string) error {
+	if len(clientIDs) == 0 {
+		return nil
+	}
+	now := float64(time.Now().Unix())
+	members := make([]redis.Z, 0, len(clientIDs))
+	for _, clientID := range clientIDs {
+		members = append(members, redis.Z{Score: now, Member: clientID})
+	}
+
+	key, seenKey := redisPresenceKeyPrefix+roomID, redisPresenceSeenKeyPrefix+roomID
+	pipe := b.RedisWR.TxPipeline()
+	pipe.ZAddXX(b.Context, seenKey, members...)
+	pipe.Expire(b.Context, key, redisPresenceTTL)
+	pipe.Expire(b.Context, seenKey, redisPresenceTTL)
+	if _, err := pipe.Exec(b.Context); err != nil {
+		return fmt.Errorf("error refreshing chat presence: %v", err)
+	}
+	return nil
+}
+
+// ListPresence returns the entries seen within redisPresenceTTL and prunes the rest
+func (b *redisBroker) ListPresence(roomID string) ([]Presence, error) {
+	key, seenKey := redisPresenceKeyPrefix+roomID, redisPresenceSeenKeyPrefix+roomID
+	cutoff := strconv.FormatInt(time.Now().Add(-redisPresenceTTL).Unix(), 10)
+
+	stale, err := b.RedisRead.ZRangeByScore(b.Context, seenKey, &redis.ZRangeBy{Min: "-inf", Max: "(" + cutoff}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error listing chat presence: %v", err)
+	}
+	if len(stale) > 0 {
+		pipe := b.RedisWR.TxPipeline()
+		pipe.HDel(b.Context, key, stale...)
+		pipe.ZRemRangeByScore(b.Context, seenKey, "-inf", "("+cutoff)
+		if _, err := pipe.Exec(b.Context); err != nil {
+			log.Printf("[Chat] Failed to prune stale presence of room %s: %v", roomID, err)
+		}
+	}
+
+	clientIDs, err := b.RedisRead.ZRangeByScore(b.Context, seenKey, &redis.ZRangeBy{Min: cutoff, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error listing chat presence: %v", err)
+	}
+	if len(clientIDs) == 0 {
+		return nil, nil
+	}
+	values, err := b.RedisRead.HMGet(b.Context, key, clientIDs...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error listing chat presence: %v", err)
+	}
+
+	var result []Presence
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var p Presence
+		if err := json.Unmarshal([]byte(data), &p); err != nil {
+			continue
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+func (b *redisBroker) Close() error {
+	if b.pubsub != nil {
+		return b.pubsub.Close()
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package websocket
+
+import (
+	"fmt"
+	"net/url"
+	"recycle-waste-management-backend/src/domain/entities"
+	"recycle-waste-management-backend/src/infrastructure/providers"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxChatMessageLength    = 2000
+	maxAcknowledgedMessages = 100
+	maxLocationLabelLength  = 200
+	maxPriceOfferNoteLength = 500
+	maxPriceOfferAmount     = 10000000
+)
+
+// validateChatMessage checks a typed chat message before it is stored and
+// broadcast. It normalises the request in place (e.g. default currency).
+func validateChatMessage(roomID string, msgReq *entities.ChatMessageRequest) error {
+	text, err := validateChatText(msgReq.Message, maxChatMessageLength)
+	if err != nil {
+		return err
+	}
+	msgReq.Message = text
+
+	switch msgReq.Type {
+	case entities.ChatTypeMessage:
+		if msgReq.Message == "" {
+			return fmt.Errorf("message is empty")
+		}
+	case entities.ChatTypeImage:
+		return validateChatImageURL(roomID, msgReq.ImageURL)
+	case entities.ChatTypeLocation:
+		loc := msgReq.Location
+		if loc == nil {
+			return fmt.Errorf("location is required")
+		}
+		if loc.Lat < -90 || loc.Lat > 90 || loc.Lng < -180 || loc.Lng > 180 {
+			return fmt.Errorf("location is out of range")
+		}
+		if loc.Label, err = validateChatText(loc.Label, maxLocationLabelLength); err != nil {
+			return fmt.Errorf("location label: %v", err)
+		}
+	case entities.ChatTypePriceOffer:
+		offer := msgReq.PriceOffer
+		if offer == nil {
+			return fmt.Errorf("price_offer is required")
+		}
+		if offer.Amount <= 0 || offer.Amount > maxPriceOfferAmount {
+			return fmt.Errorf("price_offer amount is out of range")
+		}
+		if offer.Currency == "" {
+			offer.Currency = "THB"
+		}
+		if offer.Currency != "THB" {
+			return fmt.Errorf("unsupported currency: %s", offer.Currency)
+		}
+		if offer.Note, err = validateChatText(offer.Note, maxPriceOfferNoteLength); err != nil {
+			return fmt.Errorf("price_offer note: %v", err)
+		}
+	default:
+		return fmt.Errorf("unsupported message type: %s", msgReq.Type)
+	}
+	return nil
+}
+
+// validateChatText trims user supplied text and rejects invalid UTF-8, control
+// characters other than line breaks and tabs, and text longer than maxLength runes.
+func validateChatText(text string, maxLength int) (string, error) {
+	if !utf8.ValidString(text) {
+		return "", fmt.Errorf("text is not valid UTF-8")
+	}
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) > maxLength {
+		return "", fmt.Errorf("text exceeds %d characters", maxLength)
+	}
+	for _, r := range text {
+		if unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t' {
+			return "", fmt.Errorf("text contains control characters")
+		}
+	}
+	return text, nil
+}
+
+// validateChatImageURL only accepts images uploaded through the chat image
+// endpoint for this room and stored in our S3 bucket, so clients cannot embed
+// arbitrary links.
+func validateChatImageURL(roomID string, imageURL string) error {
+	if imageURL == "" {
+		return fmt.Errorf("image_url is required")
+	}
+	parsed, err := url.Parse(imageURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return fmt.Errorf("invalid image_url")
+	}
+	if !strings.EqualFold(parsed.Host, providers.S3BucketHost()) {
+		return fmt.Errorf("image_url does not belong to this chat")
+	}
+
+	// Key format: chat-images/YYYY/MM/DD/<roomID>/<uuid>.<ext>
+	parts := strings.Split(strings.TrimPrefix(parsed.Path, "/"), "/")
+	if len(parts) != 6 || parts[0] != entities.ChatImageFolder || parts[4] != roomID {
+		return fmt.Errorf("image_url does not belong to this chat")
+	}
+	return nil
+}
@@ -8,10 +8,8 @@ const auth = getAuth(app);
 const provider = new GoogleAuthProvider();
 const authLoginGithub = async () => {
     try {
-        const url = new URL('https://github.com/login/oauth/authorize');
-        url.searchParams.append('client_id', config.githubClientId);
-        url.searchParams.append('scope', 'user:email');
-        window.location.href = url.toString();
+        // The backend issues the state GitHub must hand back to the callback
+        window.location.href = config.webAPI + '/api/auth/github/login';
     } catch (error) {
         console.log(error);
 
@@ -22,12 +20,19 @@ const authLoginGoogle = async () => {
   try {
       // เริ่มกระบวนการ Sign In
       const result : UserCredential = await signInWithPopup(auth, provider);
-      const user = result.user;
-      const userID = user.uid;
-      console.log(userID);
-      // send api google/callback?uid=${userID}
-      const url = new URL(config.webAPI + '/api/auth/google/callback' + '?uid=' + userID);
-      window.location.href = url.toString();
+      const idToken = await result.user.getIdToken();
+      // POST the ID token as a form so the backend can verify it and redirect back,
+      // without the token ending up in a URL
+      const form = document.createElement('form');
+      form.method = 'POST';
+      form.action = config.webAPI + '/api/auth/google/callback';
+      const input = document.createElement('input');
+      input.type = 'hidden';
+      input.name = 'id_token';
+      input.value = idToken;
+      form.appendChild(input);
+      document.body.appendChild(form);
+      form.submit();
   } catch (error) {
       console.log("Error during redirect login:", error);
   }
@@ -1,4 +1,5 @@
 import config from '@/config'
+import { refreshSession } from '@/stores/session'
 
 const getCookie = (name: string): string | null => {
   const value = `; ${document.cookie}`
@@ -25,7 +26,13 @@ const request = async <T>(method: string, url: string, data?: unknown): Promise<
     body: data ? JSON.stringify(data) : undefined,
   }
 
-  const response = await fetch(fullUrl, options)
+  let response = await fetch(fullUrl, options)
+  if (response.status === 401) {
+    // The access token may have expired; retry once with a refreshed one
+    if (await refreshSession()) {
+      response = await fetch(fullUrl, { ...options, headers: getHeaders() })
+    }
+  }
 
   if (!response.ok) {
     const errorData = await response.json().catch(() => ({}))
@@ -0,0 +1,93 @@
+import config from '@/config'
+import { getCookie, setCookie, deleteCookie } from './cookie'
+
+const REFRESH_TOKEN_KEY = 'refresh_token'
+// Renew the access token this long before it expires
+const REFRESH_MARGIN_MS = 60 * 1000
+const HOUR_MS = 60 * 60 * 1000
+
+let refreshTimer: ReturnType<typeof setTimeout> | null = null
+let refreshing: Promise<string | null> | null = null
+
+// tokenExpiry reads the exp claim of a JWT, in milliseconds
+const tokenExpiry = (token: string): number | null => {
+  try {
+    const payload = JSON.parse(atob(token.split('.')[1].replace(/-/g, '+').replace(/_/g, '/')))
+    return typeof payload.exp === 'number' ? payload.exp * 1000 : null
+  } catch {
+    return null
+  }
+}
+
+const scheduleRefresh = (expiresAt: number): void => {
+  if (refreshTimer) clearTimeout(refreshTimer)
+  refreshTimer = null
+  if (!localStorage.getItem(REFRESH_TOKEN_KEY)) return
+  refreshTimer = setTimeout(() => {
+    void refreshSession()
+  }, Math.max(expiresAt - Date.now() - REFRESH_MARGIN_MS, 0))
+}
+
+// saveSession keeps the access token in a cookie that expires together with the
+// token, stores the refresh token and schedules the next refresh
+const saveSession = (accessToken: string, refreshToken?: string | null): void => {
+  const expiresAt = tokenExpiry(accessToken) ?? Date.now() + HOUR_MS
+  setCookie('token', accessToken, 0, Math.max(expiresAt - Date.now(), 0) / HOUR_MS)
+  if (refreshToken) {
+    localStorage.setItem(REFRESH_TOKEN_KEY, refreshToken)
+  }
+  scheduleRefresh(expiresAt)
+}
+
+const clearSession = (): void => {
+  if (refreshTimer) clearTimeout(refreshTimer)
+  refreshTimer = null
+  deleteCookie('token')
+  localStorage.removeItem(REFRESH_TOKEN_KEY)
+}
+
+// refreshSession trades the refresh token for a new token pair. Refresh tokens
+// rotate, so concurrent callers share one request.
+const refreshSession = (): Promise<string | null> => {
+  if (refreshing) return refreshing
+  const refreshToken = localStorage.getItem(REFRESH_TOKEN_KEY)
+  if (!refreshToken) return Promise.resolve(null)
+
+  refreshing = (async () => {
+    try {
+      const response = await fetch(`${config.webAPI}/api/auth/refresh`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ refresh_token: refreshToken }),
+      })
+      if (!response.ok) {
+        // The session was revoked or has expired
+        clearSession()
+        return null
+      }
+      const { data } = await response.json()
+      saveSession(data.access_token, data.refresh_token)
+      return data.access_token as string
+    } catch (error) {
+      console.error('Error refreshing session:', error)
+      return null
+    } finally {
+      refreshing = null
+    }
+  })()
+  return refreshing
+}
+
+// resumeSession returns a usable access token when the app starts, renewing an
+// expired one if a refresh token is stored
+const resumeSession = async (): Promise<string | null> => {
+  const token = getCookie('token')
+  if (token) {
+    const expiresAt = tokenExpiry(token)
+    if (expiresAt) scheduleRefresh(expiresAt)
+    return token
+  }
+  return refreshSession()
+}
+
+export { saveSession, clearSession, refreshSession, resumeSession }
@@ -1,5 +1,5 @@
 import { defineStore } from 'pinia'
-import { getCookie, deleteCookie } from '../stores/cookie'
+import { clearSession, resumeSession } from './session'
 import { useShopStore } from './shop'
 
 interface User {
@@ -58,7 +58,7 @@ export const useUsersStore = defineStore('users', {
   },
   actions: {
     async checkLogin() {
-      const token = getCookie('token')
+      const token = await resumeSession()
       this.isLogin = !!token
       this.jwt = token || ''
 
@@ -144,7 +144,7 @@ export const useUsersStore = defineStore('users', {
     },
 
     logout() {
-      deleteCookie('token')
+      clearSession()
       localStorage.removeItem('employee_data')
       this.isLogin = false
       this.jwt = ''